@@ -1,7 +1,17 @@
 package api
 
+import (
+	"github.com/moira-alert/moira/prometheus"
+	"github.com/moira-alert/moira/remote"
+)
+
 // Config for api configuration variables
 type Config struct {
-	EnableCORS bool
-	Listen     string
+	EnableCORS        bool
+	Listen            string
+	MetricsTTLSeconds int64
+	// MinCheckIntervalSeconds is minimal check_interval of trigger, checker does not check triggers more often anyway
+	MinCheckIntervalSeconds int64
+	Remote                  *remote.Config
+	Prometheus              *prometheus.Config
 }
@@ -26,6 +26,15 @@ func UpdateTrigger(dataBase moira.Database, trigger *dto.TriggerModel, triggerID
 
 // saveTrigger create or update trigger data and update trigger metrics in last state
 func saveTrigger(dataBase moira.Database, trigger *moira.Trigger, triggerID string, timeSeriesNames map[string]bool) (*dto.SaveTriggerResponse, *api.ErrorResponse) {
+	if trigger.Dependencies != nil {
+		parentID, err := findDependencyCycle(dataBase, trigger, triggerID)
+		if err != nil {
+			return nil, api.ErrorInternalServer(err)
+		}
+		if parentID != "" {
+			return nil, api.ErrorInvalidRequest(fmt.Errorf("Dependencies make a cycle: parent trigger %s depends on this trigger", parentID))
+		}
+	}
 	if err := dataBase.AcquireTriggerCheckLock(triggerID, 10); err != nil {
 		return nil, api.ErrorInternalServer(err)
 	}
@@ -68,6 +77,76 @@ func saveTrigger(dataBase moira.Database, trigger *moira.Trigger, triggerID stri
 	return &resp, nil
 }
 
+// findDependencyCycle returns ID of parent trigger which depends on saved trigger directly or through its own parents,
+// empty string means dependencies have no cycle. Triggers depending on themselves by tag are ignored as checker does
+func findDependencyCycle(dataBase moira.Database, trigger *moira.Trigger, triggerID string) (string, error) {
+	dependsOnTrigger := func(dependencies *moira.TriggerDependencies) bool {
+		if dependencies == nil {
+			return false
+		}
+		for _, parentID := range dependencies.TriggerIDs {
+			if parentID == triggerID {
+				return true
+			}
+		}
+		for _, parentTag := range dependencies.Tags {
+			for _, tag := range trigger.Tags {
+				if parentTag == tag {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	visited := map[string]bool{triggerID: true}
+	parentIDs, err := getDependencyTriggerIDs(dataBase, trigger.Dependencies)
+	if err != nil {
+		return "", err
+	}
+	for len(parentIDs) > 0 {
+		parentID := parentIDs[0]
+		parentIDs = parentIDs[1:]
+		if visited[parentID] {
+			continue
+		}
+		visited[parentID] = true
+		parent, err := dataBase.GetTrigger(parentID)
+		if err != nil {
+			if err == database.ErrNil {
+				continue
+			}
+			return "", err
+		}
+		if dependsOnTrigger(parent.Dependencies) {
+			return parentID, nil
+		}
+		grandParentIDs, err := getDependencyTriggerIDs(dataBase, parent.Dependencies)
+		if err != nil {
+			return "", err
+		}
+		parentIDs = append(parentIDs, grandParentIDs...)
+	}
+	return "", nil
+}
+
+// getDependencyTriggerIDs returns IDs of parent triggers given by IDs and by tags
+func getDependencyTriggerIDs(dataBase moira.Database, dependencies *moira.TriggerDependencies) ([]string, error) {
+	if dependencies == nil {
+		return nil, nil
+	}
+	parentIDs := make([]string, 0, len(dependencies.TriggerIDs))
+	parentIDs = append(parentIDs, dependencies.TriggerIDs...)
+	for _, tag := range dependencies.Tags {
+		tagTriggerIDs, err := dataBase.GetTagTriggerIDs(tag)
+		if err != nil {
+			return nil, err
+		}
+		parentIDs = append(parentIDs, tagTriggerIDs...)
+	}
+	return parentIDs, nil
+}
+
 // GetTrigger gets trigger with his throttling - next allowed message time
 func GetTrigger(dataBase moira.Database, triggerID string) (*dto.Trigger, *api.ErrorResponse) {
 	trigger, err := dataBase.GetTrigger(triggerID)
@@ -103,14 +182,43 @@ func RemoveTrigger(database moira.Database, triggerID string) *api.ErrorResponse
 	return nil
 }
 
-// GetTriggerThrottling gets trigger throttling timestamp
+// GetTriggerThrottling gets trigger throttling timestamp, beginning of throttling window, throttling reason
+// and throttling timestamps of subscriptions with own throttling levels
 func GetTriggerThrottling(database moira.Database, triggerID string) (*dto.ThrottlingResponse, *api.ErrorResponse) {
-	throttling, _ := database.GetTriggerThrottling(triggerID)
+	subscriptionsThrottling, err := getSubscriptionsThrottling(database, triggerID)
+	if err != nil {
+		return nil, api.ErrorInternalServer(err)
+	}
+	throttling, beginning := database.GetTriggerThrottling(triggerID)
 	throttlingUnix := throttling.Unix()
 	if throttlingUnix < time.Now().Unix() {
-		throttlingUnix = 0
+		return &dto.ThrottlingResponse{Throttling: 0, Beginning: beginning.Unix(), Subscriptions: subscriptionsThrottling}, nil
+	}
+	reason, err := database.GetTriggerThrottlingReason(triggerID)
+	if err != nil {
+		return nil, api.ErrorInternalServer(err)
 	}
-	return &dto.ThrottlingResponse{Throttling: throttlingUnix}, nil
+	return &dto.ThrottlingResponse{Throttling: throttlingUnix, Beginning: beginning.Unix(), Reason: reason, Subscriptions: subscriptionsThrottling}, nil
+}
+
+// getSubscriptionsThrottling returns throttling timestamps of subscriptions which are still throttled, nil if there are none
+func getSubscriptionsThrottling(database moira.Database, triggerID string) (map[string]int64, error) {
+	throttling, err := database.GetSubscriptionsThrottling(triggerID)
+	if err != nil {
+		return nil, err
+	}
+	var subscriptionsThrottling map[string]int64
+	now := time.Now().Unix()
+	for subscriptionID, next := range throttling {
+		if next.Unix() < now {
+			continue
+		}
+		if subscriptionsThrottling == nil {
+			subscriptionsThrottling = make(map[string]int64)
+		}
+		subscriptionsThrottling[subscriptionID] = next.Unix()
+	}
+	return subscriptionsThrottling, nil
 }
 
 // GetTriggerLastCheck gets trigger last check data
@@ -201,8 +309,54 @@ func SetMetricsMaintenance(database moira.Database, triggerID string, metricsMai
 	return nil
 }
 
-// GetTriggerMetrics gets all trigger metrics values, default values from: now - 10min, to: now
-func GetTriggerMetrics(dataBase moira.Database, from, to int64, triggerID string) (dto.TriggerMetrics, *api.ErrorResponse) {
+// AcknowledgeTrigger records user's acknowledgement of trigger metric or whole trigger
+// and cancels pending escalations of acknowledged scope
+func AcknowledgeTrigger(database moira.Database, triggerID string, acknowledgement dto.TriggerAcknowledgement, userLogin string) *api.ErrorResponse {
+	ack := &moira.Acknowledgement{
+		User:      userLogin,
+		Timestamp: time.Now().Unix(),
+		Until:     acknowledgement.Until,
+	}
+	if err := setTriggerCheckAcknowledgement(database, triggerID, acknowledgement.Metric, ack); err != nil {
+		return acknowledgementErrorResponse(triggerID, acknowledgement.Metric, err)
+	}
+	if _, err := database.RemoveEscalationNotifications(triggerID, acknowledgement.Metric); err != nil {
+		return api.ErrorInternalServer(err)
+	}
+	return nil
+}
+
+// RemoveTriggerAcknowledgement removes acknowledgement of trigger metric or whole trigger if metric is empty
+func RemoveTriggerAcknowledgement(database moira.Database, triggerID, metric string) *api.ErrorResponse {
+	if err := setTriggerCheckAcknowledgement(database, triggerID, metric, nil); err != nil {
+		return acknowledgementErrorResponse(triggerID, metric, err)
+	}
+	return nil
+}
+
+// setTriggerCheckAcknowledgement sets acknowledgement under trigger check lock, so it is not overwritten by check in progress
+func setTriggerCheckAcknowledgement(dataBase moira.Database, triggerID, metric string, ack *moira.Acknowledgement) error {
+	if err := dataBase.AcquireTriggerCheckLock(triggerID, 10); err != nil {
+		return err
+	}
+	defer dataBase.DeleteTriggerCheckLock(triggerID)
+	return dataBase.SetTriggerCheckAcknowledgement(triggerID, metric, ack)
+}
+
+func acknowledgementErrorResponse(triggerID, metric string, err error) *api.ErrorResponse {
+	switch err {
+	case database.ErrNil:
+		return api.ErrorNotFound(fmt.Sprintf("Trigger with ID = '%s' has no last check", triggerID))
+	case database.ErrUnknownMetric:
+		return api.ErrorNotFound(fmt.Sprintf("Trigger with ID = '%s' has no metric '%s'", triggerID, metric))
+	default:
+		return api.ErrorInternalServer(err)
+	}
+}
+
+// GetTriggerMetrics gets all trigger metrics values, default values from: now - 10min, to: now.
+// Values of the finest archive are kept by checker for metricsTTL seconds
+func GetTriggerMetrics(dataBase moira.Database, metricsTTL int64, from, to int64, triggerID string) (dto.TriggerMetrics, *api.ErrorResponse) {
 	trigger, err := dataBase.GetTrigger(triggerID)
 	if err != nil {
 		if err == database.ErrNil {
@@ -214,7 +368,7 @@ func GetTriggerMetrics(dataBase moira.Database, from, to int64, triggerID string
 	triggerMetrics := make(map[string][]moira.MetricValue)
 	isSimpleTrigger := trigger.IsSimple()
 	for _, tar := range trigger.Targets {
-		result, err := target.EvaluateTarget(dataBase, tar, from, to, isSimpleTrigger)
+		result, err := target.EvaluateTarget(dataBase, tar, from, to, isSimpleTrigger, metricsTTL)
 		if err != nil {
 			return nil, api.ErrorInternalServer(err)
 		}
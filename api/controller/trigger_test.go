@@ -53,6 +53,44 @@ func TestUpdateTrigger(t *testing.T) {
 	})
 }
 
+func TestSaveTriggerDependencies(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	dataBase := mock_moira_alert.NewMockDatabase(mockCtrl)
+
+	Convey("Direct cycle by trigger ID should be rejected", t, func() {
+		trigger := moira.Trigger{ID: "a", Dependencies: &moira.TriggerDependencies{TriggerIDs: []string{"b"}}}
+		dataBase.EXPECT().GetTrigger("b").Return(moira.Trigger{ID: "b", Dependencies: &moira.TriggerDependencies{TriggerIDs: []string{"a"}}}, nil)
+		resp, err := saveTrigger(dataBase, &trigger, "a", make(map[string]bool))
+		So(err, ShouldResemble, api.ErrorInvalidRequest(fmt.Errorf("Dependencies make a cycle: parent trigger b depends on this trigger")))
+		So(resp, ShouldBeNil)
+	})
+
+	Convey("Cycle through dependency tags should be rejected", t, func() {
+		trigger := moira.Trigger{ID: "a", Tags: []string{"db"}, Dependencies: &moira.TriggerDependencies{Tags: []string{"network"}}}
+		dataBase.EXPECT().GetTagTriggerIDs("network").Return([]string{"b"}, nil)
+		dataBase.EXPECT().GetTrigger("b").Return(moira.Trigger{ID: "b", Dependencies: &moira.TriggerDependencies{TriggerIDs: []string{"c"}}}, nil)
+		dataBase.EXPECT().GetTrigger("c").Return(moira.Trigger{ID: "c", Dependencies: &moira.TriggerDependencies{Tags: []string{"db"}}}, nil)
+		resp, err := saveTrigger(dataBase, &trigger, "a", make(map[string]bool))
+		So(err, ShouldResemble, api.ErrorInvalidRequest(fmt.Errorf("Dependencies make a cycle: parent trigger c depends on this trigger")))
+		So(resp, ShouldBeNil)
+	})
+
+	Convey("Dependencies without cycle should be saved", t, func() {
+		trigger := moira.Trigger{ID: "a", Tags: []string{"db"}, Dependencies: &moira.TriggerDependencies{TriggerIDs: []string{"b", "missing"}}}
+		dataBase.EXPECT().GetTrigger("b").Return(moira.Trigger{ID: "b", Dependencies: &moira.TriggerDependencies{TriggerIDs: []string{"b"}}}, nil)
+		dataBase.EXPECT().GetTrigger("missing").Return(moira.Trigger{}, database.ErrNil)
+		dataBase.EXPECT().AcquireTriggerCheckLock("a", 10)
+		dataBase.EXPECT().DeleteTriggerCheckLock("a")
+		dataBase.EXPECT().GetTriggerLastCheck("a").Return(moira.CheckData{}, database.ErrNil)
+		dataBase.EXPECT().SetTriggerLastCheck("a", gomock.Any()).Return(nil)
+		dataBase.EXPECT().SaveTrigger("a", &trigger).Return(nil)
+		resp, err := saveTrigger(dataBase, &trigger, "a", make(map[string]bool))
+		So(err, ShouldBeNil)
+		So(resp.ID, ShouldEqual, "a")
+	})
+}
+
 func TestSaveTrigger(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -336,6 +374,7 @@ func TestGetTriggerThrottling(t *testing.T) {
 	yesterday := now.Add(-time.Hour * 24)
 
 	Convey("no throttling", t, func() {
+		dataBase.EXPECT().GetSubscriptionsThrottling(triggerID).Return(map[string]time.Time{}, nil)
 		dataBase.EXPECT().GetTriggerThrottling(triggerID).Return(begging, begging)
 		actual, err := GetTriggerThrottling(dataBase, triggerID)
 		So(err, ShouldBeNil)
@@ -343,18 +382,47 @@ func TestGetTriggerThrottling(t *testing.T) {
 	})
 
 	Convey("has throttling", t, func() {
+		dataBase.EXPECT().GetSubscriptionsThrottling(triggerID).Return(map[string]time.Time{}, nil)
 		dataBase.EXPECT().GetTriggerThrottling(triggerID).Return(tomorrow, begging)
+		dataBase.EXPECT().GetTriggerThrottlingReason(triggerID).Return("Trigger switched 20 times in last 3h0m0s", nil)
 		actual, err := GetTriggerThrottling(dataBase, triggerID)
 		So(err, ShouldBeNil)
-		So(actual, ShouldResemble, &dto.ThrottlingResponse{Throttling: tomorrow.Unix()})
+		So(actual, ShouldResemble, &dto.ThrottlingResponse{Throttling: tomorrow.Unix(), Reason: "Trigger switched 20 times in last 3h0m0s"})
+	})
+
+	Convey("has throttling with reason error", t, func() {
+		expected := fmt.Errorf("Oooops! Error get")
+		dataBase.EXPECT().GetSubscriptionsThrottling(triggerID).Return(map[string]time.Time{}, nil)
+		dataBase.EXPECT().GetTriggerThrottling(triggerID).Return(tomorrow, yesterday)
+		dataBase.EXPECT().GetTriggerThrottlingReason(triggerID).Return("", expected)
+		actual, err := GetTriggerThrottling(dataBase, triggerID)
+		So(err, ShouldResemble, api.ErrorInternalServer(expected))
+		So(actual, ShouldBeNil)
 	})
 
 	Convey("has old throttling", t, func() {
+		dataBase.EXPECT().GetSubscriptionsThrottling(triggerID).Return(map[string]time.Time{}, nil)
 		dataBase.EXPECT().GetTriggerThrottling(triggerID).Return(yesterday, begging)
 		actual, err := GetTriggerThrottling(dataBase, triggerID)
 		So(err, ShouldBeNil)
 		So(actual, ShouldResemble, &dto.ThrottlingResponse{Throttling: 0})
 	})
+
+	Convey("has subscriptions throttling", t, func() {
+		dataBase.EXPECT().GetSubscriptionsThrottling(triggerID).Return(map[string]time.Time{"subscription1": tomorrow, "subscription2": yesterday}, nil)
+		dataBase.EXPECT().GetTriggerThrottling(triggerID).Return(yesterday, begging)
+		actual, err := GetTriggerThrottling(dataBase, triggerID)
+		So(err, ShouldBeNil)
+		So(actual, ShouldResemble, &dto.ThrottlingResponse{Throttling: 0, Subscriptions: map[string]int64{"subscription1": tomorrow.Unix()}})
+	})
+
+	Convey("has subscriptions throttling error", t, func() {
+		expected := fmt.Errorf("Oooops! Error get")
+		dataBase.EXPECT().GetSubscriptionsThrottling(triggerID).Return(nil, expected)
+		actual, err := GetTriggerThrottling(dataBase, triggerID)
+		So(err, ShouldResemble, api.ErrorInternalServer(expected))
+		So(actual, ShouldBeNil)
+	})
 }
 
 func TestGetTriggerLastCheck(t *testing.T) {
@@ -533,6 +601,66 @@ func TestSetMetricsMaintenance(t *testing.T) {
 	})
 }
 
+func TestAcknowledgeTrigger(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	dataBase := mock_moira_alert.NewMockDatabase(mockCtrl)
+	triggerID := uuid.NewV4().String()
+	acknowledgement := dto.TriggerAcknowledgement{Metric: "metric", Until: time.Now().Unix() + 3600}
+
+	Convey("Success", t, func() {
+		dataBase.EXPECT().AcquireTriggerCheckLock(triggerID, 10)
+		dataBase.EXPECT().DeleteTriggerCheckLock(triggerID)
+		dataBase.EXPECT().SetTriggerCheckAcknowledgement(triggerID, "metric", gomock.Any()).Return(nil).Do(func(triggerID, metric string, ack *moira.Acknowledgement) {
+			So(ack.User, ShouldEqual, "user")
+			So(ack.Until, ShouldEqual, acknowledgement.Until)
+		})
+		dataBase.EXPECT().RemoveEscalationNotifications(triggerID, "metric").Return(int64(1), nil)
+		err := AcknowledgeTrigger(dataBase, triggerID, acknowledgement, "user")
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Error", t, func() {
+		expected := fmt.Errorf("Oooops! Error set")
+		dataBase.EXPECT().AcquireTriggerCheckLock(triggerID, 10)
+		dataBase.EXPECT().DeleteTriggerCheckLock(triggerID)
+		dataBase.EXPECT().SetTriggerCheckAcknowledgement(triggerID, "metric", gomock.Any()).Return(expected)
+		err := AcknowledgeTrigger(dataBase, triggerID, acknowledgement, "user")
+		So(err, ShouldResemble, api.ErrorInternalServer(expected))
+	})
+
+	Convey("No last check", t, func() {
+		dataBase.EXPECT().AcquireTriggerCheckLock(triggerID, 10)
+		dataBase.EXPECT().DeleteTriggerCheckLock(triggerID)
+		dataBase.EXPECT().SetTriggerCheckAcknowledgement(triggerID, "metric", gomock.Any()).Return(database.ErrNil)
+		err := AcknowledgeTrigger(dataBase, triggerID, acknowledgement, "user")
+		So(err, ShouldResemble, api.ErrorNotFound(fmt.Sprintf("Trigger with ID = '%s' has no last check", triggerID)))
+	})
+
+	Convey("Unknown metric", t, func() {
+		dataBase.EXPECT().AcquireTriggerCheckLock(triggerID, 10)
+		dataBase.EXPECT().DeleteTriggerCheckLock(triggerID)
+		dataBase.EXPECT().SetTriggerCheckAcknowledgement(triggerID, "metric", gomock.Any()).Return(database.ErrUnknownMetric)
+		err := AcknowledgeTrigger(dataBase, triggerID, acknowledgement, "user")
+		So(err, ShouldResemble, api.ErrorNotFound(fmt.Sprintf("Trigger with ID = '%s' has no metric 'metric'", triggerID)))
+	})
+
+	Convey("AcquireTriggerCheckLock error", t, func() {
+		expected := fmt.Errorf("AcquireTriggerCheckLock error")
+		dataBase.EXPECT().AcquireTriggerCheckLock(triggerID, 10).Return(expected)
+		err := AcknowledgeTrigger(dataBase, triggerID, acknowledgement, "user")
+		So(err, ShouldResemble, api.ErrorInternalServer(expected))
+	})
+
+	Convey("Remove acknowledgement", t, func() {
+		dataBase.EXPECT().AcquireTriggerCheckLock(triggerID, 10)
+		dataBase.EXPECT().DeleteTriggerCheckLock(triggerID)
+		dataBase.EXPECT().SetTriggerCheckAcknowledgement(triggerID, "", nil).Return(nil)
+		err := RemoveTriggerAcknowledgement(dataBase, triggerID, "")
+		So(err, ShouldBeNil)
+	})
+}
+
 func TestGetTriggerMetrics(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -579,7 +707,7 @@ func TestGetTriggerMetrics(t *testing.T) {
 		dataBase.EXPECT().GetPatternMetrics(pattern).Return([]string{metric}, nil)
 		dataBase.EXPECT().GetMetricRetention(metric).Return(retention, nil)
 		dataBase.EXPECT().GetMetricsValues([]string{metric}, from, until).Return(dataList, nil)
-		triggerMetrics, err := GetTriggerMetrics(dataBase, from, until, triggerID)
+		triggerMetrics, err := GetTriggerMetrics(dataBase, 0, from, until, triggerID)
 		So(err, ShouldBeNil)
 		So(triggerMetrics, ShouldResemble, dto.TriggerMetrics(map[string][]moira.MetricValue{metric: {{Value: 0, Timestamp: 17}, {Value: 1, Timestamp: 27}, {Value: 2, Timestamp: 37}, {Value: 3, Timestamp: 47}, {Value: 4, Timestamp: 57}}}))
 	})
@@ -587,14 +715,14 @@ func TestGetTriggerMetrics(t *testing.T) {
 	Convey("GetTrigger error", t, func() {
 		expected := fmt.Errorf("Get trigger error")
 		dataBase.EXPECT().GetTrigger(triggerID).Return(moira.Trigger{}, expected)
-		triggerMetrics, err := GetTriggerMetrics(dataBase, from, until, triggerID)
+		triggerMetrics, err := GetTriggerMetrics(dataBase, 0, from, until, triggerID)
 		So(err, ShouldResemble, api.ErrorInternalServer(expected))
 		So(triggerMetrics, ShouldBeNil)
 	})
 
 	Convey("No trigger", t, func() {
 		dataBase.EXPECT().GetTrigger(triggerID).Return(moira.Trigger{}, database.ErrNil)
-		triggerMetrics, err := GetTriggerMetrics(dataBase, from, until, triggerID)
+		triggerMetrics, err := GetTriggerMetrics(dataBase, 0, from, until, triggerID)
 		So(err, ShouldResemble, api.ErrorInvalidRequest(fmt.Errorf("Trigger not found")))
 		So(triggerMetrics, ShouldBeNil)
 	})
@@ -605,7 +733,7 @@ func TestGetTriggerMetrics(t *testing.T) {
 		dataBase.EXPECT().GetPatternMetrics(pattern).Return([]string{metric}, nil)
 		dataBase.EXPECT().GetMetricRetention(metric).Return(retention, nil)
 		dataBase.EXPECT().GetMetricsValues([]string{metric}, from, until).Return(nil, expected)
-		triggerMetrics, err := GetTriggerMetrics(dataBase, from, until, triggerID)
+		triggerMetrics, err := GetTriggerMetrics(dataBase, 0, from, until, triggerID)
 		So(err, ShouldResemble, api.ErrorInternalServer(expected))
 		So(triggerMetrics, ShouldBeNil)
 	})
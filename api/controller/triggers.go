@@ -1,13 +1,16 @@
 package controller
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/satori/go.uuid"
 
 	"github.com/moira-alert/moira"
 	"github.com/moira-alert/moira/api"
 	"github.com/moira-alert/moira/api/dto"
+	"github.com/moira-alert/moira/checker"
 	"github.com/moira-alert/moira/database"
 )
 
@@ -31,6 +34,36 @@ func CreateTrigger(dataBase moira.Database, trigger *dto.TriggerModel, timeSerie
 	return resp, err
 }
 
+// backtestTimeout limits time of single backtest, remote and prometheus triggers fetch data on every replayed check
+const backtestTimeout = time.Minute
+
+// BacktestTrigger replays checks of given trigger on historical data and returns events it would have generated,
+// trigger state and events are not saved. Backtest stops when ctx is done or backtestTimeout is exceeded
+func BacktestTrigger(ctx context.Context, dataBase moira.Database, logger moira.Logger, config *api.Config, trigger *dto.TriggerModel, from, to, step int64) (*dto.TriggerBacktest, *api.ErrorResponse) {
+	ctx, cancel := context.WithTimeout(ctx, backtestTimeout)
+	defer cancel()
+
+	triggerChecker := checker.TriggerChecker{
+		Database:         dataBase,
+		Logger:           logger,
+		Config:           &checker.Config{MetricsTTLSeconds: config.MetricsTTLSeconds},
+		RemoteConfig:     config.Remote,
+		PrometheusConfig: config.Prometheus,
+	}
+	result, err := triggerChecker.Backtest(ctx, trigger.ToMoiraTrigger(), from, to, step)
+	if err != nil {
+		if _, ok := err.(checker.ErrWrongBacktestInterval); ok {
+			return nil, api.ErrorInvalidRequest(err)
+		}
+		if err == context.DeadlineExceeded {
+			return nil, api.ErrorInvalidRequest(fmt.Errorf("Backtest did not finish in %v, use shorter interval or bigger step", backtestTimeout))
+		}
+		return nil, api.ErrorInternalServer(err)
+	}
+	backtest := dto.TriggerBacktest(*result)
+	return &backtest, nil
+}
+
 func isTriggerExists(dataBase moira.Database, triggerID string) (bool, error) {
 	_, err := dataBase.GetTrigger(triggerID)
 	if err == database.ErrNil {
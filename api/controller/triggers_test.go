@@ -1,13 +1,16 @@
 package controller
 
 import (
+	"context"
 	"fmt"
 	"github.com/golang/mock/gomock"
 	"github.com/moira-alert/moira"
 	"github.com/moira-alert/moira/api"
 	"github.com/moira-alert/moira/api/dto"
+	"github.com/moira-alert/moira/checker"
 	"github.com/moira-alert/moira/database"
 	"github.com/moira-alert/moira/mock/moira-alert"
+	"github.com/op/go-logging"
 	"github.com/satori/go.uuid"
 	. "github.com/smartystreets/goconvey/convey"
 	"testing"
@@ -78,6 +81,22 @@ func TestCreateTrigger(t *testing.T) {
 	})
 }
 
+func TestBacktestTrigger(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	dataBase := mock_moira_alert.NewMockDatabase(mockCtrl)
+	logger, _ := logging.GetLogger("Test")
+	config := &api.Config{}
+
+	Convey("Wrong backtest interval", t, func() {
+		triggerModel := dto.TriggerModel{Targets: []string{"my.metric"}}
+		resp, err := BacktestTrigger(context.Background(), dataBase, logger, config, &triggerModel, 600, 0, 60)
+		So(resp, ShouldBeNil)
+		So(err.HTTPStatusCode, ShouldEqual, 400)
+		So(err.Err, ShouldHaveSameTypeAs, checker.ErrWrongBacktestInterval{})
+	})
+}
+
 func TestGetAllTriggers(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -28,5 +28,24 @@ func (subscription *Subscription) Bind(r *http.Request) error {
 	if len(subscription.Contacts) == 0 {
 		return fmt.Errorf("Subscription must have contacts")
 	}
+	if subscription.ReminderInterval != nil && *subscription.ReminderInterval < 0 {
+		return fmt.Errorf("Subscription reminder interval can not be negative")
+	}
+	for _, escalation := range subscription.Escalations {
+		if len(escalation.Contacts) == 0 {
+			return fmt.Errorf("Subscription escalation must have contacts")
+		}
+		if escalation.OffsetInSeconds <= 0 {
+			return fmt.Errorf("Subscription escalation offset must be positive")
+		}
+	}
+	if subscription.DigestInterval < 0 {
+		return fmt.Errorf("Subscription digest interval can not be negative")
+	}
+	for _, level := range subscription.ThrottlingLevels {
+		if level.Duration <= 0 || level.Delay <= 0 || level.Count <= 0 {
+			return fmt.Errorf("Subscription throttling level duration, delay and count must be positive")
+		}
+	}
 	return nil
 }
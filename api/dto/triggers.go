@@ -4,13 +4,16 @@ package dto
 import (
 	"fmt"
 	"net/http"
-	"time"
 	"strings"
+	"time"
+
+	"github.com/go-graphite/carbonapi/pkg/parser"
 
 	"github.com/moira-alert/moira"
 	"github.com/moira-alert/moira/api/middleware"
 	"github.com/moira-alert/moira/checker"
 	"github.com/moira-alert/moira/expression"
+	"github.com/moira-alert/moira/prometheus"
 	"github.com/moira-alert/moira/target"
 )
 
@@ -44,6 +47,26 @@ type TriggerModel struct {
 	Schedule   *moira.ScheduleData `json:"sched,omitempty"`
 	Expression string              `json:"expression"`
 	Patterns   []string            `json:"patterns"`
+	// TriggerSource is graphite_local, graphite_remote or prometheus_remote, targets of prometheus triggers are PromQL queries
+	TriggerSource moira.TriggerSource `json:"trigger_source,omitempty"`
+	// Metric leaves WARN or ERROR state only when its value crosses recovery value
+	WarnRecoveryValue  *float64 `json:"warn_recovery_value,omitempty"`
+	ErrorRecoveryValue *float64 `json:"error_recovery_value,omitempty"`
+	// Metric switches to new state only after it is received for ConfirmPoints consecutive points and ConfirmDuration seconds
+	ConfirmPoints   int64 `json:"confirm_points,omitempty"`
+	ConfirmDuration int64 `json:"confirm_duration,omitempty"`
+	// Anomaly detection trigger state is defined by deviation from baseline instead of warn and error values
+	Anomaly *moira.AnomalyDetection `json:"anomaly,omitempty"`
+	// StateAggregation defines trigger state by number or percent of metrics in bad states
+	StateAggregation *moira.StateAggregation `json:"state_aggregation,omitempty"`
+	// ReminderIntervals override default intervals in seconds between reminders for WARN, ERROR and NODATA states, 0 means never.
+	// Subscription reminder_interval can only make reminders less frequent than these intervals
+	ReminderIntervals map[string]int64 `json:"reminder_intervals,omitempty"`
+	// CheckInterval in seconds or cron-like CheckSchedule in UTC make trigger checked on schedule instead of incoming metrics
+	CheckInterval int64  `json:"check_interval,omitempty"`
+	CheckSchedule string `json:"check_schedule,omitempty"`
+	// Dependencies are parent triggers given by IDs or tags, events are suppressed while any parent is in bad state
+	Dependencies *moira.TriggerDependencies `json:"dependencies,omitempty"`
 }
 
 // ToMoiraTrigger transforms TriggerModel to moira.Trigger
@@ -61,6 +84,19 @@ func (model *TriggerModel) ToMoiraTrigger() *moira.Trigger {
 		Schedule:   model.Schedule,
 		Expression: &model.Expression,
 		Patterns:   model.Patterns,
+
+		TriggerSource: model.TriggerSource,
+
+		WarnRecoveryValue:  model.WarnRecoveryValue,
+		ErrorRecoveryValue: model.ErrorRecoveryValue,
+		ConfirmPoints:      model.ConfirmPoints,
+		ConfirmDuration:    model.ConfirmDuration,
+		Anomaly:            model.Anomaly,
+		StateAggregation:   model.StateAggregation,
+		ReminderIntervals:  model.ReminderIntervals,
+		CheckInterval:      model.CheckInterval,
+		CheckSchedule:      model.CheckSchedule,
+		Dependencies:       model.Dependencies,
 	}
 }
 
@@ -79,6 +115,19 @@ func CreateTriggerModel(trigger *moira.Trigger) TriggerModel {
 		Schedule:   trigger.Schedule,
 		Expression: moira.UseString(trigger.Expression),
 		Patterns:   trigger.Patterns,
+
+		TriggerSource: trigger.TriggerSource,
+
+		WarnRecoveryValue:  trigger.WarnRecoveryValue,
+		ErrorRecoveryValue: trigger.ErrorRecoveryValue,
+		ConfirmPoints:      trigger.ConfirmPoints,
+		ConfirmDuration:    trigger.ConfirmDuration,
+		Anomaly:            trigger.Anomaly,
+		StateAggregation:   trigger.StateAggregation,
+		ReminderIntervals:  trigger.ReminderIntervals,
+		CheckInterval:      trigger.CheckInterval,
+		CheckSchedule:      trigger.CheckSchedule,
+		Dependencies:       trigger.Dependencies,
 	}
 }
 
@@ -97,20 +146,64 @@ func (trigger *Trigger) Bind(request *http.Request) error {
 	if trigger.Name == "" {
 		return fmt.Errorf("trigger name is required")
 	}
-	if trigger.WarnValue == nil && trigger.Expression == "" {
-		return fmt.Errorf("warn_value is required")
+	if trigger.Anomaly != nil {
+		if err := checkAnomalyDetection(trigger.Anomaly); err != nil {
+			return err
+		}
+	} else {
+		if trigger.WarnValue == nil && trigger.Expression == "" {
+			return fmt.Errorf("warn_value is required")
+		}
+		if trigger.ErrorValue == nil && trigger.Expression == "" {
+			return fmt.Errorf("error_value is required")
+		}
 	}
-	if trigger.ErrorValue == nil && trigger.Expression == "" {
-		return fmt.Errorf("error_value is required")
+	switch trigger.TriggerSource {
+	case "", moira.GraphiteLocal, moira.GraphiteRemote, moira.PrometheusRemote:
+	default:
+		return fmt.Errorf("unknown trigger_source '%s'", trigger.TriggerSource)
+	}
+	if trigger.Expression == "" {
+		if err := expression.ValidateRecoveryValues(trigger.WarnValue, trigger.ErrorValue, trigger.WarnRecoveryValue, trigger.ErrorRecoveryValue); err != nil {
+			return err
+		}
+	}
+	if trigger.ConfirmPoints < 0 || trigger.ConfirmDuration < 0 {
+		return fmt.Errorf("confirm_points and confirm_duration can not be negative")
+	}
+	if trigger.StateAggregation != nil {
+		if err := checkStateAggregation(trigger.StateAggregation); err != nil {
+			return err
+		}
+	}
+	if err := checkReminderIntervals(trigger.ReminderIntervals); err != nil {
+		return err
+	}
+	if err := checkCheckSchedule(trigger.CheckInterval, trigger.CheckSchedule, middleware.GetConfig(request).MinCheckIntervalSeconds); err != nil {
+		return err
+	}
+	if trigger.Dependencies != nil {
+		if err := checkDependencies(trigger.ID, trigger.Dependencies); err != nil {
+			return err
+		}
 	}
 
 	triggerExpression := expression.TriggerExpression{
 		AdditionalTargetsValues: make(map[string]float64),
 		WarnValue:               trigger.WarnValue,
 		ErrorValue:              trigger.ErrorValue,
+		WarnRecoveryValue:       trigger.WarnRecoveryValue,
+		ErrorRecoveryValue:      trigger.ErrorRecoveryValue,
 		PreviousState:           checker.NODATA,
 		Expression:              &trigger.Expression,
 	}
+	if trigger.Anomaly != nil {
+		value := float64(42)
+		triggerExpression.Baseline = &value
+		triggerExpression.Deviation = &value
+		triggerExpression.WarnDeviation = &trigger.Anomaly.WarnDeviation
+		triggerExpression.ErrorDeviation = &trigger.Anomaly.ErrorDeviation
+	}
 
 	if err := resolvePatterns(request, trigger, &triggerExpression); err != nil {
 		return err
@@ -127,9 +220,23 @@ func resolvePatterns(request *http.Request, trigger *Trigger, expressionValues *
 	trigger.Patterns = make([]string, 0)
 	timeSeriesNames := make(map[string]bool)
 
+	source := trigger.ToMoiraTrigger().GetTriggerSource()
+
 	for _, tar := range trigger.Targets {
+		if source != moira.GraphiteLocal {
+			if err := validateRemoteTarget(source, tar); err != nil {
+				return err
+			}
+			if targetNum == 1 {
+				expressionValues.MainTargetValue = 42
+			} else {
+				expressionValues.AdditionalTargetsValues[fmt.Sprintf("t%v", targetNum)] = 42
+			}
+			targetNum++
+			continue
+		}
 		database := middleware.GetDatabase(request)
-		result, err := target.EvaluateTarget(database, tar, now-600, now, false)
+		result, err := target.EvaluateTarget(database, tar, now-600, now, false, 0)
 		if err != nil {
 			return err
 		}
@@ -151,6 +258,106 @@ func resolvePatterns(request *http.Request, trigger *Trigger, expressionValues *
 	return nil
 }
 
+// validateRemoteTarget checks syntax of target evaluated by remote storage,
+// remote data can not be fetched by api to resolve patterns
+func validateRemoteTarget(source moira.TriggerSource, tar string) error {
+	if source == moira.PrometheusRemote {
+		if err := prometheus.ValidateQuery(tar); err != nil {
+			return fmt.Errorf("invalid PromQL query '%s': %s", tar, err.Error())
+		}
+		return nil
+	}
+	if _, _, err := parser.ParseExpr(tar); err != nil {
+		return fmt.Errorf("invalid graphite target '%s': %s", tar, err.Error())
+	}
+	return nil
+}
+
+// defaultAnomalySeason is a week, to compare metric values with the same time and day of week
+const defaultAnomalySeason = 7 * 24 * 60 * 60
+
+func checkAnomalyDetection(anomaly *moira.AnomalyDetection) error {
+	switch anomaly.Method {
+	case moira.RollingAnomaly:
+	case moira.SeasonalAnomaly:
+		if anomaly.Season == 0 {
+			anomaly.Season = defaultAnomalySeason
+		}
+		if anomaly.Season < 0 {
+			return fmt.Errorf("anomaly season can not be negative")
+		}
+	default:
+		return fmt.Errorf("unknown anomaly method '%s'", anomaly.Method)
+	}
+	if anomaly.Window <= 0 {
+		return fmt.Errorf("anomaly window is required")
+	}
+	if anomaly.WarnDeviation <= 0 || anomaly.ErrorDeviation < anomaly.WarnDeviation {
+		return fmt.Errorf("anomaly warn_deviation must be positive and not greater than error_deviation")
+	}
+	return nil
+}
+
+func checkStateAggregation(aggregation *moira.StateAggregation) error {
+	if aggregation.WarnValue == nil && aggregation.ErrorValue == nil {
+		return fmt.Errorf("state aggregation requires warn_value or error_value")
+	}
+	for _, value := range []*float64{aggregation.WarnValue, aggregation.ErrorValue} {
+		if value != nil && (*value <= 0 || aggregation.Percent && *value > 100) {
+			return fmt.Errorf("state aggregation values must be positive and percents can not be greater than 100")
+		}
+	}
+	return nil
+}
+
+func checkReminderIntervals(intervals map[string]int64) error {
+	for state, interval := range intervals {
+		switch state {
+		case checker.WARN, checker.ERROR, checker.NODATA:
+		default:
+			return fmt.Errorf("reminder intervals can be set only for WARN, ERROR and NODATA states, got '%s'", state)
+		}
+		if interval < 0 {
+			return fmt.Errorf("reminder interval for %s state can not be negative", state)
+		}
+	}
+	return nil
+}
+
+func checkCheckSchedule(checkInterval int64, checkSchedule string, minCheckInterval int64) error {
+	if checkInterval < 0 {
+		return fmt.Errorf("check_interval can not be negative")
+	}
+	if checkInterval != 0 && checkInterval < minCheckInterval {
+		return fmt.Errorf("check_interval can not be less than %d seconds", minCheckInterval)
+	}
+	if checkSchedule == "" {
+		return nil
+	}
+	if checkInterval != 0 {
+		return fmt.Errorf("only one of check_interval and check_schedule can be set")
+	}
+	_, err := checker.ParseCronSchedule(checkSchedule)
+	return err
+}
+
+func checkDependencies(triggerID string, dependencies *moira.TriggerDependencies) error {
+	for _, parentID := range dependencies.TriggerIDs {
+		if parentID == "" {
+			return fmt.Errorf("dependency trigger id can not be empty")
+		}
+		if parentID == triggerID {
+			return fmt.Errorf("trigger can not depend on itself")
+		}
+	}
+	for _, tag := range dependencies.Tags {
+		if tag == "" {
+			return fmt.Errorf("dependency tag can not be empty")
+		}
+	}
+	return nil
+}
+
 func checkTriggerTags(tags []string) []string {
 	reservedTagsFound := make([]string, 0)
 	for _, tag := range tags {
@@ -162,6 +369,13 @@ func checkTriggerTags(tags []string) []string {
 	return reservedTagsFound
 }
 
+// TriggerBacktest is trigger events and states replayed on historical data
+type TriggerBacktest checker.BacktestResult
+
+func (*TriggerBacktest) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
 func (*Trigger) Render(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
@@ -181,8 +395,27 @@ func (*MetricsMaintenance) Bind(r *http.Request) error {
 	return nil
 }
 
+// TriggerAcknowledgement acknowledges metric of trigger or whole trigger if metric is empty until given timestamp
+type TriggerAcknowledgement struct {
+	Metric string `json:"metric,omitempty"`
+	Until  int64  `json:"until"`
+}
+
+func (acknowledgement *TriggerAcknowledgement) Bind(r *http.Request) error {
+	if acknowledgement.Until <= time.Now().Unix() {
+		return fmt.Errorf("acknowledgement until must be in future")
+	}
+	return nil
+}
+
 type ThrottlingResponse struct {
 	Throttling int64 `json:"throttling"`
+	// Beginning is start of current throttling window, trigger events are counted since it
+	Beginning int64 `json:"beginning,omitempty"`
+	// Reason describes why notifications are delayed until Throttling
+	Reason string `json:"reason,omitempty"`
+	// Subscriptions maps IDs of subscriptions with own throttling levels to their throttling timestamps
+	Subscriptions map[string]int64 `json:"subscriptions,omitempty"`
 }
 
 func (*ThrottlingResponse) Render(w http.ResponseWriter, r *http.Request) error {
@@ -15,6 +15,8 @@ import (
 )
 
 var database moira.Database
+var logger moira.Logger
+var apiConfig *api.Config
 
 const contactKey moira_middle.ContextKey = "contact"
 const subscriptionKey moira_middle.ContextKey = "subscription"
@@ -22,6 +24,8 @@ const subscriptionKey moira_middle.ContextKey = "subscription"
 // NewHandler creates new api handler request uris based on github.com/go-chi/chi
 func NewHandler(db moira.Database, log moira.Logger, config *api.Config, configFile []byte) http.Handler {
 	database = db
+	logger = log
+	apiConfig = config
 	router := chi.NewRouter()
 	router.Use(render.SetContentType(render.ContentTypeJSON))
 	router.Use(moira_middle.UserContext)
@@ -33,6 +37,7 @@ func NewHandler(db moira.Database, log moira.Logger, config *api.Config, configF
 
 	router.Route("/api", func(router chi.Router) {
 		router.Use(moira_middle.DatabaseContext(database))
+		router.Use(moira_middle.ConfigContext(apiConfig))
 		router.Get("/config", webConfig(configFile))
 		router.Route("/user", user)
 		router.Route("/trigger", triggers)
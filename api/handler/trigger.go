@@ -32,6 +32,10 @@ func trigger(router chi.Router) {
 		router.Delete("/", deleteTriggerMetric)
 	})
 	router.Put("/maintenance", setMetricsMaintenance)
+	router.Route("/acknowledge", func(router chi.Router) {
+		router.Put("/", acknowledgeTrigger)
+		router.Delete("/", removeTriggerAcknowledgement)
+	})
 }
 
 func updateTrigger(writer http.ResponseWriter, request *http.Request) {
@@ -131,7 +135,7 @@ func getTriggerMetrics(writer http.ResponseWriter, request *http.Request) {
 		render.Render(writer, request, api.ErrorInvalidRequest(fmt.Errorf("Can not parse to: %v", to)))
 		return
 	}
-	triggerMetrics, err := controller.GetTriggerMetrics(database, int64(from), int64(to), triggerID)
+	triggerMetrics, err := controller.GetTriggerMetrics(database, apiConfig.MetricsTTLSeconds, int64(from), int64(to), triggerID)
 	if err != nil {
 		render.Render(writer, request, err)
 		return
@@ -149,6 +153,27 @@ func deleteTriggerMetric(writer http.ResponseWriter, request *http.Request) {
 	}
 }
 
+func acknowledgeTrigger(writer http.ResponseWriter, request *http.Request) {
+	triggerID := middleware.GetTriggerID(request)
+	acknowledgement := dto.TriggerAcknowledgement{}
+	if err := render.Bind(request, &acknowledgement); err != nil {
+		render.Render(writer, request, api.ErrorInvalidRequest(err))
+		return
+	}
+	userLogin := middleware.GetLogin(request)
+	if err := controller.AcknowledgeTrigger(database, triggerID, acknowledgement, userLogin); err != nil {
+		render.Render(writer, request, err)
+	}
+}
+
+func removeTriggerAcknowledgement(writer http.ResponseWriter, request *http.Request) {
+	triggerID := middleware.GetTriggerID(request)
+	metric := request.URL.Query().Get("metric")
+	if err := controller.RemoveTriggerAcknowledgement(database, triggerID, metric); err != nil {
+		render.Render(writer, request, err)
+	}
+}
+
 func setMetricsMaintenance(writer http.ResponseWriter, request *http.Request) {
 	triggerID := middleware.GetTriggerID(request)
 	metricsMaintenance := dto.MetricsMaintenance{}
@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/render"
+	"github.com/go-graphite/carbonapi/date"
 
 	"github.com/moira-alert/moira/api"
 	"github.com/moira-alert/moira/api/controller"
@@ -19,6 +21,7 @@ import (
 func triggers(router chi.Router) {
 	router.Get("/", getAllTriggers)
 	router.Put("/", createTrigger)
+	router.With(middleware.DateRange("-1day", "now")).Post("/backtest", backtestTrigger)
 	router.With(middleware.Paginate(0, 10)).Get("/page", getTriggersPage)
 	router.Route("/{triggerId}", trigger)
 }
@@ -62,6 +65,59 @@ func createTrigger(writer http.ResponseWriter, request *http.Request) {
 	}
 }
 
+func backtestTrigger(writer http.ResponseWriter, request *http.Request) {
+	trigger := &dto.Trigger{}
+	if err := render.Bind(request, trigger); err != nil {
+		switch err.(type) {
+		case target.ErrParseExpr, target.ErrEvalExpr, target.ErrUnknownFunction:
+			render.Render(writer, request, api.ErrorInvalidRequest(fmt.Errorf("Invalid graphite targets: %s", err.Error())))
+		case expression.ErrInvalidExpression:
+			render.Render(writer, request, api.ErrorInvalidRequest(fmt.Errorf("Invalid expression: %s", err.Error())))
+		default:
+			render.Render(writer, request, api.ErrorInternalServer(err))
+		}
+		return
+	}
+	fromStr := middleware.GetFromStr(request)
+	toStr := middleware.GetToStr(request)
+	from := date.DateParamToEpoch(fromStr, "UTC", 0, time.UTC)
+	if from == 0 {
+		render.Render(writer, request, api.ErrorInvalidRequest(fmt.Errorf("Can not parse from: %s", fromStr)))
+		return
+	}
+	to := date.DateParamToEpoch(toStr, "UTC", 0, time.UTC)
+	if to == 0 {
+		render.Render(writer, request, api.ErrorInvalidRequest(fmt.Errorf("Can not parse to: %s", toStr)))
+		return
+	}
+	step, err := getBacktestStep(request)
+	if err != nil {
+		render.Render(writer, request, api.ErrorInvalidRequest(err))
+		return
+	}
+	backtest, errorResponse := controller.BacktestTrigger(request.Context(), database, logger, apiConfig, &trigger.TriggerModel, int64(from), int64(to), step)
+	if errorResponse != nil {
+		render.Render(writer, request, errorResponse)
+		return
+	}
+	if err := render.Render(writer, request, backtest); err != nil {
+		render.Render(writer, request, api.ErrorRender(err))
+	}
+}
+
+// getBacktestStep returns interval in seconds between replayed checks, one minute by default
+func getBacktestStep(request *http.Request) (int64, error) {
+	stepStr := request.URL.Query().Get("step")
+	if stepStr == "" {
+		return 60, nil
+	}
+	step, err := strconv.ParseInt(stepStr, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Can not parse step: %s", stepStr)
+	}
+	return step, nil
+}
+
 func getTriggersPage(writer http.ResponseWriter, request *http.Request) {
 	request.ParseForm()
 	onlyErrors := getOnlyProblemsFlag(request)
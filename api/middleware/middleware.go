@@ -3,6 +3,7 @@ package middleware
 import (
 	"context"
 	"github.com/moira-alert/moira"
+	"github.com/moira-alert/moira/api"
 	"net/http"
 )
 
@@ -15,6 +16,7 @@ func (key ContextKey) String() string {
 
 var (
 	databaseKey        ContextKey = "database"
+	configKey          ContextKey = "config"
 	triggerIDKey       ContextKey = "triggerID"
 	contactIDKey       ContextKey = "contactID"
 	tagKey             ContextKey = "tag"
@@ -32,6 +34,11 @@ func GetDatabase(request *http.Request) moira.Database {
 	return request.Context().Value(databaseKey).(moira.Database)
 }
 
+// GetConfig gets api configuration from request context, which was sets in ConfigContext middleware
+func GetConfig(request *http.Request) *api.Config {
+	return request.Context().Value(configKey).(*api.Config)
+}
+
 // GetLogin gets user login string from request context, which was sets in UserContext middleware
 func GetLogin(request *http.Request) string {
 	return request.Context().Value(loginKey).(string)
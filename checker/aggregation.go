@@ -0,0 +1,45 @@
+package checker
+
+import (
+	"fmt"
+
+	"github.com/moira-alert/moira"
+)
+
+// aggregateMetricStates sets trigger state by trigger state aggregation rule over states of its metrics
+// and sends trigger event if state has changed
+func (triggerChecker *TriggerChecker) aggregateMetricStates(checkData moira.CheckData) (moira.CheckData, error) {
+	checkData.State, checkData.Message = getAggregatedState(triggerChecker.trigger.StateAggregation, checkData.Metrics)
+	return triggerChecker.compareTriggerStates(checkData)
+}
+
+func getAggregatedState(aggregation *moira.StateAggregation, metrics map[string]moira.MetricState) (string, string) {
+	var warnCount, errorCount int
+	for _, metricState := range metrics {
+		switch metricState.State {
+		case ERROR, NODATA:
+			errorCount++
+			warnCount++
+		case WARN:
+			warnCount++
+		}
+	}
+	total := len(metrics)
+	if total == 0 {
+		return OK, ""
+	}
+	if aggregation.ErrorValue != nil && aggregatedValue(aggregation, errorCount, total) >= *aggregation.ErrorValue {
+		return ERROR, fmt.Sprintf("%d of %d metrics are in ERROR or NODATA state", errorCount, total)
+	}
+	if aggregation.WarnValue != nil && aggregatedValue(aggregation, warnCount, total) >= *aggregation.WarnValue {
+		return WARN, fmt.Sprintf("%d of %d metrics are in WARN, ERROR or NODATA state", warnCount, total)
+	}
+	return OK, ""
+}
+
+func aggregatedValue(aggregation *moira.StateAggregation, count, total int) float64 {
+	if aggregation.Percent {
+		return float64(count) * 100 / float64(total)
+	}
+	return float64(count)
+}
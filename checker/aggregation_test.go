@@ -0,0 +1,101 @@
+package checker
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/op/go-logging"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/moira-alert/moira"
+	"github.com/moira-alert/moira/mock/moira-alert"
+)
+
+func TestGetAggregatedState(t *testing.T) {
+	warnValue := 2.0
+	errorValue := 50.0
+	metrics := map[string]moira.MetricState{
+		"m1": {State: OK},
+		"m2": {State: WARN},
+		"m3": {State: ERROR},
+		"m4": {State: OK},
+	}
+
+	Convey("Count of metrics", t, func() {
+		aggregation := &moira.StateAggregation{WarnValue: &warnValue}
+		state, message := getAggregatedState(aggregation, metrics)
+		So(state, ShouldEqual, WARN)
+		So(message, ShouldEqual, "2 of 4 metrics are in WARN, ERROR or NODATA state")
+	})
+
+	Convey("Percent of metrics", t, func() {
+		aggregation := &moira.StateAggregation{Percent: true, ErrorValue: &errorValue}
+		state, _ := getAggregatedState(aggregation, metrics)
+		So(state, ShouldEqual, OK)
+
+		metrics["m4"] = moira.MetricState{State: NODATA}
+		state, message := getAggregatedState(aggregation, metrics)
+		So(state, ShouldEqual, ERROR)
+		So(message, ShouldEqual, "2 of 4 metrics are in ERROR or NODATA state")
+	})
+
+	Convey("No metrics", t, func() {
+		aggregation := &moira.StateAggregation{WarnValue: &warnValue, ErrorValue: &errorValue}
+		state, _ := getAggregatedState(aggregation, map[string]moira.MetricState{})
+		So(state, ShouldEqual, OK)
+	})
+}
+
+func TestAggregateMetricStates(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	logger, _ := logging.GetLogger("Test")
+	dataBase := mock_moira_alert.NewMockDatabase(mockCtrl)
+	errorValue := 1.0
+
+	triggerChecker := TriggerChecker{
+		TriggerID: "SuperId",
+		Database:  dataBase,
+		Logger:    logger,
+		trigger: &moira.Trigger{
+			Name:             "Super Trigger",
+			StateAggregation: &moira.StateAggregation{ErrorValue: &errorValue, SuppressMetricEvents: true},
+		},
+		lastCheck: &moira.CheckData{State: OK, Timestamp: 1502712000, EventTimestamp: 1502708400},
+	}
+
+	Convey("Metric event is suppressed", t, func() {
+		lastState := moira.MetricState{State: OK, Timestamp: 1502712000, EventTimestamp: 1502708400}
+		currentState := moira.MetricState{State: ERROR, Timestamp: 1502719200}
+		actual, err := triggerChecker.compareMetricStates("m1", currentState, lastState)
+		So(err, ShouldBeNil)
+		So(actual.State, ShouldEqual, ERROR)
+		So(actual.EventTimestamp, ShouldEqual, currentState.Timestamp)
+	})
+
+	Convey("Single trigger event is sent", t, func() {
+		checkData := moira.CheckData{
+			Metrics: map[string]moira.MetricState{
+				"m1": {State: ERROR},
+				"m2": {State: OK},
+			},
+			State:     OK,
+			Timestamp: 1502719200,
+		}
+		message := "1 of 2 metrics are in ERROR or NODATA state"
+		dataBase.EXPECT().PushNotificationEvent(&moira.NotificationEvent{
+			IsTriggerEvent: true,
+			TriggerID:      triggerChecker.TriggerID,
+			State:          ERROR,
+			OldState:       OK,
+			Timestamp:      checkData.Timestamp,
+			Metric:         triggerChecker.trigger.Name,
+			Message:        &message,
+		}, true).Return(nil)
+		actual, err := triggerChecker.aggregateMetricStates(checkData)
+		So(err, ShouldBeNil)
+		So(actual.State, ShouldEqual, ERROR)
+		So(actual.Message, ShouldEqual, message)
+		So(actual.EventTimestamp, ShouldEqual, checkData.Timestamp)
+	})
+}
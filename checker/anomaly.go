@@ -0,0 +1,85 @@
+package checker
+
+import (
+	"math"
+
+	"github.com/moira-alert/moira"
+	"github.com/moira-alert/moira/expression"
+	"github.com/moira-alert/moira/target"
+)
+
+// anomalyMinRelativeStdDev is minimal standard deviation used to calculate deviation, relative to baseline,
+// so metric with flat history does not deviate infinitely on tiny change
+const anomalyMinRelativeStdDev = 0.01
+
+// getHistory evaluates main target for reference intervals of all values checked by anomaly detection trigger
+func (triggerChecker *TriggerChecker) getHistory(tar string, from, until int64, allowRealTimeAlerting bool) (map[string]*target.TimeSeries, error) {
+	historyFrom, _ := triggerChecker.trigger.Anomaly.GetReferenceInterval(from)
+	_, historyUntil := triggerChecker.trigger.Anomaly.GetReferenceInterval(until)
+	result, err := triggerChecker.evaluateTarget(tar, historyFrom, historyUntil, allowRealTimeAlerting)
+	if err != nil {
+		return nil, err
+	}
+	history := make(map[string]*target.TimeSeries, len(result.TimeSeries))
+	for _, timeSeries := range result.TimeSeries {
+		history[timeSeries.Name] = timeSeries
+	}
+	return history, nil
+}
+
+// setAnomalyValues sets baseline and deviation of main target value to expression values.
+// Standard deviation of history is never taken less than anomalyMinRelativeStdDev of baseline,
+// if both are zero (history is flat zero) value has no deviation.
+// Returns false if history of time series has not enough values to calculate baseline
+func (triggerTimeSeries *triggerTimeSeries) setAnomalyValues(anomaly *moira.AnomalyDetection, timeSeries *target.TimeSeries, valueTimestamp int64, expressionValues *expression.TriggerExpression) bool {
+	history, ok := triggerTimeSeries.History[timeSeries.Name]
+	if !ok {
+		return false
+	}
+	baseline, stdDev, ok := getBaseline(anomaly, history, valueTimestamp)
+	if !ok {
+		return false
+	}
+	stdDev = math.Max(stdDev, math.Abs(baseline)*anomalyMinRelativeStdDev)
+	deviation := 0.0
+	if value := expressionValues.MainTargetValue; value != baseline && stdDev > 0 {
+		deviation = (value - baseline) / stdDev
+	}
+	expressionValues.Baseline = &baseline
+	expressionValues.Deviation = &deviation
+	expressionValues.WarnDeviation = &anomaly.WarnDeviation
+	expressionValues.ErrorDeviation = &anomaly.ErrorDeviation
+	return true
+}
+
+// getBaseline returns mean and standard deviation of history values within reference interval of value timestamp,
+// at least two values are required
+func getBaseline(anomaly *moira.AnomalyDetection, history *target.TimeSeries, valueTimestamp int64) (float64, float64, bool) {
+	from, until := anomaly.GetReferenceInterval(valueTimestamp)
+	startTime := int64(history.StartTime)
+	stepTime := int64(history.StepTime)
+	if stepTime <= 0 {
+		return 0, 0, false
+	}
+	if from < startTime {
+		from = startTime
+	}
+	if offset := (from - startTime) % stepTime; offset != 0 {
+		from += stepTime - offset
+	}
+	var count, sum, squaresSum float64
+	for timestamp := from; timestamp <= until; timestamp += stepTime {
+		value := history.GetTimestampValue(timestamp)
+		if IsInvalidValue(value) {
+			continue
+		}
+		count++
+		sum += value
+		squaresSum += value * value
+	}
+	if count < 2 {
+		return 0, 0, false
+	}
+	mean := sum / count
+	return mean, math.Sqrt(math.Max(squaresSum/count-mean*mean, 0)), true
+}
@@ -0,0 +1,136 @@
+package checker
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-graphite/carbonapi/expr/types"
+	pb "github.com/go-graphite/carbonzipper/carbonzipperpb3"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/moira-alert/moira"
+	"github.com/moira-alert/moira/expression"
+	"github.com/moira-alert/moira/target"
+)
+
+func TestGetBaseline(t *testing.T) {
+	history := &target.TimeSeries{
+		MetricData: types.MetricData{FetchResponse: pb.FetchResponse{
+			Name:      "main.metric",
+			StartTime: 10,
+			StopTime:  70,
+			StepTime:  10,
+			Values:    []float64{2, 4, math.NaN(), 4, 5, 5},
+			IsAbsent:  []bool{false, false, true, false, false, false},
+		}},
+	}
+
+	Convey("Rolling baseline of values before timestamp", t, func() {
+		anomaly := &moira.AnomalyDetection{Method: moira.RollingAnomaly, Window: 60}
+		baseline, stdDev, ok := getBaseline(anomaly, history, 70)
+		So(ok, ShouldBeTrue)
+		So(baseline, ShouldEqual, 4)
+		So(stdDev, ShouldAlmostEqual, math.Sqrt(1.2))
+	})
+
+	Convey("Reference interval not aligned with steps", t, func() {
+		anomaly := &moira.AnomalyDetection{Method: moira.RollingAnomaly, Window: 20}
+		baseline, stdDev, ok := getBaseline(anomaly, history, 65)
+		So(ok, ShouldBeTrue)
+		So(baseline, ShouldEqual, 5)
+		So(stdDev, ShouldEqual, 0)
+	})
+
+	Convey("Seasonal baseline around the same time of previous season", t, func() {
+		anomaly := &moira.AnomalyDetection{Method: moira.SeasonalAnomaly, Window: 20, Season: 1000}
+		baseline, _, ok := getBaseline(anomaly, history, 1020)
+		So(ok, ShouldBeTrue)
+		So(baseline, ShouldEqual, 3)
+	})
+
+	Convey("Not enough values", t, func() {
+		anomaly := &moira.AnomalyDetection{Method: moira.RollingAnomaly, Window: 20}
+		_, _, ok := getBaseline(anomaly, history, 40)
+		So(ok, ShouldBeFalse)
+	})
+}
+
+func TestSetAnomalyValues(t *testing.T) {
+	history := &target.TimeSeries{
+		MetricData: types.MetricData{FetchResponse: pb.FetchResponse{
+			Name:      "main.metric",
+			StartTime: 10,
+			StopTime:  50,
+			StepTime:  10,
+			Values:    []float64{1, 3, 1, 3},
+			IsAbsent:  []bool{false, false, false, false},
+		}},
+	}
+	anomaly := &moira.AnomalyDetection{Method: moira.RollingAnomaly, Window: 40, WarnDeviation: 2, ErrorDeviation: 3}
+	tts := &triggerTimeSeries{History: map[string]*target.TimeSeries{"main.metric": history}}
+
+	Convey("Has history", t, func() {
+		expressionValues := &expression.TriggerExpression{MainTargetValue: 5}
+		ok := tts.setAnomalyValues(anomaly, &target.TimeSeries{MetricData: history.MetricData}, 50, expressionValues)
+		So(ok, ShouldBeTrue)
+		So(*expressionValues.Baseline, ShouldEqual, 2)
+		So(*expressionValues.Deviation, ShouldEqual, 3)
+		So(*expressionValues.WarnDeviation, ShouldEqual, 2)
+		So(*expressionValues.ErrorDeviation, ShouldEqual, 3)
+
+		state, err := expressionValues.Evaluate()
+		So(err, ShouldBeNil)
+		So(state, ShouldEqual, ERROR)
+	})
+
+	Convey("Flat history", t, func() {
+		flat := &target.TimeSeries{
+			MetricData: types.MetricData{FetchResponse: pb.FetchResponse{
+				Name:      "flat.metric",
+				StartTime: 10,
+				StopTime:  50,
+				StepTime:  10,
+				Values:    []float64{100, 100, 100, 100},
+				IsAbsent:  []bool{false, false, false, false},
+			}},
+		}
+		flatTTS := &triggerTimeSeries{History: map[string]*target.TimeSeries{"flat.metric": flat}}
+
+		Convey("Tiny change does not deviate", func() {
+			expressionValues := &expression.TriggerExpression{MainTargetValue: 100.001}
+			ok := flatTTS.setAnomalyValues(anomaly, &target.TimeSeries{MetricData: flat.MetricData}, 50, expressionValues)
+			So(ok, ShouldBeTrue)
+			So(*expressionValues.Baseline, ShouldEqual, 100)
+			So(*expressionValues.Deviation, ShouldAlmostEqual, 0.001)
+
+			state, err := expressionValues.Evaluate()
+			So(err, ShouldBeNil)
+			So(state, ShouldEqual, OK)
+		})
+
+		Convey("Big change deviates by minimal standard deviation", func() {
+			expressionValues := &expression.TriggerExpression{MainTargetValue: 105}
+			ok := flatTTS.setAnomalyValues(anomaly, &target.TimeSeries{MetricData: flat.MetricData}, 50, expressionValues)
+			So(ok, ShouldBeTrue)
+			So(*expressionValues.Deviation, ShouldAlmostEqual, 5)
+
+			state, err := expressionValues.Evaluate()
+			So(err, ShouldBeNil)
+			So(state, ShouldEqual, ERROR)
+		})
+
+		Convey("Flat zero history has no deviation", func() {
+			flat.Values = []float64{0, 0, 0, 0}
+			expressionValues := &expression.TriggerExpression{MainTargetValue: 0.001}
+			ok := flatTTS.setAnomalyValues(anomaly, &target.TimeSeries{MetricData: flat.MetricData}, 50, expressionValues)
+			So(ok, ShouldBeTrue)
+			So(*expressionValues.Deviation, ShouldEqual, 0)
+		})
+	})
+
+	Convey("No history of time series", t, func() {
+		other := &target.TimeSeries{MetricData: types.MetricData{FetchResponse: pb.FetchResponse{Name: "other.metric"}}}
+		ok := tts.setAnomalyValues(anomaly, other, 50, &expression.TriggerExpression{MainTargetValue: 5})
+		So(ok, ShouldBeFalse)
+	})
+}
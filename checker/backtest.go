@@ -0,0 +1,122 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/moira-alert/moira"
+)
+
+// MaxBacktestChecks is the maximum number of checks replayed by one backtest
+const MaxBacktestChecks = 10080
+
+// ErrWrongBacktestInterval used if backtest interval is empty or requires too many checks
+type ErrWrongBacktestInterval struct {
+	from, until, checkInterval int64
+}
+
+// ErrWrongBacktestInterval implementation with given interval
+func (err ErrWrongBacktestInterval) Error() string {
+	return fmt.Sprintf("Wrong backtest interval %v - %v with check interval %vs, it must be non-empty and require at most %v checks", err.from, err.until, err.checkInterval, MaxBacktestChecks)
+}
+
+// StatePoint represents state of trigger or metric since given timestamp
+type StatePoint struct {
+	Timestamp int64    `json:"timestamp"`
+	State     string   `json:"state"`
+	Value     *float64 `json:"value,omitempty"`
+}
+
+// BacktestResult represents events and states trigger would have had on historical data
+type BacktestResult struct {
+	Events        []moira.NotificationEvent `json:"events"`
+	TriggerStates []StatePoint              `json:"trigger_states"`
+	MetricStates  map[string][]StatePoint   `json:"metric_states"`
+}
+
+// backtestDatabase reads metrics from underlying database, but keeps notification events in memory
+// and ignores trigger last check and metrics cleanup
+type backtestDatabase struct {
+	moira.Database
+	events []moira.NotificationEvent
+}
+
+// PushNotificationEvent keeps event in memory
+func (dataBase *backtestDatabase) PushNotificationEvent(event *moira.NotificationEvent, ui bool) error {
+	dataBase.events = append(dataBase.events, *event)
+	return nil
+}
+
+// SetTriggerLastCheck does nothing, backtest keeps last check itself
+func (*backtestDatabase) SetTriggerLastCheck(triggerID string, checkData *moira.CheckData) error {
+	return nil
+}
+
+// RemovePatternsMetrics does nothing, backtest must not change metrics
+func (*backtestDatabase) RemovePatternsMetrics(pattern []string) error {
+	return nil
+}
+
+// RemoveMetricsValues does nothing, backtest must not change metrics
+func (*backtestDatabase) RemoveMetricsValues(metrics []string, toTime int64) error {
+	return nil
+}
+
+// Backtest replays checks of given trigger every checkInterval seconds from given interval.
+// Like new trigger, it starts with NODATA state. Trigger last check and notification events are not saved to database,
+// events and states sampled at every check are returned instead.
+// Replay stops with ctx error as soon as ctx is done, so caller can bound work of remote and prometheus triggers
+func (triggerChecker *TriggerChecker) Backtest(ctx context.Context, trigger *moira.Trigger, from, until, checkInterval int64) (*BacktestResult, error) {
+	if checkInterval <= 0 || from >= until || (until-from)/checkInterval > MaxBacktestChecks {
+		return nil, ErrWrongBacktestInterval{from: from, until: until, checkInterval: checkInterval}
+	}
+	dataBase := &backtestDatabase{Database: triggerChecker.Database}
+	backtestChecker := *triggerChecker
+	backtestChecker.TriggerID = trigger.ID
+	backtestChecker.Database = dataBase
+	if backtestChecker.Config == nil {
+		backtestChecker.Config = &Config{}
+	}
+	backtestChecker.setTrigger(trigger)
+	backtestChecker.lastCheck = &moira.CheckData{
+		Metrics:   make(map[string]moira.MetricState),
+		State:     NODATA,
+		Timestamp: from,
+	}
+
+	result := &BacktestResult{
+		TriggerStates: make([]StatePoint, 0),
+		MetricStates:  make(map[string][]StatePoint),
+	}
+	for timestamp := from + checkInterval; timestamp <= until; timestamp += checkInterval {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		backtestChecker.Until = timestamp
+		backtestChecker.setFrom()
+		checkData, err := backtestChecker.check()
+		if err != nil {
+			return nil, err
+		}
+		result.addCheck(checkData)
+		backtestChecker.lastCheck = &checkData
+	}
+	result.Events = dataBase.events
+	if result.Events == nil {
+		result.Events = make([]moira.NotificationEvent, 0)
+	}
+	return result, nil
+}
+
+// addCheck adds trigger and metric states to their timelines if they differ from previous ones
+func (result *BacktestResult) addCheck(checkData moira.CheckData) {
+	if count := len(result.TriggerStates); count == 0 || result.TriggerStates[count-1].State != checkData.State {
+		result.TriggerStates = append(result.TriggerStates, StatePoint{Timestamp: checkData.Timestamp, State: checkData.State})
+	}
+	for metric, metricState := range checkData.Metrics {
+		states := result.MetricStates[metric]
+		if count := len(states); count == 0 || states[count-1].State != metricState.State {
+			result.MetricStates[metric] = append(states, StatePoint{Timestamp: metricState.Timestamp, State: metricState.State, Value: metricState.Value})
+		}
+	}
+}
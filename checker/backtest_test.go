@@ -0,0 +1,92 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/moira-alert/moira"
+	"github.com/moira-alert/moira/mock/moira-alert"
+	"github.com/moira-alert/moira/remote"
+	"github.com/op/go-logging"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBacktest(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	logger, _ := logging.GetLogger("Test")
+	dataBase := mock_moira_alert.NewMockDatabase(mockCtrl)
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		from, _ := strconv.ParseInt(request.URL.Query().Get("from"), 10, 64)
+		until, _ := strconv.ParseInt(request.URL.Query().Get("until"), 10, 64)
+		datapoints := make([]string, 0)
+		for timestamp := (from + 59) / 60 * 60; timestamp <= until; timestamp += 60 {
+			value := 0
+			if timestamp >= 7200 && timestamp < 7800 {
+				value = 25
+			}
+			datapoints = append(datapoints, fmt.Sprintf("[%d, %d]", value, timestamp))
+		}
+		fmt.Fprintf(writer, `[{"target": "metric", "datapoints": [%s]}]`, strings.Join(datapoints, ", "))
+	}))
+	defer server.Close()
+
+	triggerChecker := TriggerChecker{
+		Database:     dataBase,
+		Logger:       logger,
+		RemoteConfig: &remote.Config{URL: server.URL, CheckInterval: time.Minute, Timeout: time.Second},
+	}
+	warnValue, errorValue := 10.0, 20.0
+	trigger := &moira.Trigger{
+		ID:            "SuperId",
+		Name:          "super trigger",
+		Targets:       []string{"metric"},
+		WarnValue:     &warnValue,
+		ErrorValue:    &errorValue,
+		TriggerSource: moira.GraphiteRemote,
+	}
+
+	Convey("Backtest should return events and state timelines without saving them", t, func() {
+		result, err := triggerChecker.Backtest(context.Background(), trigger, 6000, 9000, 60)
+		So(err, ShouldBeNil)
+
+		transitions := make([]string, 0, len(result.Events))
+		for _, event := range result.Events {
+			transitions = append(transitions, fmt.Sprintf("%s %v %s->%s", event.Metric, event.Timestamp, event.OldState, event.State))
+		}
+		So(transitions, ShouldResemble, []string{
+			"metric 5400 NODATA->OK",
+			"metric 7200 OK->ERROR",
+			"metric 7800 ERROR->OK",
+		})
+		So(result.TriggerStates, ShouldResemble, []StatePoint{{Timestamp: 6060, State: OK}})
+		So(result.MetricStates["metric"], ShouldHaveLength, 3)
+		So(result.MetricStates["metric"][1].Timestamp, ShouldEqual, 7200)
+		So(result.MetricStates["metric"][1].State, ShouldEqual, ERROR)
+		So(*result.MetricStates["metric"][1].Value, ShouldEqual, 25)
+		So(result.MetricStates["metric"][2].State, ShouldEqual, OK)
+	})
+
+	Convey("Backtest should not replay too many checks", t, func() {
+		_, err := triggerChecker.Backtest(context.Background(), trigger, 0, 60*MaxBacktestChecks+60, 60)
+		So(err, ShouldResemble, ErrWrongBacktestInterval{from: 0, until: 60*MaxBacktestChecks + 60, checkInterval: 60})
+		_, err = triggerChecker.Backtest(context.Background(), trigger, 9000, 6000, 60)
+		So(err, ShouldHaveSameTypeAs, ErrWrongBacktestInterval{})
+	})
+
+	Convey("Backtest should stop when context is done", t, func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		result, err := triggerChecker.Backtest(ctx, trigger, 6000, 9000, 60)
+		So(err, ShouldEqual, context.Canceled)
+		So(result, ShouldBeNil)
+	})
+}
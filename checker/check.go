@@ -39,15 +39,36 @@ func (err ErrTriggerHasSameTimeSeriesNames) Error() string {
 // Check handle trigger and last check and write new state of trigger, if state were change then write new NotificationEvent
 func (triggerChecker *TriggerChecker) Check() error {
 	triggerChecker.Logger.Debugf("Checking trigger %s", triggerChecker.TriggerID)
+	badParent, err := triggerChecker.getBadParent()
+	if err != nil {
+		triggerChecker.Logger.Errorf("Failed to get parent triggers states of %s: %s", triggerChecker.TriggerID, err.Error())
+	}
+	triggerChecker.badParent = badParent
+	checkData, err := triggerChecker.check()
+	if err != nil {
+		return err
+	}
+	if checkData, err = triggerChecker.updateSuppressedBy(checkData); err != nil {
+		return err
+	}
+	return triggerChecker.Database.SetTriggerLastCheck(triggerChecker.TriggerID, &checkData)
+}
+
+func (triggerChecker *TriggerChecker) check() (moira.CheckData, error) {
 	checkData, err := triggerChecker.handleTrigger()
 	if err != nil {
 		checkData, err = triggerChecker.handleErrorCheck(checkData, err)
 		if err != nil {
-			return err
+			return checkData, err
+		}
+	} else if triggerChecker.trigger.StateAggregation != nil {
+		checkData, err = triggerChecker.aggregateMetricStates(checkData)
+		if err != nil {
+			return checkData, err
 		}
 	}
 	checkData.UpdateScore()
-	return triggerChecker.Database.SetTriggerLastCheck(triggerChecker.TriggerID, &checkData)
+	return checkData, nil
 }
 
 func (triggerChecker *TriggerChecker) handleTrigger() (moira.CheckData, error) {
@@ -56,11 +77,12 @@ func (triggerChecker *TriggerChecker) handleTrigger() (moira.CheckData, error) {
 		lastMetrics[k] = v
 	}
 	checkData := moira.CheckData{
-		Metrics:        lastMetrics,
-		State:          OK,
-		Timestamp:      triggerChecker.Until,
-		EventTimestamp: triggerChecker.lastCheck.EventTimestamp,
-		Score:          triggerChecker.lastCheck.Score,
+		Metrics:         lastMetrics,
+		State:           OK,
+		Timestamp:       triggerChecker.Until,
+		EventTimestamp:  triggerChecker.lastCheck.EventTimestamp,
+		Score:           triggerChecker.lastCheck.Score,
+		Acknowledgement: triggerChecker.lastCheck.Acknowledgement,
 	}
 
 	triggerTimeSeries, metrics, err := triggerChecker.getTimeSeries(triggerChecker.From, triggerChecker.Until)
@@ -163,7 +185,9 @@ func (triggerChecker *TriggerChecker) handleErrorCheck(checkData moira.CheckData
 		checkData.State = EXCEPTION
 		checkData.Message = checkingError.Error()
 	default:
-		triggerChecker.Metrics.CheckError.Mark(1)
+		if triggerChecker.Metrics != nil {
+			triggerChecker.Metrics.CheckError.Mark(1)
+		}
 		triggerChecker.Logger.Errorf("Trigger %s check failed: %s", triggerChecker.TriggerID, checkingError.Error())
 		checkData.State = EXCEPTION
 	}
@@ -184,11 +208,13 @@ func (triggerChecker *TriggerChecker) checkForNoData(timeSeries *target.TimeSeri
 		return true, nil
 	}
 	return false, &moira.MetricState{
-		State:       toMetricState(triggerChecker.ttlState),
-		Timestamp:   lastCheckTimeStamp - triggerChecker.ttl,
-		Value:       nil,
-		Maintenance: metricLastState.Maintenance,
-		Suppressed:  metricLastState.Suppressed,
+		State:              toMetricState(triggerChecker.ttlState),
+		Timestamp:          lastCheckTimeStamp - triggerChecker.ttl,
+		Value:              nil,
+		Maintenance:        metricLastState.Maintenance,
+		Suppressed:         metricLastState.Suppressed,
+		SuppressedByParent: metricLastState.SuppressedByParent,
+		Acknowledgement:    metricLastState.Acknowledgement,
 	}
 }
 
@@ -200,15 +226,24 @@ func (triggerChecker *TriggerChecker) getTimeSeriesStepsStates(triggerTimeSeries
 	triggerChecker.Logger.Debugf("[TriggerID:%s][TimeSeries:%s] Checkpoint: %v", triggerChecker.TriggerID, timeSeries.Name, checkPoint)
 
 	metricStates := make([]moira.MetricState, 0)
+	lastCheckedTimestamp := metricLastState.Timestamp
 
 	for valueTimestamp := startTime; valueTimestamp < triggerChecker.Until+stepTime; valueTimestamp += stepTime {
 		metricNewState, err := triggerChecker.getTimeSeriesState(triggerTimeSeries, timeSeries, metricLastState, valueTimestamp, checkPoint)
 		if err != nil {
 			return nil, err
 		}
+		newPoint := valueTimestamp > lastCheckedTimestamp
 		if metricNewState == nil {
+			if newPoint && metricLastState.PendingState != "" {
+				// step without value breaks consecutive points of pending state
+				metricLastState.PendingState = ""
+				metricLastState.PendingTimestamp = 0
+				metricLastState.PendingPoints = 0
+			}
 			continue
 		}
+		triggerChecker.confirmMetricState(metricNewState, metricLastState, newPoint)
 		metricLastState = *metricNewState
 		metricStates = append(metricStates, *metricNewState)
 	}
@@ -223,10 +258,16 @@ func (triggerChecker *TriggerChecker) getTimeSeriesState(triggerTimeSeries *trig
 	if !noEmptyValues {
 		return nil, nil
 	}
+	if anomaly := triggerChecker.trigger.Anomaly; anomaly != nil && !triggerTimeSeries.setAnomalyValues(anomaly, timeSeries, valueTimestamp, triggerExpression) {
+		triggerChecker.Logger.Debugf("[TriggerID:%s][TimeSeries:%s] Not enough history to check value for ts %v", triggerChecker.TriggerID, timeSeries.Name, valueTimestamp)
+		return nil, nil
+	}
 	triggerChecker.Logger.Debugf("[TriggerID:%s][TimeSeries:%s] Values for ts %v: MainTargetValue: %v, additionalTargetValues: %v", triggerChecker.TriggerID, timeSeries.Name, valueTimestamp, triggerExpression.MainTargetValue, triggerExpression.AdditionalTargetsValues)
 
 	triggerExpression.WarnValue = triggerChecker.trigger.WarnValue
 	triggerExpression.ErrorValue = triggerChecker.trigger.ErrorValue
+	triggerExpression.WarnRecoveryValue = triggerChecker.trigger.WarnRecoveryValue
+	triggerExpression.ErrorRecoveryValue = triggerChecker.trigger.ErrorRecoveryValue
 	triggerExpression.PreviousState = lastState.State
 	triggerExpression.Expression = triggerChecker.trigger.Expression
 
@@ -236,14 +277,52 @@ func (triggerChecker *TriggerChecker) getTimeSeriesState(triggerTimeSeries *trig
 	}
 
 	return &moira.MetricState{
-		State:       expressionState,
-		Timestamp:   valueTimestamp,
-		Value:       &triggerExpression.MainTargetValue,
-		Maintenance: lastState.Maintenance,
-		Suppressed:  lastState.Suppressed,
+		State:              expressionState,
+		Timestamp:          valueTimestamp,
+		Value:              &triggerExpression.MainTargetValue,
+		Maintenance:        lastState.Maintenance,
+		Suppressed:         lastState.Suppressed,
+		SuppressedByParent: lastState.SuppressedByParent,
+		Acknowledgement:    lastState.Acknowledgement,
 	}, nil
 }
 
+// confirmMetricState keeps last state of metric until new state is received for trigger ConfirmPoints
+// consecutive points and lasts for ConfirmDuration seconds since the first of them. Not confirmed state is kept as pending.
+// Points checked again near checkpoint were already counted, so they keep last state and its pending state as is
+func (triggerChecker *TriggerChecker) confirmMetricState(newState *moira.MetricState, lastState moira.MetricState, newPoint bool) {
+	confirmPoints := triggerChecker.trigger.ConfirmPoints
+	confirmDuration := triggerChecker.trigger.ConfirmDuration
+	if confirmPoints <= 1 && confirmDuration <= 0 {
+		return
+	}
+	if !newPoint {
+		newState.State = lastState.State
+		newState.PendingState = lastState.PendingState
+		newState.PendingTimestamp = lastState.PendingTimestamp
+		newState.PendingPoints = lastState.PendingPoints
+		return
+	}
+	if newState.State == lastState.State {
+		return
+	}
+	newState.PendingState = newState.State
+	newState.PendingTimestamp = newState.Timestamp
+	newState.PendingPoints = 1
+	if lastState.PendingState == newState.State {
+		newState.PendingTimestamp = lastState.PendingTimestamp
+		newState.PendingPoints = lastState.PendingPoints + 1
+	}
+	if newState.PendingPoints >= confirmPoints && newState.Timestamp-newState.PendingTimestamp >= confirmDuration {
+		triggerChecker.Logger.Debugf("[TriggerID:%s] State %s confirmed since %v", triggerChecker.TriggerID, newState.State, newState.PendingTimestamp)
+		newState.PendingState = ""
+		newState.PendingTimestamp = 0
+		newState.PendingPoints = 0
+		return
+	}
+	newState.State = lastState.State
+}
+
 func (triggerChecker *TriggerChecker) cleanupMetricsValues(metrics []string, until int64) {
 	if len(metrics) > 0 {
 		if err := triggerChecker.Database.RemoveMetricsValues(metrics, until-triggerChecker.Config.MetricsTTLSeconds); err != nil {
@@ -268,6 +268,121 @@ func TestGetTimeSeriesStepsStates(t *testing.T) {
 	})
 }
 
+func TestConfirmMetricState(t *testing.T) {
+	logger, _ := logging.GetLogger("Test")
+	logging.SetLevel(logging.INFO, "Test")
+	triggerChecker := TriggerChecker{
+		Logger:  logger,
+		trigger: &moira.Trigger{},
+	}
+	lastState := moira.MetricState{State: OK, Timestamp: 10}
+
+	Convey("No confirmation configured", t, func() {
+		newState := moira.MetricState{State: ERROR, Timestamp: 20}
+		triggerChecker.confirmMetricState(&newState, lastState, true)
+		So(newState, ShouldResemble, moira.MetricState{State: ERROR, Timestamp: 20})
+	})
+
+	Convey("Confirm by points", t, func() {
+		triggerChecker.trigger.ConfirmPoints = 3
+		states := []moira.MetricState{
+			{State: ERROR, Timestamp: 20},
+			{State: ERROR, Timestamp: 30},
+			{State: ERROR, Timestamp: 40},
+		}
+		state := lastState
+		for i := range states {
+			triggerChecker.confirmMetricState(&states[i], state, true)
+			state = states[i]
+		}
+		So(states[0], ShouldResemble, moira.MetricState{State: OK, Timestamp: 20, PendingState: ERROR, PendingTimestamp: 20, PendingPoints: 1})
+		So(states[1], ShouldResemble, moira.MetricState{State: OK, Timestamp: 30, PendingState: ERROR, PendingTimestamp: 20, PendingPoints: 2})
+		So(states[2], ShouldResemble, moira.MetricState{State: ERROR, Timestamp: 40})
+
+		Convey("Point checked again is not counted twice", func() {
+			again := moira.MetricState{State: ERROR, Timestamp: 30}
+			triggerChecker.confirmMetricState(&again, states[1], false)
+			So(again, ShouldResemble, moira.MetricState{State: OK, Timestamp: 30, PendingState: ERROR, PendingTimestamp: 20, PendingPoints: 2})
+		})
+	})
+
+	Convey("Pending state is reset by point of last state", t, func() {
+		triggerChecker.trigger.ConfirmPoints = 3
+		pending := moira.MetricState{State: OK, Timestamp: 30, PendingState: ERROR, PendingTimestamp: 20, PendingPoints: 2}
+		newState := moira.MetricState{State: OK, Timestamp: 40}
+		triggerChecker.confirmMetricState(&newState, pending, true)
+		So(newState, ShouldResemble, moira.MetricState{State: OK, Timestamp: 40})
+
+		newState = moira.MetricState{State: WARN, Timestamp: 40}
+		triggerChecker.confirmMetricState(&newState, pending, true)
+		So(newState, ShouldResemble, moira.MetricState{State: OK, Timestamp: 40, PendingState: WARN, PendingTimestamp: 40, PendingPoints: 1})
+	})
+
+	Convey("Confirm by duration", t, func() {
+		triggerChecker.trigger.ConfirmPoints = 0
+		triggerChecker.trigger.ConfirmDuration = 60
+		pending := moira.MetricState{State: OK, Timestamp: 50, PendingState: ERROR, PendingTimestamp: 20, PendingPoints: 3}
+		newState := moira.MetricState{State: ERROR, Timestamp: 70}
+		triggerChecker.confirmMetricState(&newState, pending, true)
+		So(newState, ShouldResemble, moira.MetricState{State: OK, Timestamp: 70, PendingState: ERROR, PendingTimestamp: 20, PendingPoints: 4})
+
+		newState = moira.MetricState{State: ERROR, Timestamp: 80}
+		triggerChecker.confirmMetricState(&newState, pending, true)
+		So(newState, ShouldResemble, moira.MetricState{State: ERROR, Timestamp: 80})
+	})
+
+	Convey("Confirm by points and duration", t, func() {
+		triggerChecker.trigger.ConfirmPoints = 3
+		triggerChecker.trigger.ConfirmDuration = 60
+		pending := moira.MetricState{State: OK, Timestamp: 30, PendingState: ERROR, PendingTimestamp: 20, PendingPoints: 2}
+		newState := moira.MetricState{State: ERROR, Timestamp: 90}
+		triggerChecker.confirmMetricState(&newState, pending, true)
+		So(newState, ShouldResemble, moira.MetricState{State: OK, Timestamp: 90, PendingState: ERROR, PendingTimestamp: 20, PendingPoints: 3})
+	})
+}
+
+func TestConfirmMetricStateWithGap(t *testing.T) {
+	logger, _ := logging.GetLogger("Test")
+	logging.SetLevel(logging.INFO, "Test")
+	var warnValue float64 = 10
+	var errValue float64 = 20
+	triggerChecker := TriggerChecker{
+		Logger: logger,
+		Until:  47,
+		From:   17,
+		trigger: &moira.Trigger{
+			WarnValue:     &warnValue,
+			ErrorValue:    &errValue,
+			ConfirmPoints: 3,
+		},
+	}
+	fetchResponse := pb.FetchResponse{
+		Name:      "main.metric",
+		StartTime: int32(triggerChecker.From),
+		StopTime:  int32(triggerChecker.Until),
+		StepTime:  int32(10),
+		Values:    []float64{30, 0, 30, 30},
+		IsAbsent:  []bool{false, true, false, false},
+	}
+	tts := &triggerTimeSeries{
+		Main: []*target.TimeSeries{{MetricData: types.MetricData{FetchResponse: fetchResponse}}},
+	}
+	metricLastState := moira.MetricState{State: OK, Timestamp: 7, EventTimestamp: 7}
+
+	Convey("Step without value resets pending points", t, func() {
+		metricStates, err := triggerChecker.getTimeSeriesStepsStates(tts, tts.Main[0], metricLastState)
+		So(err, ShouldBeNil)
+		So(metricStates, ShouldHaveLength, 3)
+		So(metricStates[0].State, ShouldEqual, OK)
+		So(metricStates[0].PendingPoints, ShouldEqual, 1)
+		So(metricStates[1].State, ShouldEqual, OK)
+		So(metricStates[1].PendingTimestamp, ShouldEqual, 37)
+		So(metricStates[1].PendingPoints, ShouldEqual, 1)
+		So(metricStates[2].State, ShouldEqual, OK)
+		So(metricStates[2].PendingPoints, ShouldEqual, 2)
+	})
+}
+
 func TestCheckForNODATA(t *testing.T) {
 	logger, _ := logging.GetLogger("Test")
 	logging.SetLevel(logging.INFO, "Test")
@@ -409,7 +524,7 @@ func TestCheckErrors(t *testing.T) {
 
 	Convey("GetTimeSeries error", t, func() {
 		dataBase.EXPECT().GetPatternMetrics(pattern).Return([]string{metric}, nil)
-		dataBase.EXPECT().GetMetricRetention(metric).Return(retention, nil)
+		dataBase.EXPECT().GetMetricArchives(metric).Return([]moira.MetricArchive{{Precision: retention}}, nil)
 		dataBase.EXPECT().GetMetricsValues([]string{metric}, triggerChecker.From, triggerChecker.Until).Return(nil, metricErr)
 		dataBase.EXPECT().SetTriggerLastCheck(triggerChecker.TriggerID, &moira.CheckData{
 			Metrics:        triggerChecker.lastCheck.Metrics,
@@ -494,7 +609,7 @@ func TestHandleTrigger(t *testing.T) {
 
 	Convey("First Event", t, func() {
 		dataBase.EXPECT().GetPatternMetrics(pattern).Return([]string{metric}, nil)
-		dataBase.EXPECT().GetMetricRetention(metric).Return(retention, nil)
+		dataBase.EXPECT().GetMetricArchives(metric).Return([]moira.MetricArchive{{Precision: retention}}, nil)
 		dataBase.EXPECT().GetMetricsValues([]string{metric}, triggerChecker.From, triggerChecker.Until).Return(dataList, nil)
 		var val float64
 		var val1 float64 = 4
@@ -541,7 +656,7 @@ func TestHandleTrigger(t *testing.T) {
 
 	Convey("Last check is not empty", t, func() {
 		dataBase.EXPECT().GetPatternMetrics(pattern).Return([]string{metric}, nil)
-		dataBase.EXPECT().GetMetricRetention(metric).Return(retention, nil)
+		dataBase.EXPECT().GetMetricArchives(metric).Return([]moira.MetricArchive{{Precision: retention}}, nil)
 		dataBase.EXPECT().GetMetricsValues([]string{metric}, triggerChecker.From, triggerChecker.Until).Return(dataList, nil)
 		dataBase.EXPECT().RemoveMetricsValues([]string{metric}, triggerChecker.Until-triggerChecker.Config.MetricsTTLSeconds)
 		checkData, err := triggerChecker.handleTrigger()
@@ -568,7 +683,7 @@ func TestHandleTrigger(t *testing.T) {
 		triggerChecker.Until = 4267
 		lastCheck.Timestamp = 4267
 		dataBase.EXPECT().GetPatternMetrics(pattern).Return([]string{metric}, nil)
-		dataBase.EXPECT().GetMetricRetention(metric).Return(retention, nil)
+		dataBase.EXPECT().GetMetricArchives(metric).Return([]moira.MetricArchive{{Precision: retention}}, nil)
 		dataBase.EXPECT().GetMetricsValues([]string{metric}, triggerChecker.From, triggerChecker.Until).Return(dataList, nil)
 		dataBase.EXPECT().RemoveMetricsValues([]string{metric}, triggerChecker.Until-triggerChecker.Config.MetricsTTLSeconds)
 		dataBase.EXPECT().PushNotificationEvent(&moira.NotificationEvent{
@@ -645,7 +760,7 @@ func TestHandleTrigger(t *testing.T) {
 			},
 		}
 		dataBase.EXPECT().GetPatternMetrics(pattern1).Return([]string{metric1, metric2}, nil)
-		dataBase.EXPECT().GetMetricRetention(metric1).Return(retention, nil)
+		dataBase.EXPECT().GetMetricArchives(metric1).Return([]moira.MetricArchive{{Precision: retention}}, nil)
 		dataBase.EXPECT().GetMetricsValues([]string{metric1, metric2}, triggerChecker1.From, triggerChecker1.Until).Return(map[string][]*moira.MetricValue{metric1: metricValues, metric2: metricValues}, nil)
 		dataBase.EXPECT().RemoveMetricsValues([]string{metric1, metric2}, gomock.Any())
 		dataBase.EXPECT().PushNotificationEvent(gomock.Any(), true).Return(nil)
@@ -678,7 +793,7 @@ func TestHandleTrigger(t *testing.T) {
 		triggerChecker.ttlState = DEL
 		lastCheck.Timestamp = 4267
 		dataBase.EXPECT().GetPatternMetrics(pattern).Return([]string{metric}, nil)
-		dataBase.EXPECT().GetMetricRetention(metric).Return(retention, nil)
+		dataBase.EXPECT().GetMetricArchives(metric).Return([]moira.MetricArchive{{Precision: retention}}, nil)
 		dataBase.EXPECT().GetMetricsValues([]string{metric}, triggerChecker.From, triggerChecker.Until).Return(dataList, nil)
 		dataBase.EXPECT().RemoveMetricsValues([]string{metric}, triggerChecker.Until-triggerChecker.Config.MetricsTTLSeconds)
 		dataBase.EXPECT().RemovePatternsMetrics(triggerChecker.trigger.Patterns).Return(nil)
@@ -736,11 +851,11 @@ func TestHandleErrorCheck(t *testing.T) {
 					State:     NODATA,
 				},
 			}
-			err1 := "This metric has been in bad state for more than 24 hours - please, fix."
 			checkData := moira.CheckData{
 				State:     OK,
 				Timestamp: time.Now().Unix(),
 			}
+			err1 := fmt.Sprintf("This metric has been in NODATA state for %s - please, fix.", formatStateDuration(checkData.Timestamp))
 			event := &moira.NotificationEvent{
 				IsTriggerEvent: true,
 				Timestamp:      checkData.Timestamp,
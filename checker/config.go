@@ -10,6 +10,7 @@ type Config struct {
 	MetricsTTLSeconds           int64
 	StopCheckingIntervalSeconds int64
 	MaxParallelChecks           int
+	ShardingEnabled             bool
 	LogFile                     string
 	LogLevel                    string
 }
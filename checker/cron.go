@@ -0,0 +1,143 @@
+package checker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule represents cron-like schedule of trigger checks: minute, hour, day of month, month and day of week
+// fields with numbers, ranges, lists, '*' and '/step' suffixes. Schedule is evaluated in UTC
+type CronSchedule struct {
+	minutes     uint64
+	hours       uint64
+	daysOfMonth uint64
+	months      uint64
+	daysOfWeek  uint64
+	anyDay      bool
+	anyWeekDay  bool
+}
+
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// ParseCronSchedule parses cron-like schedule with five space separated fields, for example "*/15 * * * *"
+func ParseCronSchedule(spec string) (*CronSchedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("check schedule must have %d fields: minute, hour, day of month, month and day of week", len(cronFields))
+	}
+	values := make([]uint64, len(fields))
+	for i, field := range fields {
+		value, err := parseCronField(field, cronFields[i])
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	// Sunday may be set both as 0 and 7
+	if values[4]&(1<<7) != 0 {
+		values[4] |= 1
+	}
+	schedule := &CronSchedule{
+		minutes:     values[0],
+		hours:       values[1],
+		daysOfMonth: values[2],
+		months:      values[3],
+		daysOfWeek:  values[4],
+		anyDay:      fields[2] == "*",
+		anyWeekDay:  fields[4] == "*",
+	}
+	if _, ok := schedule.next(time.Unix(0, 0)); !ok {
+		return nil, fmt.Errorf("check schedule '%s' never matches", spec)
+	}
+	return schedule, nil
+}
+
+func parseCronField(field string, bounds cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("wrong step in %s field '%s'", bounds.name, field)
+			}
+			part = part[:i]
+		}
+		from, to := bounds.min, bounds.max
+		if part != "*" {
+			var err error
+			rangeBounds := strings.SplitN(part, "-", 2)
+			if from, err = strconv.Atoi(rangeBounds[0]); err != nil {
+				return 0, fmt.Errorf("wrong value in %s field '%s'", bounds.name, field)
+			}
+			to = from
+			if len(rangeBounds) == 2 {
+				if to, err = strconv.Atoi(rangeBounds[1]); err != nil {
+					return 0, fmt.Errorf("wrong value in %s field '%s'", bounds.name, field)
+				}
+			} else if step > 1 {
+				to = bounds.max
+			}
+		}
+		if from < bounds.min || to > bounds.max || from > to {
+			return 0, fmt.Errorf("%s field '%s' is out of range %d-%d", bounds.name, field, bounds.min, bounds.max)
+		}
+		for value := from; value <= to; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+	return bits, nil
+}
+
+// Next returns the first time matching schedule after given time
+func (schedule *CronSchedule) Next(after time.Time) time.Time {
+	next, _ := schedule.next(after)
+	return next
+}
+
+// next returns the first time matching schedule after given time,
+// false with limit of search if schedule does not match in five years
+func (schedule *CronSchedule) next(after time.Time) (time.Time, bool) {
+	next := after.UTC().Truncate(time.Minute).Add(time.Minute)
+	// Every valid schedule matches at least once in a leap year cycle
+	limit := next.AddDate(5, 0, 0)
+	for next.Before(limit) {
+		switch {
+		case !schedule.matchDay(next):
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, time.UTC)
+		case schedule.hours&(1<<uint(next.Hour())) == 0:
+			next = next.Truncate(time.Hour).Add(time.Hour)
+		case schedule.minutes&(1<<uint(next.Minute())) == 0:
+			next = next.Add(time.Minute)
+		default:
+			return next, true
+		}
+	}
+	return limit, false
+}
+
+// matchDay checks month and day, like cron it matches any of restricted day of month and day of week
+func (schedule *CronSchedule) matchDay(date time.Time) bool {
+	if schedule.months&(1<<uint(date.Month())) == 0 {
+		return false
+	}
+	dayOfMonth := schedule.daysOfMonth&(1<<uint(date.Day())) != 0
+	dayOfWeek := schedule.daysOfWeek&(1<<uint(date.Weekday())) != 0
+	if schedule.anyDay || schedule.anyWeekDay {
+		return dayOfMonth && dayOfWeek
+	}
+	return dayOfMonth || dayOfWeek
+}
@@ -0,0 +1,41 @@
+package checker
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseCronSchedule(t *testing.T) {
+	Convey("Wrong schedules", t, func() {
+		for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "a * * * *", "5-1 * * * *", "0 0 30 2 *", "0 0 31 4,6,9,11 *"} {
+			_, err := ParseCronSchedule(spec)
+			So(err, ShouldNotBeNil)
+		}
+	})
+
+	Convey("Next check time", t, func() {
+		now := time.Date(2017, 8, 15, 10, 7, 30, 0, time.UTC)
+
+		schedule, err := ParseCronSchedule("*/15 * * * *")
+		So(err, ShouldBeNil)
+		So(schedule.Next(now), ShouldResemble, time.Date(2017, 8, 15, 10, 15, 0, 0, time.UTC))
+
+		schedule, err = ParseCronSchedule("0 3,15 * * *")
+		So(err, ShouldBeNil)
+		So(schedule.Next(now), ShouldResemble, time.Date(2017, 8, 15, 15, 0, 0, 0, time.UTC))
+
+		schedule, err = ParseCronSchedule("30 9 * * 1-5")
+		So(err, ShouldBeNil)
+		So(schedule.Next(now), ShouldResemble, time.Date(2017, 8, 16, 9, 30, 0, 0, time.UTC))
+
+		schedule, err = ParseCronSchedule("0 0 1 * 7")
+		So(err, ShouldBeNil)
+		So(schedule.Next(now), ShouldResemble, time.Date(2017, 8, 20, 0, 0, 0, 0, time.UTC))
+
+		schedule, err = ParseCronSchedule("0 0 29 2 *")
+		So(err, ShouldBeNil)
+		So(schedule.Next(now), ShouldResemble, time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC))
+	})
+}
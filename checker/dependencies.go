@@ -0,0 +1,112 @@
+package checker
+
+import (
+	"fmt"
+
+	"github.com/moira-alert/moira"
+	"github.com/moira-alert/moira/database"
+)
+
+// badParentStates are states of parent trigger or its metrics which suppress events of dependent triggers,
+// WARN is not bad enough to suppress them
+var badParentStates = map[string]bool{
+	ERROR:     true,
+	NODATA:    true,
+	EXCEPTION: true,
+}
+
+// getBadParent returns ID of first parent trigger in bad state, empty string if all parents are OK.
+// Parent is in bad state if its trigger state or state of any its metric not under maintenance is ERROR, NODATA or EXCEPTION
+func (triggerChecker *TriggerChecker) getBadParent() (string, error) {
+	dependencies := triggerChecker.trigger.Dependencies
+	if dependencies == nil {
+		return "", nil
+	}
+	parentIDs := make([]string, 0, len(dependencies.TriggerIDs))
+	parentIDs = append(parentIDs, dependencies.TriggerIDs...)
+	for _, tag := range dependencies.Tags {
+		tagTriggerIDs, err := triggerChecker.Database.GetTagTriggerIDs(tag)
+		if err != nil {
+			return "", err
+		}
+		parentIDs = append(parentIDs, tagTriggerIDs...)
+	}
+
+	checked := make(map[string]bool, len(parentIDs))
+	for _, parentID := range parentIDs {
+		if parentID == triggerChecker.TriggerID || checked[parentID] {
+			continue
+		}
+		checked[parentID] = true
+		parentCheck, err := triggerChecker.Database.GetTriggerLastCheck(parentID)
+		if err != nil {
+			if err == database.ErrNil {
+				continue
+			}
+			return "", err
+		}
+		if isBadParentCheck(parentCheck) {
+			return parentID, nil
+		}
+	}
+	return "", nil
+}
+
+// isBadParentCheck checks if last check of parent trigger has bad trigger state or bad metric state not under maintenance
+func isBadParentCheck(parentCheck moira.CheckData) bool {
+	if badParentStates[parentCheck.State] {
+		return true
+	}
+	for _, metricState := range parentCheck.Metrics {
+		if badParentStates[metricState.State] && metricState.Maintenance < metricState.Timestamp {
+			return true
+		}
+	}
+	return false
+}
+
+// isSuppressedByParent checks if event is suppressed because of parent trigger in bad state
+func (triggerChecker *TriggerChecker) isSuppressedByParent(event *moira.NotificationEvent) bool {
+	if triggerChecker.badParent == "" {
+		return false
+	}
+	triggerChecker.Logger.Debugf("Event %v suppressed due to parent trigger %s in bad state", event, triggerChecker.badParent)
+	triggerChecker.suppressedByParent = true
+	return true
+}
+
+// updateSuppressedBy remembers parent trigger which suppressed events while it stays in bad state
+// and notifies about suppressed events once parent is recovered
+func (triggerChecker *TriggerChecker) updateSuppressedBy(checkData moira.CheckData) (moira.CheckData, error) {
+	lastSuppressedBy := triggerChecker.lastCheck.SuppressedBy
+	if triggerChecker.badParent != "" {
+		if triggerChecker.suppressedByParent {
+			checkData.SuppressedBy = triggerChecker.badParent
+		} else {
+			checkData.SuppressedBy = lastSuppressedBy
+		}
+		return checkData, nil
+	}
+	checkData.SuppressedBy = ""
+	if lastSuppressedBy == "" {
+		return checkData, nil
+	}
+
+	parentName := lastSuppressedBy
+	if parent, err := triggerChecker.Database.GetTrigger(lastSuppressedBy); err == nil {
+		parentName = parent.Name
+	}
+	message := fmt.Sprintf("Events of this trigger were suppressed by parent trigger %s, which is recovered now.", parentName)
+	event := moira.NotificationEvent{
+		IsTriggerEvent: true,
+		TriggerID:      triggerChecker.TriggerID,
+		State:          checkData.State,
+		OldState:       checkData.State,
+		Timestamp:      checkData.Timestamp,
+		Metric:         triggerChecker.trigger.Name,
+		Message:        &message,
+	}
+	triggerChecker.Logger.Infof("Writing new event: %v", event)
+	err := triggerChecker.Database.PushNotificationEvent(&event, true)
+	return checkData, err
+}
@@ -0,0 +1,171 @@
+package checker
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/op/go-logging"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/moira-alert/moira"
+	"github.com/moira-alert/moira/database"
+	"github.com/moira-alert/moira/mock/moira-alert"
+)
+
+func TestGetBadParent(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	logger, _ := logging.GetLogger("Test")
+	dataBase := mock_moira_alert.NewMockDatabase(mockCtrl)
+
+	triggerChecker := TriggerChecker{
+		TriggerID: "child",
+		Database:  dataBase,
+		Logger:    logger,
+		trigger:   &moira.Trigger{ID: "child"},
+	}
+
+	Convey("No dependencies", t, func() {
+		parentID, err := triggerChecker.getBadParent()
+		So(err, ShouldBeNil)
+		So(parentID, ShouldBeEmpty)
+	})
+
+	Convey("Parent given by ID is in bad state", t, func() {
+		triggerChecker.trigger.Dependencies = &moira.TriggerDependencies{TriggerIDs: []string{"missing", "parent"}}
+		dataBase.EXPECT().GetTriggerLastCheck("missing").Return(moira.CheckData{}, database.ErrNil)
+		dataBase.EXPECT().GetTriggerLastCheck("parent").Return(moira.CheckData{State: OK, Metrics: map[string]moira.MetricState{
+			"warn":  {State: WARN, Timestamp: 100},
+			"error": {State: ERROR, Timestamp: 100},
+		}}, nil)
+		parentID, err := triggerChecker.getBadParent()
+		So(err, ShouldBeNil)
+		So(parentID, ShouldEqual, "parent")
+	})
+
+	Convey("Parents given by tag are OK", t, func() {
+		triggerChecker.trigger.Dependencies = &moira.TriggerDependencies{Tags: []string{"redis"}}
+		dataBase.EXPECT().GetTagTriggerIDs("redis").Return([]string{"child", "parent"}, nil)
+		dataBase.EXPECT().GetTriggerLastCheck("parent").Return(moira.CheckData{State: OK}, nil)
+		parentID, err := triggerChecker.getBadParent()
+		So(err, ShouldBeNil)
+		So(parentID, ShouldBeEmpty)
+	})
+
+	Convey("Parent with warning or maintained metrics is not in bad state", t, func() {
+		triggerChecker.trigger.Dependencies = &moira.TriggerDependencies{TriggerIDs: []string{"parent"}}
+		dataBase.EXPECT().GetTriggerLastCheck("parent").Return(moira.CheckData{State: OK, Score: 1100, Metrics: map[string]moira.MetricState{
+			"warn":       {State: WARN, Timestamp: 100},
+			"maintained": {State: ERROR, Timestamp: 100, Maintenance: 200},
+		}}, nil)
+		parentID, err := triggerChecker.getBadParent()
+		So(err, ShouldBeNil)
+		So(parentID, ShouldBeEmpty)
+	})
+
+	Convey("Parent trigger state is bad", t, func() {
+		triggerChecker.trigger.Dependencies = &moira.TriggerDependencies{TriggerIDs: []string{"parent"}}
+		dataBase.EXPECT().GetTriggerLastCheck("parent").Return(moira.CheckData{State: EXCEPTION}, nil)
+		parentID, err := triggerChecker.getBadParent()
+		So(err, ShouldBeNil)
+		So(parentID, ShouldEqual, "parent")
+	})
+}
+
+func TestUpdateSuppressedBy(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	logger, _ := logging.GetLogger("Test")
+	dataBase := mock_moira_alert.NewMockDatabase(mockCtrl)
+
+	triggerChecker := TriggerChecker{
+		TriggerID: "child",
+		Database:  dataBase,
+		Logger:    logger,
+		trigger:   &moira.Trigger{ID: "child", Name: "Child"},
+		lastCheck: &moira.CheckData{},
+	}
+	checkData := moira.CheckData{State: OK, Timestamp: 1000}
+
+	Convey("Parent suppresses events", t, func() {
+		triggerChecker.badParent = "parent"
+		triggerChecker.suppressedByParent = true
+		actual, err := triggerChecker.updateSuppressedBy(checkData)
+		So(err, ShouldBeNil)
+		So(actual.SuppressedBy, ShouldEqual, "parent")
+	})
+
+	Convey("Parent stays in bad state", t, func() {
+		triggerChecker.badParent = "parent"
+		triggerChecker.suppressedByParent = false
+		triggerChecker.lastCheck.SuppressedBy = "parent"
+		actual, err := triggerChecker.updateSuppressedBy(checkData)
+		So(err, ShouldBeNil)
+		So(actual.SuppressedBy, ShouldEqual, "parent")
+	})
+
+	Convey("Parent is recovered", t, func() {
+		triggerChecker.badParent = ""
+		triggerChecker.lastCheck.SuppressedBy = "parent"
+		message := "Events of this trigger were suppressed by parent trigger Parent, which is recovered now."
+		event := moira.NotificationEvent{
+			IsTriggerEvent: true,
+			TriggerID:      "child",
+			State:          OK,
+			OldState:       OK,
+			Timestamp:      1000,
+			Metric:         "Child",
+			Message:        &message,
+		}
+		dataBase.EXPECT().GetTrigger("parent").Return(moira.Trigger{ID: "parent", Name: "Parent"}, nil)
+		dataBase.EXPECT().PushNotificationEvent(&event, true).Return(nil)
+		actual, err := triggerChecker.updateSuppressedBy(checkData)
+		So(err, ShouldBeNil)
+		So(actual.SuppressedBy, ShouldBeEmpty)
+	})
+}
+
+func TestCompareMetricStatesSuppressedByParent(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	logger, _ := logging.GetLogger("Test")
+	dataBase := mock_moira_alert.NewMockDatabase(mockCtrl)
+
+	triggerChecker := TriggerChecker{
+		TriggerID: "child",
+		Database:  dataBase,
+		Logger:    logger,
+		trigger:   &moira.Trigger{ID: "child"},
+	}
+	lastState := moira.MetricState{Timestamp: 100, EventTimestamp: 100, State: OK}
+	currentState := moira.MetricState{Timestamp: 200, State: ERROR}
+
+	Convey("Event should be suppressed by parent in bad state without maintenance flag", t, func() {
+		triggerChecker.badParent = "parent"
+		actual, err := triggerChecker.compareMetricStates("metric", currentState, lastState)
+		So(err, ShouldBeNil)
+		So(actual.SuppressedByParent, ShouldBeTrue)
+		So(actual.Suppressed, ShouldBeFalse)
+		So(actual.SuppressedState, ShouldEqual, OK)
+		So(triggerChecker.suppressedByParent, ShouldBeTrue)
+		lastState = actual
+	})
+
+	Convey("State changed while parent was in bad state should be sent with parent message", t, func() {
+		triggerChecker.badParent = ""
+		currentState.Timestamp = 300
+		message := "This metric changed its state while parent trigger was in bad state."
+		dataBase.EXPECT().PushNotificationEvent(&moira.NotificationEvent{
+			TriggerID: "child",
+			Timestamp: 300,
+			State:     ERROR,
+			OldState:  OK,
+			Metric:    "metric",
+			Message:   &message,
+		}, true).Return(nil)
+		actual, err := triggerChecker.compareMetricStates("metric", currentState, lastState)
+		So(err, ShouldBeNil)
+		So(actual.SuppressedByParent, ShouldBeFalse)
+		So(actual.SuppressedState, ShouldBeEmpty)
+	})
+}
@@ -2,11 +2,13 @@ package checker
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/moira-alert/moira"
 )
 
+// badStateReminder contains default reminder intervals, used if trigger doesn't override them
 var badStateReminder = map[string]int64{
 	ERROR:  86400,
 	NODATA: 86400,
@@ -15,7 +17,8 @@ var badStateReminder = map[string]int64{
 func (triggerChecker *TriggerChecker) compareTriggerStates(currentCheck moira.CheckData) (moira.CheckData, error) {
 	currentStateValue := currentCheck.State
 	lastStateValue := triggerChecker.lastCheck.State
-	lastStateSuppressed := triggerChecker.lastCheck.Suppressed
+	lastStateSuppressed := triggerChecker.lastCheck.Suppressed || triggerChecker.lastCheck.SuppressedByParent
+	lastStateSuppressedByParent := triggerChecker.lastCheck.SuppressedByParent && !triggerChecker.lastCheck.Suppressed
 	lastStateSuppressedValue := triggerChecker.lastCheck.SuppressedState
 	timestamp := currentCheck.Timestamp
 
@@ -30,11 +33,22 @@ func (triggerChecker *TriggerChecker) compareTriggerStates(currentCheck moira.Ch
 	}
 
 	currentCheck.SuppressedState = lastStateSuppressedValue
+	currentCheck.StateTimestamp = triggerChecker.lastCheck.StateTimestamp
+	if !currentCheck.Acknowledgement.IsActive(timestamp) {
+		currentCheck.Acknowledgement = nil
+	}
 
-	needSend, message := needSendEvent(currentStateValue, lastStateValue, timestamp, triggerChecker.lastCheck.GetEventTimestamp(), lastStateSuppressed, lastStateSuppressedValue)
+	lastStateTimestamp := triggerChecker.lastCheck.GetStateTimestamp()
+	lastEventTimestamp := triggerChecker.lastCheck.GetEventTimestamp()
+	remindInterval := triggerChecker.getRemindInterval(currentStateValue)
+	needSend, isReminder, message := needSendEvent(currentStateValue, lastStateValue, timestamp, lastEventTimestamp, lastStateTimestamp, lastStateSuppressed, lastStateSuppressedByParent, lastStateSuppressedValue, remindInterval)
 	if !needSend {
 		return currentCheck, nil
 	}
+	if isReminder && currentCheck.Acknowledgement != nil {
+		triggerChecker.Logger.Debugf("Reminder about trigger %s skipped due to acknowledgement by %s", triggerChecker.TriggerID, currentCheck.Acknowledgement.User)
+		return currentCheck, nil
+	}
 	if message == nil {
 		message = &currentCheck.Message
 	}
@@ -55,10 +69,21 @@ func (triggerChecker *TriggerChecker) compareTriggerStates(currentCheck moira.Ch
 	}
 
 	currentCheck.EventTimestamp = timestamp
+	currentCheck.StateTimestamp = 0
 	currentCheck.Suppressed = false
+	currentCheck.SuppressedByParent = false
+	if isReminder {
+		event.StateTimestamp = lastStateTimestamp
+		event.LastEventTimestamp = lastEventTimestamp
+		currentCheck.StateTimestamp = lastStateTimestamp
+	}
 
 	if triggerChecker.isTriggerSuppressed(&event, timestamp, 0, "") {
 		currentCheck.Suppressed = true
+	} else if triggerChecker.isSuppressedByParent(&event) {
+		currentCheck.SuppressedByParent = true
+	}
+	if currentCheck.Suppressed || currentCheck.SuppressedByParent {
 		if !lastStateSuppressed {
 			currentCheck.SuppressedState = lastStateValue
 		}
@@ -78,19 +103,34 @@ func (triggerChecker *TriggerChecker) compareMetricStates(metric string, current
 		currentState.EventTimestamp = currentState.Timestamp
 	}
 
-	if lastState.Suppressed && lastState.SuppressedState == "" {
+	lastStateSuppressed := lastState.Suppressed || lastState.SuppressedByParent
+	if lastStateSuppressed && lastState.SuppressedState == "" {
 		lastState.SuppressedState = lastState.State
 	}
 
 	currentState.SuppressedState = lastState.SuppressedState
+	currentState.StateTimestamp = lastState.StateTimestamp
+	if !currentState.Acknowledgement.IsActive(currentState.Timestamp) {
+		currentState.Acknowledgement = nil
+	}
 
-	needSend, message := needSendEvent(currentState.State, lastState.State, currentState.Timestamp, lastState.GetEventTimestamp(), lastState.Suppressed, lastState.SuppressedState)
+	lastStateTimestamp := lastState.GetStateTimestamp()
+	lastEventTimestamp := lastState.GetEventTimestamp()
+	remindInterval := triggerChecker.getRemindInterval(currentState.State)
+	needSend, isReminder, message := needSendEvent(currentState.State, lastState.State, currentState.Timestamp, lastEventTimestamp, lastStateTimestamp, lastStateSuppressed, lastState.SuppressedByParent && !lastState.Suppressed, lastState.SuppressedState, remindInterval)
 	if !needSend {
 		return currentState, nil
 	}
+	if isReminder && (currentState.Acknowledgement != nil || triggerChecker.isTriggerAcknowledged(currentState.Timestamp)) {
+		triggerChecker.Logger.Debugf("Reminder about metric %s skipped due to acknowledgement", metric)
+		return currentState, nil
+	}
+	if currentState.State == OK {
+		currentState.Acknowledgement = nil
+	}
 
 	eventOldState := lastState.State
-	if lastState.Suppressed {
+	if lastStateSuppressed {
 		eventOldState = lastState.SuppressedState
 	}
 
@@ -105,17 +145,32 @@ func (triggerChecker *TriggerChecker) compareMetricStates(metric string, current
 	}
 
 	currentState.EventTimestamp = currentState.Timestamp
+	currentState.StateTimestamp = 0
 	currentState.Suppressed = false
+	currentState.SuppressedByParent = false
+	if isReminder {
+		event.StateTimestamp = lastStateTimestamp
+		event.LastEventTimestamp = lastEventTimestamp
+		currentState.StateTimestamp = lastStateTimestamp
+	}
 
 	if triggerChecker.isTriggerSuppressed(&event, currentState.Timestamp, currentState.Maintenance, metric) {
 		currentState.Suppressed = true
-		if !lastState.Suppressed {
+	} else if triggerChecker.isSuppressedByParent(&event) {
+		currentState.SuppressedByParent = true
+	}
+	if currentState.Suppressed || currentState.SuppressedByParent {
+		if !lastStateSuppressed {
 			currentState.SuppressedState = lastState.State
 		}
 		return currentState, nil
 	}
 
 	currentState.SuppressedState = ""
+	if aggregation := triggerChecker.trigger.StateAggregation; aggregation != nil && aggregation.SuppressMetricEvents {
+		triggerChecker.Logger.Debugf("Event %v suppressed due to trigger state aggregation", event)
+		return currentState, nil
+	}
 	triggerChecker.Logger.Infof("Writing new event: %v", event)
 	err := triggerChecker.Database.PushNotificationEvent(&event, true)
 	return currentState, err
@@ -133,22 +188,60 @@ func (triggerChecker *TriggerChecker) isTriggerSuppressed(event *moira.Notificat
 	return false
 }
 
-func needSendEvent(currentStateValue string, lastStateValue string, currentStateTimestamp int64, lastStateEventTimestamp int64, isLastCheckSuppressed bool, lastStateSuppressedValue string) (needSend bool, message *string) {
+func (triggerChecker *TriggerChecker) isTriggerAcknowledged(timestamp int64) bool {
+	return triggerChecker.lastCheck != nil && triggerChecker.lastCheck.Acknowledgement.IsActive(timestamp)
+}
+
+// getRemindInterval returns interval between reminders about metric staying in given state, 0 means no reminders.
+// Subscription reminder intervals are applied by notifier to these reminders, so they can not be more frequent
+func (triggerChecker *TriggerChecker) getRemindInterval(state string) int64 {
+	if remindInterval, ok := triggerChecker.trigger.ReminderIntervals[state]; ok {
+		return remindInterval
+	}
+	return badStateReminder[state]
+}
+
+func needSendEvent(currentStateValue string, lastStateValue string, currentStateTimestamp int64, lastStateEventTimestamp int64, lastStateTimestamp int64, isLastCheckSuppressed bool, isLastCheckSuppressedByParent bool, lastStateSuppressedValue string, remindInterval int64) (needSend bool, isReminder bool, message *string) {
 	if !isLastCheckSuppressed && currentStateValue != lastStateValue {
-		return true, nil
+		return true, false, nil
 	}
 	if isLastCheckSuppressed && currentStateValue != lastStateSuppressedValue {
 		message := "This metric changed its state during maintenance interval."
-		return true, &message
+		if isLastCheckSuppressedByParent {
+			message = "This metric changed its state while parent trigger was in bad state."
+		}
+		return true, false, &message
 	}
-	remindInterval, ok := badStateReminder[currentStateValue]
-	if ok && needRemindAgain(currentStateTimestamp, lastStateEventTimestamp, remindInterval) {
-		message := fmt.Sprintf("This metric has been in bad state for more than %v hours - please, fix.", remindInterval/3600)
-		return true, &message
+	if needRemindAgain(currentStateTimestamp, lastStateEventTimestamp, remindInterval) {
+		message := fmt.Sprintf("This metric has been in %s state for %s - please, fix.", currentStateValue, formatStateDuration(currentStateTimestamp-lastStateTimestamp))
+		return true, true, &message
 	}
-	return false, nil
+	return false, false, nil
 }
 
 func needRemindAgain(currentStateTimestamp, lastStateEventTimestamp, remindInterval int64) bool {
-	return currentStateTimestamp-lastStateEventTimestamp >= remindInterval
+	return remindInterval > 0 && currentStateTimestamp-lastStateEventTimestamp >= remindInterval
+}
+
+// formatStateDuration formats duration given in seconds as days, hours and minutes, e.g. "1d 2h 30m"
+func formatStateDuration(seconds int64) string {
+	units := []struct {
+		seconds int64
+		suffix  string
+	}{
+		{86400, "d"},
+		{3600, "h"},
+		{60, "m"},
+	}
+	parts := make([]string, 0, len(units))
+	for _, unit := range units {
+		if seconds >= unit.seconds {
+			parts = append(parts, fmt.Sprintf("%d%s", seconds/unit.seconds, unit.suffix))
+			seconds %= unit.seconds
+		}
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("%ds", seconds)
+	}
+	return strings.Join(parts, " ")
 }
@@ -82,19 +82,22 @@ func TestCompareMetricStates(t *testing.T) {
 			currentState.State = NODATA
 			currentState.Timestamp = 1502809200
 
-			message := fmt.Sprintf("This metric has been in bad state for more than 24 hours - please, fix.")
+			message := fmt.Sprintf("This metric has been in NODATA state for 1d 4h - please, fix.")
 			dataBase.EXPECT().PushNotificationEvent(&moira.NotificationEvent{
-				TriggerID: triggerChecker.TriggerID,
-				Timestamp: currentState.Timestamp,
-				State:     NODATA,
-				OldState:  NODATA,
-				Metric:    "m1",
-				Value:     currentState.Value,
-				Message:   &message,
+				TriggerID:          triggerChecker.TriggerID,
+				Timestamp:          currentState.Timestamp,
+				State:              NODATA,
+				OldState:           NODATA,
+				Metric:             "m1",
+				Value:              currentState.Value,
+				Message:            &message,
+				StateTimestamp:     lastState.EventTimestamp,
+				LastEventTimestamp: lastState.EventTimestamp,
 			}, true).Return(nil)
 			actual, err := triggerChecker.compareMetricStates("m1", currentState, lastState)
 			So(err, ShouldBeNil)
 			currentState.EventTimestamp = currentState.Timestamp
+			currentState.StateTimestamp = lastState.EventTimestamp
 			currentState.Suppressed = false
 			So(actual, ShouldResemble, currentState)
 		})
@@ -106,19 +109,22 @@ func TestCompareMetricStates(t *testing.T) {
 			currentState.State = ERROR
 			currentState.Timestamp = 1502809200
 
-			message := fmt.Sprintf("This metric has been in bad state for more than 24 hours - please, fix.")
+			message := fmt.Sprintf("This metric has been in ERROR state for 1d 4h - please, fix.")
 			dataBase.EXPECT().PushNotificationEvent(&moira.NotificationEvent{
-				TriggerID: triggerChecker.TriggerID,
-				Timestamp: currentState.Timestamp,
-				State:     ERROR,
-				OldState:  ERROR,
-				Metric:    "m1",
-				Value:     currentState.Value,
-				Message:   &message,
+				TriggerID:          triggerChecker.TriggerID,
+				Timestamp:          currentState.Timestamp,
+				State:              ERROR,
+				OldState:           ERROR,
+				Metric:             "m1",
+				Value:              currentState.Value,
+				Message:            &message,
+				StateTimestamp:     lastState.EventTimestamp,
+				LastEventTimestamp: lastState.EventTimestamp,
 			}, true).Return(nil)
 			actual, err := triggerChecker.compareMetricStates("m1", currentState, lastState)
 			So(err, ShouldBeNil)
 			currentState.EventTimestamp = currentState.Timestamp
+			currentState.StateTimestamp = lastState.EventTimestamp
 			currentState.Suppressed = false
 			So(actual, ShouldResemble, currentState)
 		})
@@ -393,3 +399,154 @@ func TestCheckMetricStateSuppressedState(t *testing.T) {
 	})
 
 }
+
+func TestCompareMetricStatesWithReminderIntervals(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	logger, _ := logging.GetLogger("Test")
+	dataBase := mock_moira_alert.NewMockDatabase(mockCtrl)
+
+	triggerChecker := TriggerChecker{
+		TriggerID: "SuperId",
+		Database:  dataBase,
+		Logger:    logger,
+		trigger: &moira.Trigger{
+			ReminderIntervals: map[string]int64{WARN: 1800, ERROR: 0},
+		},
+	}
+
+	Convey("Trigger reminder intervals", t, func() {
+		Convey("Status ERROR and reminders disabled, no need to send", func() {
+			lastState := moira.MetricState{State: ERROR, Timestamp: 1000, EventTimestamp: 1000}
+			currentState := moira.MetricState{State: ERROR, Timestamp: 1000 + 86400*7}
+
+			actual, err := triggerChecker.compareMetricStates("m1", currentState, lastState)
+			So(err, ShouldBeNil)
+			currentState.EventTimestamp = lastState.EventTimestamp
+			So(actual, ShouldResemble, currentState)
+		})
+
+		Convey("Status NODATA uses default reminder interval", func() {
+			lastState := moira.MetricState{State: NODATA, Timestamp: 1000, EventTimestamp: 1000}
+			currentState := moira.MetricState{State: NODATA, Timestamp: 1000 + 3600}
+
+			actual, err := triggerChecker.compareMetricStates("m1", currentState, lastState)
+			So(err, ShouldBeNil)
+			currentState.EventTimestamp = lastState.EventTimestamp
+			So(actual, ShouldResemble, currentState)
+		})
+
+		Convey("Status WARN reminds every 30 minutes and keeps state timestamp", func() {
+			lastState := moira.MetricState{State: WARN, Timestamp: 4600, EventTimestamp: 2800, StateTimestamp: 1000}
+			currentState := moira.MetricState{State: WARN, Timestamp: 4600}
+
+			message := "This metric has been in WARN state for 1h - please, fix."
+			dataBase.EXPECT().PushNotificationEvent(&moira.NotificationEvent{
+				TriggerID:          triggerChecker.TriggerID,
+				Timestamp:          currentState.Timestamp,
+				State:              WARN,
+				OldState:           WARN,
+				Metric:             "m1",
+				Message:            &message,
+				StateTimestamp:     1000,
+				LastEventTimestamp: 2800,
+			}, true).Return(nil)
+			actual, err := triggerChecker.compareMetricStates("m1", currentState, lastState)
+			So(err, ShouldBeNil)
+			currentState.EventTimestamp = currentState.Timestamp
+			currentState.StateTimestamp = 1000
+			So(actual, ShouldResemble, currentState)
+		})
+
+		Convey("State change resets state timestamp", func() {
+			lastState := moira.MetricState{State: WARN, Timestamp: 4600, EventTimestamp: 2800, StateTimestamp: 1000}
+			currentState := moira.MetricState{State: OK, Timestamp: 4660}
+
+			dataBase.EXPECT().PushNotificationEvent(&moira.NotificationEvent{
+				TriggerID: triggerChecker.TriggerID,
+				Timestamp: currentState.Timestamp,
+				State:     OK,
+				OldState:  WARN,
+				Metric:    "m1",
+			}, true).Return(nil)
+			actual, err := triggerChecker.compareMetricStates("m1", currentState, lastState)
+			So(err, ShouldBeNil)
+			currentState.EventTimestamp = currentState.Timestamp
+			So(actual, ShouldResemble, currentState)
+		})
+	})
+}
+
+func TestCompareMetricStatesWithAcknowledgement(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	logger, _ := logging.GetLogger("Test")
+	dataBase := mock_moira_alert.NewMockDatabase(mockCtrl)
+
+	triggerChecker := TriggerChecker{
+		TriggerID: "SuperId",
+		Database:  dataBase,
+		Logger:    logger,
+		trigger: &moira.Trigger{
+			ReminderIntervals: map[string]int64{WARN: 1800},
+		},
+		lastCheck: &moira.CheckData{},
+	}
+	acknowledgement := &moira.Acknowledgement{User: "user", Timestamp: 3000, Until: 5000}
+
+	Convey("Acknowledged metric skips reminder", t, func() {
+		lastState := moira.MetricState{State: WARN, Timestamp: 4600, EventTimestamp: 2800, Acknowledgement: acknowledgement}
+		currentState := moira.MetricState{State: WARN, Timestamp: 4600, Acknowledgement: acknowledgement}
+
+		actual, err := triggerChecker.compareMetricStates("m1", currentState, lastState)
+		So(err, ShouldBeNil)
+		currentState.EventTimestamp = lastState.EventTimestamp
+		So(actual, ShouldResemble, currentState)
+	})
+
+	Convey("Acknowledged trigger skips reminder of metric", t, func() {
+		triggerChecker.lastCheck.Acknowledgement = acknowledgement
+		lastState := moira.MetricState{State: WARN, Timestamp: 4600, EventTimestamp: 2800}
+		currentState := moira.MetricState{State: WARN, Timestamp: 4600}
+
+		actual, err := triggerChecker.compareMetricStates("m1", currentState, lastState)
+		So(err, ShouldBeNil)
+		currentState.EventTimestamp = lastState.EventTimestamp
+		So(actual, ShouldResemble, currentState)
+		triggerChecker.lastCheck.Acknowledgement = nil
+	})
+
+	Convey("Recovery removes acknowledgement", t, func() {
+		lastState := moira.MetricState{State: WARN, Timestamp: 4600, EventTimestamp: 2800, Acknowledgement: acknowledgement}
+		currentState := moira.MetricState{State: OK, Timestamp: 4660, Acknowledgement: acknowledgement}
+
+		dataBase.EXPECT().PushNotificationEvent(&moira.NotificationEvent{
+			TriggerID: triggerChecker.TriggerID,
+			Timestamp: currentState.Timestamp,
+			State:     OK,
+			OldState:  WARN,
+			Metric:    "m1",
+		}, true).Return(nil)
+		actual, err := triggerChecker.compareMetricStates("m1", currentState, lastState)
+		So(err, ShouldBeNil)
+		So(actual.Acknowledgement, ShouldBeNil)
+	})
+
+	Convey("Expired acknowledgement is removed", t, func() {
+		lastState := moira.MetricState{State: WARN, Timestamp: 5000, EventTimestamp: 5000, Acknowledgement: acknowledgement}
+		currentState := moira.MetricState{State: WARN, Timestamp: 5060, Acknowledgement: acknowledgement}
+
+		actual, err := triggerChecker.compareMetricStates("m1", currentState, lastState)
+		So(err, ShouldBeNil)
+		So(actual.Acknowledgement, ShouldBeNil)
+	})
+}
+
+func TestFormatStateDuration(t *testing.T) {
+	Convey("Format state duration", t, func() {
+		So(formatStateDuration(45), ShouldEqual, "45s")
+		So(formatStateDuration(1800), ShouldEqual, "30m")
+		So(formatStateDuration(86400), ShouldEqual, "1d")
+		So(formatStateDuration(86400+2*3600+30*60+15), ShouldEqual, "1d 2h 30m")
+	})
+}
@@ -0,0 +1,37 @@
+package checker
+
+import (
+	"github.com/go-graphite/carbonapi/expr/types"
+
+	"github.com/moira-alert/moira"
+	"github.com/moira-alert/moira/prometheus"
+	"github.com/moira-alert/moira/remote"
+	"github.com/moira-alert/moira/target"
+)
+
+// evaluateTarget gets target time series from the storage of trigger source:
+// local database, remote Graphite or Prometheus
+func (triggerChecker *TriggerChecker) evaluateTarget(tar string, from int64, until int64, allowRealTimeAlerting bool) (*target.EvaluationResult, error) {
+	var metricsData []*types.MetricData
+	var err error
+	switch triggerChecker.trigger.GetTriggerSource() {
+	case moira.GraphiteRemote:
+		metricsData, err = remote.Fetch(triggerChecker.RemoteConfig, tar, from, until, allowRealTimeAlerting)
+	case moira.PrometheusRemote:
+		metricsData, err = prometheus.Fetch(triggerChecker.PrometheusConfig, tar, from, until)
+	default:
+		return target.EvaluateTarget(triggerChecker.Database, tar, from, until, allowRealTimeAlerting, triggerChecker.Config.MetricsTTLSeconds)
+	}
+	if err != nil {
+		return nil, err
+	}
+	result := &target.EvaluationResult{
+		TimeSeries: make([]*target.TimeSeries, 0, len(metricsData)),
+		Patterns:   make([]string, 0),
+		Metrics:    make([]string, 0),
+	}
+	for _, metricData := range metricsData {
+		result.TimeSeries = append(result.TimeSeries, &target.TimeSeries{MetricData: *metricData})
+	}
+	return result, nil
+}
@@ -11,6 +11,7 @@ import (
 type triggerTimeSeries struct {
 	Main       []*target.TimeSeries
 	Additional []*target.TimeSeries
+	History    map[string]*target.TimeSeries
 }
 
 // ErrWrongTriggerTarget represents inconsistent number of timeseries
@@ -30,13 +31,19 @@ func (triggerChecker *TriggerChecker) getTimeSeries(from, until int64) (*trigger
 
 	isSimpleTrigger := triggerChecker.trigger.IsSimple()
 	for targetIndex, tar := range triggerChecker.trigger.Targets {
-		result, err := target.EvaluateTarget(triggerChecker.Database, tar, from, until, isSimpleTrigger)
+		result, err := triggerChecker.evaluateTarget(tar, from, until, isSimpleTrigger)
 		if err != nil {
 			return nil, nil, err
 		}
 
 		if targetIndex == 0 {
 			triggerTimeSeries.Main = result.TimeSeries
+			if triggerChecker.trigger.Anomaly != nil {
+				triggerTimeSeries.History, err = triggerChecker.getHistory(tar, from, until, isSimpleTrigger)
+				if err != nil {
+					return nil, nil, err
+				}
+			}
 		} else {
 			timeSeriesCount := len(result.TimeSeries)
 			switch {
@@ -80,7 +80,7 @@ func TestGetTimeSeries(t *testing.T) {
 
 	Convey("Error test", t, func() {
 		dataBase.EXPECT().GetPatternMetrics(pattern).Return([]string{metric}, nil)
-		dataBase.EXPECT().GetMetricRetention(metric).Return(retention, nil)
+		dataBase.EXPECT().GetMetricArchives(metric).Return([]moira.MetricArchive{{Precision: retention}}, nil)
 		dataBase.EXPECT().GetMetricsValues([]string{metric}, from, until).Return(nil, metricErr)
 		actual, metrics, err := triggerChecker.getTimeSeries(from, until)
 		So(actual, ShouldBeNil)
@@ -117,7 +117,7 @@ func TestGetTimeSeries(t *testing.T) {
 	Convey("Test has metrics", t, func() {
 		Convey("Only one target", func() {
 			dataBase.EXPECT().GetPatternMetrics(pattern).Return([]string{metric}, nil)
-			dataBase.EXPECT().GetMetricRetention(metric).Return(retention, nil)
+			dataBase.EXPECT().GetMetricArchives(metric).Return([]moira.MetricArchive{{Precision: retention}}, nil)
 			dataBase.EXPECT().GetMetricsValues([]string{metric}, from, until).Return(dataList, nil)
 			actual, metrics, err := triggerChecker.getTimeSeries(from, until)
 			fetchResponse := pb.FetchResponse{
@@ -143,11 +143,11 @@ func TestGetTimeSeries(t *testing.T) {
 			dataList[addMetric] = metricValues
 
 			dataBase.EXPECT().GetPatternMetrics(pattern).Return([]string{metric}, nil)
-			dataBase.EXPECT().GetMetricRetention(metric).Return(retention, nil)
+			dataBase.EXPECT().GetMetricArchives(metric).Return([]moira.MetricArchive{{Precision: retention}}, nil)
 			dataBase.EXPECT().GetMetricsValues([]string{metric}, from, until).Return(dataList, nil)
 
 			dataBase.EXPECT().GetPatternMetrics(addPattern).Return([]string{addMetric}, nil)
-			dataBase.EXPECT().GetMetricRetention(addMetric).Return(retention, nil)
+			dataBase.EXPECT().GetMetricArchives(addMetric).Return([]moira.MetricArchive{{Precision: retention}}, nil)
 			dataBase.EXPECT().GetMetricsValues([]string{addMetric}, from, until).Return(dataList, nil)
 
 			actual, metrics, err := triggerChecker.getTimeSeries(from, until)
@@ -175,11 +175,11 @@ func TestGetTimeSeries(t *testing.T) {
 			dataList[addMetric2] = metricValues
 
 			dataBase.EXPECT().GetPatternMetrics(pattern).Return([]string{metric}, nil)
-			dataBase.EXPECT().GetMetricRetention(metric).Return(retention, nil)
+			dataBase.EXPECT().GetMetricArchives(metric).Return([]moira.MetricArchive{{Precision: retention}}, nil)
 			dataBase.EXPECT().GetMetricsValues([]string{metric}, from, until).Return(dataList, nil)
 
 			dataBase.EXPECT().GetPatternMetrics(addPattern).Return([]string{addMetric, addMetric2}, nil)
-			dataBase.EXPECT().GetMetricRetention(addMetric).Return(retention, nil)
+			dataBase.EXPECT().GetMetricArchives(addMetric).Return([]moira.MetricArchive{{Precision: retention}}, nil)
 			dataBase.EXPECT().GetMetricsValues([]string{addMetric, addMetric2}, from, until).Return(dataList, nil)
 
 			actual, metrics, err := triggerChecker.getTimeSeries(from, until)
@@ -5,6 +5,8 @@ import (
 	"github.com/moira-alert/moira"
 	"github.com/moira-alert/moira/database"
 	"github.com/moira-alert/moira/metrics/graphite"
+	"github.com/moira-alert/moira/prometheus"
+	"github.com/moira-alert/moira/remote"
 	"time"
 )
 
@@ -16,6 +18,9 @@ type TriggerChecker struct {
 	Config    *Config
 	Metrics   *graphite.CheckerMetrics
 
+	RemoteConfig     *remote.Config
+	PrometheusConfig *prometheus.Config
+
 	From  int64
 	Until int64
 
@@ -24,6 +29,9 @@ type TriggerChecker struct {
 
 	ttl      int64
 	ttlState string
+
+	badParent          string
+	suppressedByParent bool
 }
 
 // ErrTriggerNotExists used if trigger to check does not exists
@@ -40,7 +48,19 @@ func (triggerChecker *TriggerChecker) InitTriggerChecker() error {
 		return err
 	}
 
-	triggerChecker.trigger = &trigger
+	triggerChecker.setTrigger(&trigger)
+
+	triggerChecker.lastCheck, err = getLastCheck(triggerChecker.Database, triggerChecker.TriggerID, triggerChecker.Until-3600)
+	if err != nil {
+		return err
+	}
+
+	triggerChecker.setFrom()
+	return nil
+}
+
+func (triggerChecker *TriggerChecker) setTrigger(trigger *moira.Trigger) {
+	triggerChecker.trigger = trigger
 	triggerChecker.ttl = trigger.TTL
 
 	if trigger.TTLState != nil {
@@ -48,20 +68,15 @@ func (triggerChecker *TriggerChecker) InitTriggerChecker() error {
 	} else {
 		triggerChecker.ttlState = NODATA
 	}
+}
 
-	triggerChecker.lastCheck, err = getLastCheck(triggerChecker.Database, triggerChecker.TriggerID, triggerChecker.Until-3600)
-	if err != nil {
-		return err
-	}
-
+func (triggerChecker *TriggerChecker) setFrom() {
 	triggerChecker.From = triggerChecker.lastCheck.Timestamp
 	if triggerChecker.ttl != 0 {
 		triggerChecker.From = triggerChecker.From - triggerChecker.ttl
 	} else {
 		triggerChecker.From = triggerChecker.From - 600
 	}
-
-	return nil
 }
 
 func getLastCheck(dataBase moira.Database, triggerID string, emptyLastCheckTimestamp int64) (*moira.CheckData, error) {
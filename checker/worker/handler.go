@@ -57,6 +57,9 @@ func (worker *Checker) checkTrigger(triggerID string) error {
 		Logger:    worker.Logger,
 		Config:    worker.Config,
 		Metrics:   worker.Metrics,
+
+		RemoteConfig:     worker.RemoteConfig,
+		PrometheusConfig: worker.PrometheusConfig,
 	}
 
 	err := triggerChecker.InitTriggerChecker()
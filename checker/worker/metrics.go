@@ -47,6 +47,9 @@ func (worker *Checker) handleMetricEvent(pattern string) error {
 
 func (worker *Checker) addTriggerIDsIfNeeded(triggerIDs []string) {
 	for _, triggerID := range triggerIDs {
+		if worker.isScheduledTrigger(triggerID) || !worker.isOwnTrigger(triggerID) {
+			continue
+		}
 		if worker.needHandleTrigger(triggerID) {
 			worker.triggersToCheck <- triggerID
 		}
@@ -0,0 +1,25 @@
+package worker
+
+import (
+	"time"
+)
+
+// remoteTriggerChecker periodically schedules checks of triggers which data is not pushed to moira
+func (worker *Checker) remoteTriggerChecker(source string, checkInterval time.Duration, getTriggerIDs func() ([]string, error)) error {
+	checkTicker := time.NewTicker(checkInterval)
+	for {
+		select {
+		case <-worker.tomb.Dying():
+			checkTicker.Stop()
+			worker.Logger.Infof("%s triggers checker stopped", source)
+			return nil
+		case <-checkTicker.C:
+			triggerIds, err := getTriggerIDs()
+			if err != nil {
+				worker.Logger.Errorf("%s triggers check failed: %s", source, err.Error())
+				continue
+			}
+			worker.addTriggerIDsIfNeeded(triggerIds)
+		}
+	}
+}
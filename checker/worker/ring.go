@@ -0,0 +1,65 @@
+package worker
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/vova616/xxhash"
+)
+
+// hashRingReplicas is number of points of every member on hash ring, more points give more even distribution
+const hashRingReplicas = 128
+
+// hashRing assigns triggers to checker instances by consistent hashing of trigger IDs,
+// so only triggers of joined or left instance are moved on rebalance
+type hashRing struct {
+	members []string
+	points  []uint32
+	owners  map[uint32]string
+}
+
+func newHashRing(members []string) *hashRing {
+	ring := &hashRing{
+		members: members,
+		points:  make([]uint32, 0, len(members)*hashRingReplicas),
+		owners:  make(map[uint32]string, len(members)*hashRingReplicas),
+	}
+	for _, member := range members {
+		for i := 0; i < hashRingReplicas; i++ {
+			point := xxhash.Checksum32([]byte(fmt.Sprintf("%s#%d", member, i)))
+			if _, ok := ring.owners[point]; ok {
+				continue
+			}
+			ring.owners[point] = member
+			ring.points = append(ring.points, point)
+		}
+	}
+	sort.Slice(ring.points, func(i, j int) bool { return ring.points[i] < ring.points[j] })
+	return ring
+}
+
+// getOwner returns member owning given key, empty string if ring has no members
+func (ring *hashRing) getOwner(key string) string {
+	if len(ring.points) == 0 {
+		return ""
+	}
+	hash := xxhash.Checksum32([]byte(key))
+	i := sort.Search(len(ring.points), func(i int) bool { return ring.points[i] >= hash })
+	if i == len(ring.points) {
+		i = 0
+	}
+	return ring.owners[ring.points[i]]
+}
+
+// hasSameMembers checks if ring is built for given sorted members
+func (ring *hashRing) hasSameMembers(members []string) bool {
+	if len(ring.members) != len(members) {
+		return false
+	}
+	for i := range members {
+		if ring.members[i] != members[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,48 @@
+package worker
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestHashRing(t *testing.T) {
+	triggerIDs := make([]string, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		triggerIDs = append(triggerIDs, fmt.Sprintf("trigger-%d", i))
+	}
+
+	Convey("Empty ring has no owners", t, func() {
+		So(newHashRing(nil).getOwner("trigger-1"), ShouldEqual, "")
+	})
+
+	Convey("Triggers are distributed between all members", t, func() {
+		ring := newHashRing([]string{"checker-1", "checker-2", "checker-3"})
+		counts := make(map[string]int)
+		for _, triggerID := range triggerIDs {
+			counts[ring.getOwner(triggerID)]++
+		}
+		So(counts, ShouldHaveLength, 3)
+		for _, count := range counts {
+			So(count, ShouldBeGreaterThan, 200)
+		}
+	})
+
+	Convey("Only triggers of left member are moved", t, func() {
+		ring := newHashRing([]string{"checker-1", "checker-2", "checker-3"})
+		rebalanced := newHashRing([]string{"checker-1", "checker-3"})
+		for _, triggerID := range triggerIDs {
+			if owner := ring.getOwner(triggerID); owner != "checker-2" {
+				So(rebalanced.getOwner(triggerID), ShouldEqual, owner)
+			}
+		}
+	})
+
+	Convey("Has same members", t, func() {
+		ring := newHashRing([]string{"checker-1", "checker-2"})
+		So(ring.hasSameMembers([]string{"checker-1", "checker-2"}), ShouldBeTrue)
+		So(ring.hasSameMembers([]string{"checker-1"}), ShouldBeFalse)
+		So(ring.hasSameMembers([]string{"checker-1", "checker-3"}), ShouldBeFalse)
+	})
+}
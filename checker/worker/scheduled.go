@@ -0,0 +1,186 @@
+package worker
+
+import (
+	"container/heap"
+	"time"
+
+	"github.com/moira-alert/moira"
+	"github.com/moira-alert/moira/checker"
+)
+
+// scheduledTriggersRefreshInterval is period of reloading triggers checked on their own schedule
+const scheduledTriggersRefreshInterval = time.Minute
+
+// scheduledTriggersChecker checks triggers with check interval or schedule when their next check time comes,
+// such triggers are not checked on incoming metrics and NODATA checks
+func (worker *Checker) scheduledTriggersChecker() error {
+	queue := newScheduledChecks()
+	if err := worker.refreshScheduledChecks(queue, time.Now()); err != nil {
+		worker.Logger.Errorf("Scheduled triggers refresh failed: %s", err.Error())
+	}
+	refreshTicker := time.NewTicker(scheduledTriggersRefreshInterval)
+	checkTicker := time.NewTicker(time.Second)
+	for {
+		select {
+		case <-worker.tomb.Dying():
+			refreshTicker.Stop()
+			checkTicker.Stop()
+			worker.Logger.Info("Scheduled triggers checker stopped")
+			return nil
+		case now := <-refreshTicker.C:
+			if err := worker.refreshScheduledChecks(queue, now); err != nil {
+				worker.Logger.Errorf("Scheduled triggers refresh failed: %s", err.Error())
+			}
+		case now := <-checkTicker.C:
+			for _, triggerID := range queue.popDue(now) {
+				if worker.isOwnTrigger(triggerID) {
+					worker.triggersToCheck <- triggerID
+				}
+			}
+		}
+	}
+}
+
+// refreshScheduledChecks reloads schedules of triggers and marks them to be skipped by event-driven checks
+func (worker *Checker) refreshScheduledChecks(queue *scheduledChecks, now time.Time) error {
+	triggerIDs, err := worker.Database.GetScheduledTriggerIDs()
+	if err != nil {
+		return err
+	}
+	triggers := make([]*moira.Trigger, 0)
+	if len(triggerIDs) > 0 {
+		if triggers, err = worker.Database.GetTriggers(triggerIDs); err != nil {
+			return err
+		}
+	}
+	scheduledTriggers := make(map[string]bool, len(triggers))
+	for i, trigger := range triggers {
+		if trigger == nil || !trigger.IsScheduledCheck() {
+			continue
+		}
+		if err := queue.set(triggerIDs[i], trigger, now); err != nil {
+			worker.Logger.Warningf("Failed to schedule trigger %s checks: %s", triggerIDs[i], err.Error())
+			continue
+		}
+		scheduledTriggers[triggerIDs[i]] = true
+	}
+	queue.removeOthers(scheduledTriggers)
+
+	worker.scheduledTriggersLock.Lock()
+	worker.scheduledTriggers = scheduledTriggers
+	worker.scheduledTriggersLock.Unlock()
+	return nil
+}
+
+func (worker *Checker) isScheduledTrigger(triggerID string) bool {
+	worker.scheduledTriggersLock.RLock()
+	defer worker.scheduledTriggersLock.RUnlock()
+	return worker.scheduledTriggers[triggerID]
+}
+
+type scheduledCheck struct {
+	triggerID string
+	interval  int64
+	schedule  string
+	cron      *checker.CronSchedule
+	nextCheck time.Time
+	index     int
+}
+
+func (check *scheduledCheck) getNextCheck(now time.Time) time.Time {
+	if check.cron != nil {
+		return check.cron.Next(now)
+	}
+	return time.Unix((now.Unix()/check.interval+1)*check.interval, 0)
+}
+
+// scheduledChecks is a queue of trigger checks ordered by next check time
+type scheduledChecks struct {
+	checks map[string]*scheduledCheck
+	queue  scheduledChecksHeap
+}
+
+func newScheduledChecks() *scheduledChecks {
+	return &scheduledChecks{
+		checks: make(map[string]*scheduledCheck),
+		queue:  make(scheduledChecksHeap, 0),
+	}
+}
+
+// set adds trigger checks to queue, next check time is kept unless trigger interval or schedule is changed
+func (checks *scheduledChecks) set(triggerID string, trigger *moira.Trigger, now time.Time) error {
+	check, ok := checks.checks[triggerID]
+	if ok && check.interval == trigger.CheckInterval && check.schedule == trigger.CheckSchedule {
+		return nil
+	}
+	newCheck := &scheduledCheck{
+		triggerID: triggerID,
+		interval:  trigger.CheckInterval,
+		schedule:  trigger.CheckSchedule,
+	}
+	if trigger.CheckSchedule != "" {
+		cron, err := checker.ParseCronSchedule(trigger.CheckSchedule)
+		if err != nil {
+			return err
+		}
+		newCheck.cron = cron
+	}
+	newCheck.nextCheck = newCheck.getNextCheck(now)
+	if ok {
+		heap.Remove(&checks.queue, check.index)
+	}
+	checks.checks[triggerID] = newCheck
+	heap.Push(&checks.queue, newCheck)
+	return nil
+}
+
+// removeOthers removes checks of triggers not in given set
+func (checks *scheduledChecks) removeOthers(triggerIDs map[string]bool) {
+	for triggerID, check := range checks.checks {
+		if !triggerIDs[triggerID] {
+			heap.Remove(&checks.queue, check.index)
+			delete(checks.checks, triggerID)
+		}
+	}
+}
+
+// popDue returns triggers which check time has come and schedules their next checks
+func (checks *scheduledChecks) popDue(now time.Time) []string {
+	triggerIDs := make([]string, 0)
+	for len(checks.queue) > 0 && !checks.queue[0].nextCheck.After(now) {
+		check := checks.queue[0]
+		triggerIDs = append(triggerIDs, check.triggerID)
+		check.nextCheck = check.getNextCheck(now)
+		heap.Fix(&checks.queue, 0)
+	}
+	return triggerIDs
+}
+
+type scheduledChecksHeap []*scheduledCheck
+
+func (queue scheduledChecksHeap) Len() int {
+	return len(queue)
+}
+
+func (queue scheduledChecksHeap) Less(i, j int) bool {
+	return queue[i].nextCheck.Before(queue[j].nextCheck)
+}
+
+func (queue scheduledChecksHeap) Swap(i, j int) {
+	queue[i], queue[j] = queue[j], queue[i]
+	queue[i].index = i
+	queue[j].index = j
+}
+
+func (queue *scheduledChecksHeap) Push(x interface{}) {
+	check := x.(*scheduledCheck)
+	check.index = len(*queue)
+	*queue = append(*queue, check)
+}
+
+func (queue *scheduledChecksHeap) Pop() interface{} {
+	old := *queue
+	check := old[len(old)-1]
+	*queue = old[:len(old)-1]
+	return check
+}
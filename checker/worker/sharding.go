@@ -0,0 +1,82 @@
+package worker
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/satori/go.uuid"
+)
+
+const (
+	checkerMemberHeartbeatInterval = time.Second * 5
+	checkerMemberTimeout           = time.Second * 30
+)
+
+// startSharding registers checker instance and builds hash ring of alive instances
+func (worker *Checker) startSharding() error {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "checker"
+	}
+	worker.memberID = fmt.Sprintf("%s-%s", hostname, uuid.NewV4().String())
+	if err := worker.updateShardingRing(time.Now()); err != nil {
+		return err
+	}
+	worker.tomb.Go(worker.shardingMembership)
+	worker.Logger.Infof("Sharding started, checker member id: %s", worker.memberID)
+	return nil
+}
+
+// shardingMembership renews checker instance registration and rebalances triggers when instances join or leave
+func (worker *Checker) shardingMembership() error {
+	heartbeatTicker := time.NewTicker(checkerMemberHeartbeatInterval)
+	for {
+		select {
+		case <-worker.tomb.Dying():
+			heartbeatTicker.Stop()
+			if err := worker.Database.RemoveCheckerMember(worker.memberID); err != nil {
+				worker.Logger.Errorf("Failed to deregister checker member: %s", err.Error())
+			}
+			worker.Logger.Info("Sharding stopped")
+			return nil
+		case now := <-heartbeatTicker.C:
+			if err := worker.updateShardingRing(now); err != nil {
+				worker.Logger.Errorf("Failed to update checker members: %s", err.Error())
+			}
+		}
+	}
+}
+
+func (worker *Checker) updateShardingRing(now time.Time) error {
+	if err := worker.Database.UpdateCheckerMember(worker.memberID, now.Unix()); err != nil {
+		return err
+	}
+	members, err := worker.Database.GetCheckerMembers(now.Add(-checkerMemberTimeout).Unix())
+	if err != nil {
+		return err
+	}
+	sort.Strings(members)
+
+	worker.shardingRingLock.Lock()
+	defer worker.shardingRingLock.Unlock()
+	if worker.shardingRing != nil && worker.shardingRing.hasSameMembers(members) {
+		return nil
+	}
+	worker.Logger.Infof("Checker members changed, rebalance triggers between %v", members)
+	worker.shardingRing = newHashRing(members)
+	return nil
+}
+
+// isOwnTrigger checks if trigger is owned by this checker instance.
+// All triggers are own if sharding is disabled or members are unknown, trigger check lock prevents concurrent checks then
+func (worker *Checker) isOwnTrigger(triggerID string) bool {
+	worker.shardingRingLock.RLock()
+	defer worker.shardingRingLock.RUnlock()
+	if worker.shardingRing == nil {
+		return true
+	}
+	owner := worker.shardingRing.getOwner(triggerID)
+	return owner == "" || owner == worker.memberID
+}
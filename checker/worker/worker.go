@@ -2,6 +2,7 @@ package worker
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/patrickmn/go-cache"
@@ -10,19 +11,30 @@ import (
 	"github.com/moira-alert/moira"
 	"github.com/moira-alert/moira/checker"
 	"github.com/moira-alert/moira/metrics/graphite"
+	"github.com/moira-alert/moira/prometheus"
+	"github.com/moira-alert/moira/remote"
 )
 
 // Checker represents workers for periodically triggers checking based by new events
 type Checker struct {
-	Logger          moira.Logger
-	Database        moira.Database
-	Config          *checker.Config
-	Metrics         *graphite.CheckerMetrics
-	TriggerCache    *cache.Cache
-	PatternCache    *cache.Cache
-	lastData        int64
-	tomb            tomb.Tomb
-	triggersToCheck chan string
+	Logger           moira.Logger
+	Database         moira.Database
+	Config           *checker.Config
+	Metrics          *graphite.CheckerMetrics
+	TriggerCache     *cache.Cache
+	PatternCache     *cache.Cache
+	RemoteConfig     *remote.Config
+	PrometheusConfig *prometheus.Config
+	lastData         int64
+	tomb             tomb.Tomb
+	triggersToCheck  chan string
+
+	scheduledTriggers     map[string]bool
+	scheduledTriggersLock sync.RWMutex
+
+	memberID         string
+	shardingRing     *hashRing
+	shardingRingLock sync.RWMutex
 }
 
 // Start start schedule new MetricEvents and check for NODATA triggers
@@ -34,6 +46,12 @@ func (worker *Checker) Start() error {
 	worker.lastData = time.Now().UTC().Unix()
 	worker.triggersToCheck = make(chan string, 16384)
 
+	if worker.Config.ShardingEnabled {
+		if err := worker.startSharding(); err != nil {
+			return err
+		}
+	}
+
 	metricEventsChannel, err := worker.Database.SubscribeMetricEvents(&worker.tomb)
 	if err != nil {
 		return err
@@ -42,6 +60,23 @@ func (worker *Checker) Start() error {
 	worker.tomb.Go(worker.noDataChecker)
 	worker.Logger.Info("NODATA checker started")
 
+	worker.tomb.Go(worker.scheduledTriggersChecker)
+	worker.Logger.Info("Scheduled triggers checker started")
+
+	if worker.RemoteConfig.IsEnabled() {
+		worker.tomb.Go(func() error {
+			return worker.remoteTriggerChecker("Remote", worker.RemoteConfig.CheckInterval, worker.Database.GetRemoteTriggerIDs)
+		})
+		worker.Logger.Info("Remote triggers checker started")
+	}
+
+	if worker.PrometheusConfig.IsEnabled() {
+		worker.tomb.Go(func() error {
+			return worker.remoteTriggerChecker("Prometheus", worker.PrometheusConfig.CheckInterval, worker.Database.GetPrometheusTriggerIDs)
+		})
+		worker.Logger.Info("Prometheus triggers checker started")
+	}
+
 	worker.Logger.Infof("Start %v parallel checkers", worker.Config.MaxParallelChecks)
 	for i := 0; i < worker.Config.MaxParallelChecks; i++ {
 		worker.tomb.Go(func() error { return worker.metricsChecker(metricEventsChannel) })
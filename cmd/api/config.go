@@ -1,28 +1,35 @@
 package main
 
 import (
+	"github.com/gosexy/to"
 	"github.com/moira-alert/moira/api"
 	"github.com/moira-alert/moira/cmd"
 )
 
 type config struct {
-	Redis    cmd.RedisConfig    `yaml:"redis"`
-	Graphite cmd.GraphiteConfig `yaml:"graphite"`
-	Logger   cmd.LoggerConfig   `yaml:"log"`
-	API      apiConfig          `yaml:"api"`
-	Pprof    cmd.ProfilerConfig `yaml:"pprof"`
+	Redis      cmd.RedisConfig      `yaml:"redis"`
+	Graphite   cmd.GraphiteConfig   `yaml:"graphite"`
+	Logger     cmd.LoggerConfig     `yaml:"log"`
+	API        apiConfig            `yaml:"api"`
+	Pprof      cmd.ProfilerConfig   `yaml:"pprof"`
+	Remote     cmd.RemoteConfig     `yaml:"remote"`
+	Prometheus cmd.PrometheusConfig `yaml:"prometheus"`
 }
 
 type apiConfig struct {
 	Listen        string `yaml:"listen"`          // Api local network address. Default is ':8081' so api will be available at http://moira.company.com:8081/api
 	EnableCORS    bool   `yaml:"enable_cors"`     // If true, CORS for cross-domain requests will be enabled. This option can be used only for debugging purposes.
 	WebConfigPath string `yaml:"web_config_path"` // Web_UI config file path. If file not found, api will return 404 in response to "api/config"
+	MetricsTTL    string `yaml:"metrics_ttl"`     // Time interval checker stores metrics values for, must be the same as checker metrics_ttl. Older values are read from coarser archives
+	CheckInterval string `yaml:"check_interval"`  // Min check_interval of triggers, must be the same as checker check_interval
 }
 
 func (config *apiConfig) getSettings() *api.Config {
 	return &api.Config{
-		Listen:     config.Listen,
-		EnableCORS: config.EnableCORS,
+		Listen:                  config.Listen,
+		EnableCORS:              config.EnableCORS,
+		MetricsTTLSeconds:       int64(to.Duration(config.MetricsTTL).Seconds()),
+		MinCheckIntervalSeconds: int64(to.Duration(config.CheckInterval).Seconds()),
 	}
 }
 
@@ -40,6 +47,8 @@ func getDefault() config {
 			Listen:        ":8081",
 			WebConfigPath: "/etc/moira/web.json",
 			EnableCORS:    false,
+			MetricsTTL:    "1h",
+			CheckInterval: "5s",
 		},
 		Graphite: cmd.GraphiteConfig{
 			RuntimeStats: false,
@@ -50,5 +59,14 @@ func getDefault() config {
 		Pprof: cmd.ProfilerConfig{
 			Listen: "",
 		},
+		Remote: cmd.RemoteConfig{
+			CheckInterval: "60s",
+			Timeout:       "60s",
+		},
+		Prometheus: cmd.PrometheusConfig{
+			CheckInterval: "60s",
+			Timeout:       "60s",
+			Step:          "60s",
+		},
 	}
 }
@@ -59,6 +59,8 @@ func main() {
 	}
 
 	apiConfig := config.API.getSettings()
+	apiConfig.Remote = config.Remote.GetSettings()
+	apiConfig.Prometheus = config.Prometheus.GetSettings()
 
 	logger, err := logging.ConfigureLog(config.Logger.LogFile, config.Logger.LogLevel, serviceName)
 	if err != nil {
@@ -9,11 +9,13 @@ import (
 )
 
 type config struct {
-	Redis    cmd.RedisConfig    `yaml:"redis"`
-	Graphite cmd.GraphiteConfig `yaml:"graphite"`
-	Logger   cmd.LoggerConfig   `yaml:"log"`
-	Checker  checkerConfig      `yaml:"checker"`
-	Pprof    cmd.ProfilerConfig `yaml:"pprof"`
+	Redis      cmd.RedisConfig      `yaml:"redis"`
+	Graphite   cmd.GraphiteConfig   `yaml:"graphite"`
+	Logger     cmd.LoggerConfig     `yaml:"log"`
+	Checker    checkerConfig        `yaml:"checker"`
+	Remote     cmd.RemoteConfig     `yaml:"remote"`
+	Prometheus cmd.PrometheusConfig `yaml:"prometheus"`
+	Pprof      cmd.ProfilerConfig   `yaml:"pprof"`
 }
 
 type checkerConfig struct {
@@ -22,6 +24,7 @@ type checkerConfig struct {
 	CheckInterval        string `yaml:"check_interval"`         // Min period to perform triggers re-check. Note: Reducing of this value leads to increasing of CPU and memory usage values
 	MetricsTTL           string `yaml:"metrics_ttl"`            // Time interval to store metrics. Note: Increasing of this value leads to increasing of Redis memory consumption value
 	MaxParallelChecks    int    `yaml:"max_parallel_checks"`    // Max concurrent checkers to run. Equals to the number of processor cores found on Moira host by default or when variable is defined as 0.
+	EnableSharding       bool   `yaml:"enable_sharding"`        // If true, checker instances split triggers between each other by consistent hashing of trigger IDs instead of competing for every trigger
 }
 
 func (config *checkerConfig) getSettings() *checker.Config {
@@ -34,6 +37,7 @@ func (config *checkerConfig) getSettings() *checker.Config {
 		NoDataCheckInterval:         to.Duration(config.NoDataCheckInterval),
 		StopCheckingIntervalSeconds: int64(to.Duration(config.StopCheckingInterval).Seconds()),
 		MaxParallelChecks:           config.MaxParallelChecks,
+		ShardingEnabled:             config.EnableSharding,
 	}
 }
 
@@ -63,5 +67,14 @@ func getDefault() config {
 		Pprof: cmd.ProfilerConfig{
 			Listen: "",
 		},
+		Remote: cmd.RemoteConfig{
+			CheckInterval: "60s",
+			Timeout:       "60s",
+		},
+		Prometheus: cmd.PrometheusConfig{
+			CheckInterval: "60s",
+			Timeout:       "60s",
+			Step:          "60s",
+		},
 	}
 }
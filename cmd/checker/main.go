@@ -1,17 +1,22 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/go-graphite/carbonapi/date"
 	"github.com/go-graphite/carbonapi/expr/functions"
 	"github.com/patrickmn/go-cache"
 
 	"github.com/moira-alert/moira"
+	"github.com/moira-alert/moira/api/dto"
 	"github.com/moira-alert/moira/checker"
 	"github.com/moira-alert/moira/checker/worker"
 	"github.com/moira-alert/moira/cmd"
@@ -19,6 +24,8 @@ import (
 	"github.com/moira-alert/moira/logging/go-logging"
 	"github.com/moira-alert/moira/metrics/graphite"
 	"github.com/moira-alert/moira/metrics/graphite/go-metrics"
+	"github.com/moira-alert/moira/prometheus"
+	"github.com/moira-alert/moira/remote"
 )
 
 const serviceName = "checker"
@@ -29,6 +36,10 @@ var (
 	printVersion           = flag.Bool("version", false, "Print version and exit")
 	printDefaultConfigFlag = flag.Bool("default-config", false, "Print default config and exit")
 	triggerID              = flag.String("t", "", "Check single trigger by id and exit")
+	backtestFileName       = flag.String("backtest", "", "Replay checks of trigger from json file in api format, print events and states it would have generated and exit")
+	backtestFrom           = flag.String("backtest-from", "-1day", "Start of backtest interval")
+	backtestTo             = flag.String("backtest-to", "now", "End of backtest interval")
+	backtestStep           = flag.Duration("backtest-step", time.Minute, "Interval between replayed checks")
 )
 
 // Moira checker bin version
@@ -83,20 +94,28 @@ func main() {
 	}
 
 	checkerSettings := config.Checker.getSettings()
+	remoteConfig := config.Remote.GetSettings()
+	prometheusConfig := config.Prometheus.GetSettings()
 	if triggerID != nil && *triggerID != "" {
-		checkSingleTrigger(database, checkerMetrics, checkerSettings)
+		checkSingleTrigger(database, checkerMetrics, checkerSettings, remoteConfig, prometheusConfig)
 	}
 
 	// configure carbon-api functions
 	functions.New(make(map[string]string))
 
+	if *backtestFileName != "" {
+		backtestTrigger(database, checkerSettings, remoteConfig, prometheusConfig)
+	}
+
 	checkerWorker := &worker.Checker{
-		Logger:       logger,
-		Database:     database,
-		Config:       checkerSettings,
-		Metrics:      checkerMetrics,
-		TriggerCache: cache.New(checkerSettings.CheckInterval, time.Minute*60),
-		PatternCache: cache.New(checkerSettings.CheckInterval, time.Minute*60),
+		Logger:           logger,
+		Database:         database,
+		Config:           checkerSettings,
+		Metrics:          checkerMetrics,
+		TriggerCache:     cache.New(checkerSettings.CheckInterval, time.Minute*60),
+		PatternCache:     cache.New(checkerSettings.CheckInterval, time.Minute*60),
+		RemoteConfig:     remoteConfig,
+		PrometheusConfig: prometheusConfig,
 	}
 	err = checkerWorker.Start()
 	if err != nil {
@@ -111,13 +130,16 @@ func main() {
 	logger.Infof("Moira Checker shutting down.")
 }
 
-func checkSingleTrigger(database moira.Database, metrics *graphite.CheckerMetrics, settings *checker.Config) {
+func checkSingleTrigger(database moira.Database, metrics *graphite.CheckerMetrics, settings *checker.Config, remoteConfig *remote.Config, prometheusConfig *prometheus.Config) {
 	triggerChecker := checker.TriggerChecker{
 		TriggerID: *triggerID,
 		Database:  database,
 		Logger:    logger,
 		Config:    settings,
 		Metrics:   metrics,
+
+		RemoteConfig:     remoteConfig,
+		PrometheusConfig: prometheusConfig,
 	}
 
 	err := triggerChecker.InitTriggerChecker()
@@ -132,6 +154,46 @@ func checkSingleTrigger(database moira.Database, metrics *graphite.CheckerMetric
 	os.Exit(0)
 }
 
+func backtestTrigger(database moira.Database, settings *checker.Config, remoteConfig *remote.Config, prometheusConfig *prometheus.Config) {
+	triggerBytes, err := ioutil.ReadFile(*backtestFileName)
+	if err != nil {
+		logger.Errorf("Failed to read trigger file: %s", err.Error())
+		os.Exit(1)
+	}
+	trigger := dto.TriggerModel{}
+	if err = json.Unmarshal(triggerBytes, &trigger); err != nil {
+		logger.Errorf("Failed to parse trigger: %s", err.Error())
+		os.Exit(1)
+	}
+	from := date.DateParamToEpoch(*backtestFrom, "UTC", 0, time.UTC)
+	until := date.DateParamToEpoch(*backtestTo, "UTC", 0, time.UTC)
+	if from == 0 || until == 0 {
+		logger.Errorf("Failed to parse backtest interval %s - %s", *backtestFrom, *backtestTo)
+		os.Exit(1)
+	}
+
+	triggerChecker := checker.TriggerChecker{
+		Database: database,
+		Logger:   logger,
+		Config:   settings,
+
+		RemoteConfig:     remoteConfig,
+		PrometheusConfig: prometheusConfig,
+	}
+	result, err := triggerChecker.Backtest(context.Background(), trigger.ToMoiraTrigger(), int64(from), int64(until), int64(backtestStep.Seconds()))
+	if err != nil {
+		logger.Errorf("Failed backtest trigger: %s", err.Error())
+		os.Exit(1)
+	}
+	resultBytes, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		logger.Errorf("Failed to marshal backtest result: %s", err.Error())
+		os.Exit(1)
+	}
+	fmt.Println(string(resultBytes))
+	os.Exit(0)
+}
+
 func stopChecker(service *worker.Checker) {
 	if err := service.Stop(); err != nil {
 		logger.Errorf("Failed to Stop Moira Checker: %v", err)
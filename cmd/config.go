@@ -10,6 +10,8 @@ import (
 
 	"github.com/moira-alert/moira/database/redis"
 	"github.com/moira-alert/moira/metrics/graphite"
+	"github.com/moira-alert/moira/prometheus"
+	"github.com/moira-alert/moira/remote"
 )
 
 // RedisConfig is a redis config structure that initialises at the start of moira
@@ -54,6 +56,48 @@ func (graphiteConfig *GraphiteConfig) GetSettings() graphite.Config {
 	}
 }
 
+// RemoteConfig is remote graphite settings structure that initialises at the start of moira
+type RemoteConfig struct {
+	URL           string `yaml:"url"`            // Remote graphite render API url, for example http://graphite.company.com/render. Leave empty to disable remote triggers checking.
+	CheckInterval string `yaml:"check_interval"` // Period to check remote triggers
+	Timeout       string `yaml:"timeout"`        // Remote graphite request timeout
+	User          string `yaml:"user"`           // Username for basic auth
+	Password      string `yaml:"password"`       // Password for basic auth
+}
+
+// GetSettings returns remote graphite config parsed from moira config files
+func (config *RemoteConfig) GetSettings() *remote.Config {
+	return &remote.Config{
+		URL:           config.URL,
+		CheckInterval: to.Duration(config.CheckInterval),
+		Timeout:       to.Duration(config.Timeout),
+		User:          config.User,
+		Password:      config.Password,
+	}
+}
+
+// PrometheusConfig is Prometheus settings structure that initialises at the start of moira
+type PrometheusConfig struct {
+	URL           string `yaml:"url"`            // Prometheus compatible HTTP API url, for example http://prometheus.company.com:9090. Leave empty to disable prometheus triggers checking.
+	CheckInterval string `yaml:"check_interval"` // Period to check prometheus triggers
+	Timeout       string `yaml:"timeout"`        // Prometheus request timeout
+	Step          string `yaml:"step"`           // Query resolution step, at least 1s
+	User          string `yaml:"user"`           // Username for basic auth
+	Password      string `yaml:"password"`       // Password for basic auth
+}
+
+// GetSettings returns Prometheus config parsed from moira config files
+func (config *PrometheusConfig) GetSettings() *prometheus.Config {
+	return &prometheus.Config{
+		URL:           config.URL,
+		CheckInterval: to.Duration(config.CheckInterval),
+		Timeout:       to.Duration(config.Timeout),
+		Step:          to.Duration(config.Step),
+		User:          config.User,
+		Password:      config.Password,
+	}
+}
+
 // LoggerConfig is logger settings structure that initialises at the start of moira
 type LoggerConfig struct {
 	LogFile  string `yaml:"log_file"`
@@ -13,8 +13,19 @@ type config struct {
 }
 
 type filterConfig struct {
-	Listen          string `yaml:"listen"`           // Metrics listener uri
-	RetentionConfig string `yaml:"retention-config"` // Retentions config file path. Simply use your original storage-schemas.conf or create new if you're using Moira without existing Graphite installation.
+	Listen            string `yaml:"listen"`             // Metrics listener uri
+	ListenUDP         string `yaml:"listen-udp"`         // Plaintext protocol over UDP listener uri. Leave empty to disable UDP listener.
+	ListenPickle      string `yaml:"listen-pickle"`      // Carbon pickle protocol listener uri. Leave empty to disable pickle listener.
+	ListenPrometheus  string `yaml:"listen-prometheus"`  // Prometheus remote-write receiver uri, requests are accepted at /api/v1/write. Leave empty to disable receiver.
+	PrometheusNaming  string `yaml:"prometheus-naming"`  // How to name Prometheus series: "tagged" makes graphite tagged series "name;label=value", "graphite" makes path "name.value1.value2" with values sorted by label names.
+	MaxConnections    int    `yaml:"max-connections"`    // Max number of simultaneous connections over all TCP listeners, excess connections are closed. 0 means unlimited.
+	SourceRateLimit   int64  `yaml:"source-rate-limit"`  // Max number of lines per second accepted from single remote address, excess lines are dropped. 0 means unlimited.
+	RetentionConfig   string `yaml:"retention-config"`   // Retentions config file path. Simply use your original storage-schemas.conf or create new if you're using Moira without existing Graphite installation.
+	AggregationConfig string `yaml:"aggregation-config"` // Aggregation rules file path in storage-aggregation.conf format. Values received within one retention interval are combined by matching rule before saving. Leave empty to keep the last received value.
+	RewriteRules      string `yaml:"rewrite-rules"`      // Metric name rewrite and drop rules file path. Rules are applied before matching and reloaded when file changes. Leave empty to disable rewriting.
+	PatternsRefresh   string `yaml:"patterns-refresh"`   // Interval of full pattern tree rebuild. Patterns added or removed by API are applied to the tree immediately, so rebuild is only a fallback in case of lost events.
+	SpillDir          string `yaml:"spill-dir"`          // Directory of on-disk queue for metrics which failed to save to Redis. Queued metrics are saved when Redis recovers, also after restart. Leave empty to discard such metrics.
+	SpillMaxSize      int64  `yaml:"spill-max-size"`     // Max size of on-disk queue in megabytes, oldest metrics are dropped when it is exceeded. 0 means unlimited.
 }
 
 func getDefault() config {
@@ -29,8 +40,11 @@ func getDefault() config {
 			LogLevel: "info",
 		},
 		Filter: filterConfig{
-			Listen:          ":2003",
-			RetentionConfig: "/etc/moira/storage-schemas.conf",
+			Listen:           ":2003",
+			PrometheusNaming: "tagged",
+			RetentionConfig:  "/etc/moira/storage-schemas.conf",
+			PatternsRefresh:  "1m",
+			SpillMaxSize:     1024,
 		},
 		Graphite: cmd.GraphiteConfig{
 			RuntimeStats: false,
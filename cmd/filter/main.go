@@ -7,6 +7,7 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/gosexy/to"
 	"github.com/moira-alert/moira"
 	"github.com/moira-alert/moira/cmd"
 	"github.com/moira-alert/moira/database/redis"
@@ -15,7 +16,9 @@ import (
 	"github.com/moira-alert/moira/filter/heartbeat"
 	"github.com/moira-alert/moira/filter/matched_metrics"
 	"github.com/moira-alert/moira/filter/patterns"
+	"github.com/moira-alert/moira/filter/rules"
 	"github.com/moira-alert/moira/logging/go-logging"
+	"github.com/moira-alert/moira/metrics/graphite"
 	"github.com/moira-alert/moira/metrics/graphite/go-metrics"
 )
 
@@ -88,13 +91,29 @@ func main() {
 		logger.Fatalf("Failed to initialize cache storage with config [%s]: %s", config.Filter.RetentionConfig, err.Error())
 	}
 
+	if config.Filter.AggregationConfig != "" {
+		aggregationConfigFile, err := os.Open(config.Filter.AggregationConfig)
+		if err != nil {
+			logger.Fatalf("Error open aggregations file [%s]: %s", config.Filter.AggregationConfig, err.Error())
+		}
+		if err = cacheStorage.LoadAggregations(aggregationConfigFile); err != nil {
+			logger.Fatalf("Failed to load aggregations with config [%s]: %s", config.Filter.AggregationConfig, err.Error())
+		}
+		aggregationConfigFile.Close()
+	}
+
 	patternStorage, err := filter.NewPatternStorage(database, cacheMetrics, logger)
 	if err != nil {
 		logger.Fatalf("Failed to refresh pattern storage: %s", err.Error())
 	}
 
+	patternsRefresh := to.Duration(config.Filter.PatternsRefresh)
+	if patternsRefresh <= 0 {
+		logger.Fatalf("Invalid patterns-refresh '%s': must be positive duration", config.Filter.PatternsRefresh)
+	}
+
 	// Refresh Patterns on first init
-	refreshPatternWorker := patterns.NewRefreshPatternWorker(database, cacheMetrics, logger, patternStorage)
+	refreshPatternWorker := patterns.NewRefreshPatternWorker(database, cacheMetrics, logger, patternStorage, patternsRefresh)
 
 	// Start patterns refresher
 	err = refreshPatternWorker.Start()
@@ -103,23 +122,41 @@ func main() {
 	}
 	defer stopRefreshPatternWorker(refreshPatternWorker)
 
+	// Load rewrite rules before any metric is received
+	if config.Filter.RewriteRules != "" {
+		rewriteRulesWorker := rules.NewRewriteRulesWorker(logger, patternStorage, config.Filter.RewriteRules)
+		if err = rewriteRulesWorker.Start(); err != nil {
+			logger.Fatalf("Failed to load rewrite rules [%s]: %s", config.Filter.RewriteRules, err.Error())
+		}
+		defer stopRewriteRulesWorker(rewriteRulesWorker)
+	}
+
 	// Start Filter heartbeat
 	heartbeatWorker := heartbeat.NewHeartbeatWorker(database, cacheMetrics, logger)
 	heartbeatWorker.Start()
 	defer stopHeartbeatWorker(heartbeatWorker)
 
-	// Start metrics listener
-	listener, err := connection.NewListener(config.Filter.Listen, logger, cacheMetrics, patternStorage)
+	// Start metrics listeners
+	listeners, err := configureListeners(config.Filter, cacheMetrics, patternStorage)
 	if err != nil {
 		logger.Fatalf("Failed to start listen: %s", err.Error())
 	}
-	metricsChan := listener.Listen()
+	metricsChan := listeners.Listen()
+
+	// Load metrics spilled by previous run before matcher is started
+	var spillQueue *matchedmetrics.SpillQueue
+	if config.Filter.SpillDir != "" {
+		spillQueue, err = matchedmetrics.NewSpillQueue(config.Filter.SpillDir, config.Filter.SpillMaxSize*1024*1024, cacheMetrics, logger)
+		if err != nil {
+			logger.Fatalf("Failed to open spill queue [%s]: %s", config.Filter.SpillDir, err.Error())
+		}
+	}
 
 	// Start metrics matcher
-	metricsMatcher := matchedmetrics.NewMetricsMatcher(cacheMetrics, logger, database, cacheStorage)
+	metricsMatcher := matchedmetrics.NewMetricsMatcher(cacheMetrics, logger, database, cacheStorage, spillQueue)
 	metricsMatcher.Start(metricsChan)
-	defer metricsMatcher.Wait()  // First stop listener
-	defer stopListener(listener) // Then waiting for metrics matcher handle all received events
+	defer metricsMatcher.Wait()    // First stop listeners
+	defer stopListeners(listeners) // Then waiting for metrics matcher handle all received events
 
 	logger.Infof("Moira Filter started. Version: %s", MoiraVersion)
 	ch := make(chan os.Signal, 1)
@@ -128,9 +165,43 @@ func main() {
 	logger.Infof("Moira Filter shutting down.")
 }
 
-func stopListener(listener *connection.MetricsListener) {
-	if err := listener.Stop(); err != nil {
-		logger.Errorf("Failed to stop listener: %v", err)
+func configureListeners(config filterConfig, filterMetrics *graphite.FilterMetrics, patternStorage *filter.PatternStorage) (*connection.Listeners, error) {
+	sources := connection.NewSources(connection.SourceLimits{
+		MaxConnections: config.MaxConnections,
+		LinesPerSecond: config.SourceRateLimit,
+	}, filterMetrics, logger)
+	listener, err := connection.NewListener(config.Listen, logger, patternStorage, sources)
+	if err != nil {
+		return nil, err
+	}
+	listeners := []connection.Listener{listener}
+	if config.ListenUDP != "" {
+		udpListener, err := connection.NewUDPListener(config.ListenUDP, logger, patternStorage, sources)
+		if err != nil {
+			return nil, err
+		}
+		listeners = append(listeners, udpListener)
+	}
+	if config.ListenPickle != "" {
+		pickleListener, err := connection.NewPickleListener(config.ListenPickle, logger, patternStorage, sources)
+		if err != nil {
+			return nil, err
+		}
+		listeners = append(listeners, pickleListener)
+	}
+	if config.ListenPrometheus != "" {
+		prometheusListener, err := connection.NewPrometheusListener(config.ListenPrometheus, config.PrometheusNaming, logger, patternStorage, sources)
+		if err != nil {
+			return nil, err
+		}
+		listeners = append(listeners, prometheusListener)
+	}
+	return connection.NewListeners(logger, filterMetrics, listeners...), nil
+}
+
+func stopListeners(listeners *connection.Listeners) {
+	if err := listeners.Stop(); err != nil {
+		logger.Errorf("Failed to stop listeners: %v", err)
 	}
 }
 
@@ -145,3 +216,9 @@ func stopRefreshPatternWorker(refreshPatternWorker *patterns.RefreshPatternWorke
 		logger.Errorf("Failed to stop refresh pattern worker: %v", err)
 	}
 }
+
+func stopRewriteRulesWorker(rewriteRulesWorker *rules.RewriteRulesWorker) {
+	if err := rewriteRulesWorker.Stop(); err != nil {
+		logger.Errorf("Failed to stop rewrite rules worker: %v", err)
+	}
+}
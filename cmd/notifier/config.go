@@ -23,11 +23,23 @@ type config struct {
 type notifierConfig struct {
 	SenderTimeout    string              `yaml:"sender_timeout"`    // Soft timeout to start retrying to send notification after single failed attempt
 	ResendingTimeout string              `yaml:"resending_timeout"` // Hard timeout to stop retrying to send notification after multiple failed attempts
+	LeaseTimeout     string              `yaml:"lease_timeout"`     // Timeout after which fetched but not sent notifications are fetched again by any notifier
 	Senders          []map[string]string `yaml:"senders"`           // Senders configuration section. See https://moira.readthedocs.io/en/latest/installation/configuration.html for more explanation
 	SelfState        selfStateConfig     `yaml:"moira_selfstate"`   // Self state monitor configuration section. Note: No inner subscriptions is required. It's own notification mechanism will be used.
 	FrontURI         string              `yaml:"front_uri"`         // Web-UI uri prefix for trigger links in notifications. For example: with 'http://localhost' every notification will contain link like 'http://localhost/trigger/triggerId'
 	Timezone         string              `yaml:"timezone"`          // Timezone to use to convert ticks. Default is UTC. See https://golang.org/pkg/time/#LoadLocation for more details.
 	DateTimeFormat   string              `yaml:"date_time_format"`  // Format for email sender. Default is "15:04 02.01.2006". See https://golang.org/pkg/time/#Time.Format for more details about golang time formatting.
+	Throttling       []throttlingConfig  `yaml:"throttling"`        // Throttling levels, checked in given order. Subscriptions can override them with own levels
+}
+
+// leaseTimeoutSenderTimeouts is minimal ratio of lease timeout to sender timeout,
+// so fetched notifications are sent or rescheduled before their lease ends and they are fetched again
+const leaseTimeoutSenderTimeouts = 3
+
+type throttlingConfig struct {
+	Duration string `yaml:"duration"` // If trigger switches Count times in Duration, its notifications are delayed for Delay
+	Delay    string `yaml:"delay"`
+	Count    int64  `yaml:"count"`
 }
 
 type selfStateConfig struct {
@@ -59,6 +71,7 @@ func getDefault() config {
 		Notifier: notifierConfig{
 			SenderTimeout:    "10s",
 			ResendingTimeout: "1:00",
+			LeaseTimeout:     "5m",
 			SelfState: selfStateConfig{
 				Enabled:                 false,
 				RedisDisconnectDelay:    "30s",
@@ -68,6 +81,10 @@ func getDefault() config {
 			},
 			FrontURI: "http://localhost",
 			Timezone: "UTC",
+			Throttling: []throttlingConfig{
+				{Duration: "3h", Delay: "1h", Count: 20},
+				{Duration: "1h", Delay: "30m", Count: 10},
+			},
 		},
 		Pprof: cmd.ProfilerConfig{
 			Listen: "",
@@ -75,7 +92,7 @@ func getDefault() config {
 	}
 }
 
-func (config *notifierConfig) getSettings(logger moira.Logger) notifier.Config {
+func (config *notifierConfig) getSettings(logger moira.Logger) (notifier.Config, error) {
 	location, err := time.LoadLocation(config.Timezone)
 	if err != nil {
 		logger.Warningf("Timezone '%s' load failed: %s. Use UTC.", config.Timezone, err.Error())
@@ -92,14 +109,38 @@ func (config *notifierConfig) getSettings(logger moira.Logger) notifier.Config {
 		logger.Infof("Format '%v' parsed successfully. Current time format: %v", format, time.Now().Format(format))
 	}
 
+	sendingTimeout := to.Duration(config.SenderTimeout)
+	leaseTimeout := to.Duration(config.LeaseTimeout)
+	if leaseTimeout <= 0 {
+		return notifier.Config{}, fmt.Errorf("lease_timeout '%s' must be positive duration", config.LeaseTimeout)
+	}
+	if leaseTimeout < leaseTimeoutSenderTimeouts*sendingTimeout {
+		return notifier.Config{}, fmt.Errorf("lease_timeout %v must be at least %d times greater than sender_timeout %v", leaseTimeout, leaseTimeoutSenderTimeouts, sendingTimeout)
+	}
+
+	throttlingLevels := make([]moira.ThrottlingLevel, 0, len(config.Throttling))
+	for _, level := range config.Throttling {
+		throttlingLevel := moira.ThrottlingLevel{
+			Duration: int64(to.Duration(level.Duration).Seconds()),
+			Delay:    int64(to.Duration(level.Delay).Seconds()),
+			Count:    level.Count,
+		}
+		if throttlingLevel.Duration <= 0 || throttlingLevel.Delay <= 0 || throttlingLevel.Count <= 0 {
+			return notifier.Config{}, fmt.Errorf("throttling level duration '%s', delay '%s' and count %d must be positive", level.Duration, level.Delay, level.Count)
+		}
+		throttlingLevels = append(throttlingLevels, throttlingLevel)
+	}
+
 	return notifier.Config{
-		SendingTimeout:   to.Duration(config.SenderTimeout),
+		SendingTimeout:   sendingTimeout,
 		ResendingTimeout: to.Duration(config.ResendingTimeout),
+		LeaseTimeout:     leaseTimeout,
 		Senders:          config.Senders,
 		FrontURL:         config.FrontURI,
 		Location:         location,
 		DateTimeFormat:   format,
-	}
+		ThrottlingLevels: throttlingLevels,
+	}, nil
 }
 
 func checkDateTimeFormat(format string) error {
@@ -78,7 +78,10 @@ func main() {
 	databaseSettings := config.Redis.GetSettings()
 	database := redis.NewDatabase(logger, databaseSettings)
 
-	notifierConfig := config.Notifier.getSettings(logger)
+	notifierConfig, err := config.Notifier.getSettings(logger)
+	if err != nil {
+		logger.Fatalf("Invalid notifier config: %s", err.Error())
+	}
 	sender := notifier.NewNotifier(database, logger, notifierConfig, notifierMetrics)
 
 	// Register moira senders
@@ -101,9 +104,10 @@ func main() {
 
 	// Start moira notification fetcher
 	fetchNotificationsWorker := &notifications.FetchNotificationsWorker{
-		Logger:   logger,
-		Database: database,
-		Notifier: sender,
+		Logger:       logger,
+		Database:     database,
+		Notifier:     sender,
+		LeaseTimeout: notifierConfig.LeaseTimeout,
 	}
 	fetchNotificationsWorker.Start()
 	defer stopNotificationsFetcher(fetchNotificationsWorker)
@@ -112,7 +116,7 @@ func main() {
 	fetchEventsWorker := &events.FetchEventsWorker{
 		Logger:    logger,
 		Database:  database,
-		Scheduler: notifier.NewScheduler(database, logger, notifierMetrics),
+		Scheduler: notifier.NewScheduler(database, logger, notifierMetrics, notifierConfig.ThrottlingLevels),
 		Metrics:   notifierMetrics,
 	}
 	fetchEventsWorker.Start()
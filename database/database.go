@@ -4,3 +4,6 @@ import "fmt"
 
 // ErrNil return from database data storing methods if no object in DB
 var ErrNil = fmt.Errorf("Nil returned")
+
+// ErrUnknownMetric return from database data storing methods if object does not contain given metric
+var ErrUnknownMetric = fmt.Errorf("Unknown metric")
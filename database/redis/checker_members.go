@@ -0,0 +1,47 @@
+package redis
+
+import (
+	"fmt"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+// UpdateCheckerMember registers checker instance or renews its registration with given heartbeat timestamp
+func (connector *DbConnector) UpdateCheckerMember(memberID string, timestamp int64) error {
+	c := connector.pool.Get()
+	defer c.Close()
+	if _, err := c.Do("ZADD", checkerMembersKey, timestamp, memberID); err != nil {
+		return fmt.Errorf("Failed to update checker member %s: %s", memberID, err.Error())
+	}
+	return nil
+}
+
+// GetCheckerMembers removes checker instances without heartbeat since given timestamp and returns alive ones
+func (connector *DbConnector) GetCheckerMembers(aliveSince int64) ([]string, error) {
+	c := connector.pool.Get()
+	defer c.Close()
+	c.Send("MULTI")
+	c.Send("ZREMRANGEBYSCORE", checkerMembersKey, "-inf", fmt.Sprintf("(%d", aliveSince))
+	c.Send("ZRANGE", checkerMembersKey, 0, -1)
+	rawResponse, err := redis.Values(c.Do("EXEC"))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get checker members: %s", err.Error())
+	}
+	members, err := redis.Strings(rawResponse[1], nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get checker members: %s", err.Error())
+	}
+	return members, nil
+}
+
+// RemoveCheckerMember deregisters checker instance
+func (connector *DbConnector) RemoveCheckerMember(memberID string) error {
+	c := connector.pool.Get()
+	defer c.Close()
+	if _, err := c.Do("ZREM", checkerMembersKey, memberID); err != nil {
+		return fmt.Errorf("Failed to remove checker member %s: %s", memberID, err.Error())
+	}
+	return nil
+}
+
+var checkerMembersKey = "moira-checker-members"
@@ -0,0 +1,68 @@
+package redis
+
+import (
+	"testing"
+
+	"github.com/op/go-logging"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCheckerMembers(t *testing.T) {
+	logger, _ := logging.GetLogger("dataBase")
+	dataBase := NewDatabase(logger, config)
+	dataBase.flush()
+	defer dataBase.flush()
+
+	Convey("Checker members manipulation", t, func() {
+		members, err := dataBase.GetCheckerMembers(0)
+		So(err, ShouldBeNil)
+		So(members, ShouldBeEmpty)
+
+		err = dataBase.UpdateCheckerMember("checker-1", 100)
+		So(err, ShouldBeNil)
+		err = dataBase.UpdateCheckerMember("checker-2", 200)
+		So(err, ShouldBeNil)
+
+		members, err = dataBase.GetCheckerMembers(100)
+		So(err, ShouldBeNil)
+		So(members, ShouldResemble, []string{"checker-1", "checker-2"})
+
+		Convey("Expired members are removed", func() {
+			members, err = dataBase.GetCheckerMembers(150)
+			So(err, ShouldBeNil)
+			So(members, ShouldResemble, []string{"checker-2"})
+
+			err = dataBase.UpdateCheckerMember("checker-1", 300)
+			So(err, ShouldBeNil)
+			members, err = dataBase.GetCheckerMembers(150)
+			So(err, ShouldBeNil)
+			So(members, ShouldResemble, []string{"checker-2", "checker-1"})
+		})
+
+		Convey("Removed member is not returned", func() {
+			err = dataBase.RemoveCheckerMember("checker-1")
+			So(err, ShouldBeNil)
+			members, err = dataBase.GetCheckerMembers(0)
+			So(err, ShouldBeNil)
+			So(members, ShouldResemble, []string{"checker-2"})
+		})
+	})
+}
+
+func TestCheckerMembersErrorConnection(t *testing.T) {
+	logger, _ := logging.GetLogger("dataBase")
+	dataBase := NewDatabase(logger, emptyConfig)
+	dataBase.flush()
+	defer dataBase.flush()
+	Convey("Should throw error when no connection", t, func() {
+		err := dataBase.UpdateCheckerMember("checker-1", 100)
+		So(err, ShouldNotBeNil)
+
+		members, err := dataBase.GetCheckerMembers(0)
+		So(err, ShouldNotBeNil)
+		So(members, ShouldBeNil)
+
+		err = dataBase.RemoveCheckerMember("checker-1")
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -20,6 +20,9 @@ const pubSubWorkerChannelSize = 16384
 const (
 	cacheCleanupInterval         = time.Minute * 60
 	cacheValueExpirationDuration = time.Minute
+	// retentionSavingCacheExpiration is interval of saving metric retention and tag index again,
+	// so series unindexed by other process after its data had been removed is indexed once it is recreated
+	retentionSavingCacheExpiration = time.Minute * 10
 )
 
 const (
@@ -44,7 +47,7 @@ func NewDatabase(logger moira.Logger, config Config) *DbConnector {
 		pool:                 pool,
 		logger:               logger,
 		retentionCache:       cache.New(cacheValueExpirationDuration, cacheCleanupInterval),
-		retentionSavingCache: cache.New(cache.NoExpiration, cache.DefaultExpiration),
+		retentionSavingCache: cache.New(retentionSavingCacheExpiration, cacheCleanupInterval),
 		metricsCache:         cache.New(cacheValueExpirationDuration, cacheCleanupInterval),
 		messengersCache:      cache.New(cache.NoExpiration, cache.DefaultExpiration),
 		sync:                 redsync.New([]redsync.Pool{pool}),
@@ -190,7 +193,7 @@ func (connector *DbConnector) manageSubscriptions(tomb *tomb.Tomb, channel strin
 				}
 			case *net.OpError:
 				connector.logger.Infof("psc.Receive() returned *net.OpError: %s. Reconnecting...", n.Err.Error())
-				newPsc, err := connector.makePubSubConnection(metricEventKey)
+				newPsc, err := connector.makePubSubConnection(channel)
 				if err != nil {
 					connector.logger.Errorf("Failed to reconnect to subscription: %v", err)
 					<-time.After(receiveErrorSleepDuration)
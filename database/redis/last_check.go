@@ -104,6 +104,55 @@ func (connector *DbConnector) SetTriggerCheckMetricsMaintenance(triggerID string
 	return nil
 }
 
+// SetTriggerCheckAcknowledgement sets acknowledgement of given trigger metric or of whole trigger if metric is empty,
+// nil acknowledgement removes it. If during the update lastCheck was updated from another place, try update again
+// If there is no trigger last check it returns database.ErrNil, if CheckData does not contain given metric it returns database.ErrUnknownMetric
+func (connector *DbConnector) SetTriggerCheckAcknowledgement(triggerID, metric string, acknowledgement *moira.Acknowledgement) error {
+	c := connector.pool.Get()
+	defer c.Close()
+	var readingErr error
+
+	lastCheckString, readingErr := redis.String(c.Do("GET", metricLastCheckKey(triggerID)))
+	if readingErr != nil {
+		if readingErr == redis.ErrNil {
+			return database.ErrNil
+		}
+		return readingErr
+	}
+	for readingErr != redis.ErrNil {
+		var lastCheck = moira.CheckData{}
+		err := json.Unmarshal([]byte(lastCheckString), &lastCheck)
+		if err != nil {
+			return fmt.Errorf("Failed to parse lastCheck json %s: %s", lastCheckString, err.Error())
+		}
+		if metric == "" {
+			lastCheck.Acknowledgement = acknowledgement
+		} else {
+			data, ok := lastCheck.Metrics[metric]
+			if !ok {
+				return database.ErrUnknownMetric
+			}
+			data.Acknowledgement = acknowledgement
+			lastCheck.Metrics[metric] = data
+		}
+		newLastCheck, err := json.Marshal(lastCheck)
+		if err != nil {
+			return err
+		}
+
+		var prev string
+		prev, readingErr = redis.String(c.Do("GETSET", metricLastCheckKey(triggerID), newLastCheck))
+		if readingErr != nil && readingErr != redis.ErrNil {
+			return readingErr
+		}
+		if prev == lastCheckString {
+			break
+		}
+		lastCheckString = prev
+	}
+	return nil
+}
+
 // GetTriggerCheckIDs gets checked triggerIDs, sorted from max to min check score and filtered by given tags
 // If onlyErrors return only triggerIDs with score > 0
 func (connector *DbConnector) GetTriggerCheckIDs(tagNames []string, onlyErrors bool) ([]string, error) {
@@ -97,6 +97,42 @@ func TestLastCheck(t *testing.T) {
 			})
 		})
 
+		Convey("Test set acknowledgement", func() {
+			triggerID := uuid.NewV4().String()
+			checkData := moira.CheckData{
+				State:     "OK",
+				Timestamp: 1504509981,
+				Metrics: map[string]moira.MetricState{
+					"metric1": {State: "ERROR", Timestamp: 1504509380},
+				},
+			}
+			err := dataBase.SetTriggerLastCheck(triggerID, &checkData)
+			So(err, ShouldBeNil)
+
+			acknowledgement := &moira.Acknowledgement{User: "user", Timestamp: 1504509990, Until: 1504513590}
+			err = dataBase.SetTriggerCheckAcknowledgement(triggerID, "metric1", acknowledgement)
+			So(err, ShouldBeNil)
+			err = dataBase.SetTriggerCheckAcknowledgement(triggerID, "metric2", acknowledgement)
+			So(err, ShouldResemble, database.ErrUnknownMetric)
+			err = dataBase.SetTriggerCheckAcknowledgement(triggerID, "", acknowledgement)
+			So(err, ShouldBeNil)
+
+			actual, err := dataBase.GetTriggerLastCheck(triggerID)
+			So(err, ShouldBeNil)
+			So(actual.Acknowledgement, ShouldResemble, acknowledgement)
+			So(actual.Metrics["metric1"].Acknowledgement, ShouldResemble, acknowledgement)
+			So(actual.Metrics, ShouldHaveLength, 1)
+
+			err = dataBase.SetTriggerCheckAcknowledgement(triggerID, "metric1", nil)
+			So(err, ShouldBeNil)
+			actual, err = dataBase.GetTriggerLastCheck(triggerID)
+			So(err, ShouldBeNil)
+			So(actual.Metrics["metric1"].Acknowledgement, ShouldBeNil)
+
+			err = dataBase.SetTriggerCheckAcknowledgement(uuid.NewV4().String(), "", acknowledgement)
+			So(err, ShouldResemble, database.ErrNil)
+		})
+
 		Convey("Test get trigger check ids", func() {
 			dataBase.flush()
 			okTriggerID := uuid.NewV4().String()
@@ -136,6 +172,9 @@ func TestLastCheckErrorConnection(t *testing.T) {
 		err = dataBase.SetTriggerCheckMetricsMaintenance("123", map[string]int64{})
 		So(err, ShouldNotBeNil)
 
+		err = dataBase.SetTriggerCheckAcknowledgement("123", "", nil)
+		So(err, ShouldNotBeNil)
+
 		actual2, err := dataBase.GetTriggerCheckIDs(make([]string, 0), true)
 		So(actual2, ShouldResemble, []string(nil))
 		So(err, ShouldNotBeNil)
@@ -50,6 +50,62 @@ func (connector *DbConnector) GetMetricsValues(metrics []string, from int64, unt
 	return res, nil
 }
 
+// GetMetricsArchiveValues gets metrics values of archive with given precision for given interval
+func (connector *DbConnector) GetMetricsArchiveValues(metrics []string, precision int64, from int64, until int64) (map[string][]*moira.MetricValue, error) {
+	c := connector.pool.Get()
+	defer c.Close()
+
+	for _, metric := range metrics {
+		c.Send("ZRANGEBYSCORE", metricArchiveDataKey(metric, precision), from, until, "WITHSCORES")
+	}
+	resultByMetrics, err := redis.Values(c.Do(""))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get metric archive values: %v", err)
+	}
+
+	res := make(map[string][]*moira.MetricValue, len(resultByMetrics))
+
+	for i, resultByMetric := range resultByMetrics {
+		metric := metrics[i]
+		metricsValues, err := reply.MetricValues(resultByMetric)
+		if err != nil {
+			return nil, err
+		}
+		res[metric] = metricsValues
+	}
+	return res, nil
+}
+
+// GetMetricArchives gets given metric archives from the finest to the coarsest one.
+// Metric without saved archives has single archive of its retention, which is not limited by time
+func (connector *DbConnector) GetMetricArchives(metric string) ([]moira.MetricArchive, error) {
+	if value, ok := connector.retentionCache.Get(metricArchivesKey(metric)); ok {
+		if archives, ok := value.([]moira.MetricArchive); ok {
+			return archives, nil
+		}
+	}
+	c := connector.pool.Get()
+	archivesBytes, err := redis.Bytes(c.Do("GET", metricArchivesKey(metric)))
+	c.Close()
+	var archives []moira.MetricArchive
+	switch err {
+	case nil:
+		if err = json.Unmarshal(archivesBytes, &archives); err != nil {
+			return nil, fmt.Errorf("Failed to parse metric archives:%s, error: %v", metric, err)
+		}
+	case redis.ErrNil:
+		retention, err := connector.GetMetricRetention(metric)
+		if err != nil {
+			return nil, err
+		}
+		archives = []moira.MetricArchive{{Precision: retention}}
+	default:
+		return nil, fmt.Errorf("Failed GET metric archives:%s, error: %v", metric, err)
+	}
+	connector.retentionCache.Set(metricArchivesKey(metric), archives, 0)
+	return archives, nil
+}
+
 // GetMetricRetention gets given metric retention, if retention is empty then return default retention value(60)
 func (connector *DbConnector) GetMetricRetention(metric string) (int64, error) {
 	retention, ok := connector.getCachedRetention(metric)
@@ -96,10 +152,35 @@ func (connector *DbConnector) SaveMetrics(metrics map[string]*moira.MatchedMetri
 	defer c.Close()
 	for _, metric := range metrics {
 		metricValue := fmt.Sprintf("%v %v", metric.Timestamp, metric.Value)
-		c.Send("ZADD", metricDataKey(metric.Metric), metric.RetentionTimestamp, metricValue)
+		if metric.Aggregate != nil {
+			sendMergeMetricAggregate(c, metricDataKey(metric.Metric), metricAggregateKey(metric.Metric, int64(metric.Retention), metric.RetentionTimestamp),
+				metric.RetentionTimestamp, int64(metric.Retention), metric.Aggregate)
+		} else {
+			c.Send("ZADD", metricDataKey(metric.Metric), metric.RetentionTimestamp, metricValue)
+		}
 
 		if err := connector.retentionSavingCache.Add(metric.Metric, true, cache.DefaultExpiration); err == nil {
 			c.Send("SET", metricRetentionKey(metric.Metric), metric.Retention)
+			if len(metric.Archives) > 1 {
+				if archives, err := json.Marshal(metric.Archives); err == nil {
+					c.Send("SET", metricArchivesKey(metric.Metric), archives)
+				}
+			} else {
+				c.Send("DEL", metricArchivesKey(metric.Metric))
+			}
+			// New series, or series unindexed after its data had been removed, is indexed once retention saving cache misses
+			if moira.IsTaggedMetric(metric.Metric) {
+				connector.indexTaggedMetric(c, metric.Metric)
+			}
+		}
+
+		for _, rollup := range metric.Rollups {
+			archiveKey := metricArchiveDataKey(metric.Metric, rollup.Archive.Precision)
+			sendMergeMetricAggregate(c, archiveKey, metricAggregateKey(metric.Metric, rollup.Archive.Precision, rollup.RetentionTimestamp),
+				rollup.RetentionTimestamp, rollup.Archive.Precision, &rollup.Aggregate)
+			if rollup.Archive.Duration > 0 {
+				c.Send("ZREMRANGEBYSCORE", archiveKey, "-inf", rollup.RetentionTimestamp-rollup.Archive.Duration)
+			}
 		}
 
 		for _, pattern := range metric.Patterns {
@@ -117,6 +198,190 @@ func (connector *DbConnector) SaveMetrics(metrics map[string]*moira.MatchedMetri
 	return c.Flush()
 }
 
+// aggregateLateValuesTTL is how long in seconds aggregate of retention interval is kept after its last update
+// in addition to interval precision to merge values received late
+const aggregateLateValuesTTL = 600
+
+// mergeMetricAggregateScript merges aggregate given in ARGV into aggregate KEYS[2] of retention interval ARGV[1]
+// and replaces value of this interval in series KEYS[1] by value of merged aggregate.
+// Aggregate expired before late values of interval are received is not recreated not to overwrite saved value by partial one
+var mergeMetricAggregateScript = redis.NewScript(2, `
+if redis.call('EXISTS', KEYS[2]) == 0 and #redis.call('ZRANGEBYSCORE', KEYS[1], ARGV[1], ARGV[1]) > 0 then
+	return 0
+end
+local count = redis.call('HINCRBY', KEYS[2], 'count', ARGV[4])
+local sum = redis.call('HINCRBYFLOAT', KEYS[2], 'sum', ARGV[5])
+local min = redis.call('HGET', KEYS[2], 'min')
+if not min or tonumber(ARGV[6]) < tonumber(min) then
+	min = ARGV[6]
+	redis.call('HSET', KEYS[2], 'min', min)
+end
+local max = redis.call('HGET', KEYS[2], 'max')
+if not max or tonumber(ARGV[7]) > tonumber(max) then
+	max = ARGV[7]
+	redis.call('HSET', KEYS[2], 'max', max)
+end
+local timestamp = redis.call('HGET', KEYS[2], 'timestamp')
+local last = redis.call('HGET', KEYS[2], 'last')
+if not timestamp or tonumber(ARGV[8]) >= tonumber(timestamp) then
+	timestamp = ARGV[8]
+	last = ARGV[9]
+	redis.call('HMSET', KEYS[2], 'timestamp', timestamp, 'last', last)
+end
+redis.call('EXPIRE', KEYS[2], ARGV[2])
+local value = last
+if ARGV[3] == 'average' then
+	value = string.format('%.17g', tonumber(sum) / count)
+elseif ARGV[3] == 'sum' then
+	value = sum
+elseif ARGV[3] == 'min' then
+	value = min
+elseif ARGV[3] == 'max' then
+	value = max
+end
+redis.call('ZREMRANGEBYSCORE', KEYS[1], ARGV[1], ARGV[1])
+redis.call('ZADD', KEYS[1], ARGV[1], timestamp .. ' ' .. value)
+return 1
+`)
+
+// sendMergeMetricAggregate merges aggregate of retention interval with aggregate saved by other filters or before restart,
+// so series keeps single value per retention interval aggregated from all received values
+func sendMergeMetricAggregate(c redis.Conn, dataKey string, aggregateKey string, retentionTimestamp int64, precision int64, aggregate *moira.MetricAggregate) {
+	mergeMetricAggregateScript.Send(c, dataKey, aggregateKey, retentionTimestamp, precision+aggregateLateValuesTTL,
+		aggregate.Method, aggregate.Count, aggregate.Sum, aggregate.Min, aggregate.Max, aggregate.Timestamp, aggregate.Last)
+}
+
+// indexTaggedMetric adds tagged series to the sets of series having each of its tag values
+func (connector *DbConnector) indexTaggedMetric(c redis.Conn, metric string) {
+	name, tags, err := moira.ParseTaggedMetric(metric)
+	if err != nil {
+		connector.logger.Warningf("Failed to index tagged metric %s: %v", metric, err)
+		return
+	}
+	tags[moira.MetricNameTag] = name
+	for tagName, tagValue := range tags {
+		c.Send("SADD", metricTagValuesKey(tagName), tagValue)
+		c.Send("SADD", metricTagSeriesKey(tagName, tagValue), metric)
+	}
+}
+
+// unindexTaggedMetricScript removes series KEYS[1] from tag index sets if it has no data left.
+// Each tag is given by pair of its values and series keys and its value in ARGV
+var unindexTaggedMetricScript = redis.NewScript(-1, `
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return 0
+end
+for i = 2, #KEYS, 2 do
+	redis.call('SREM', KEYS[i + 1], ARGV[1])
+	if redis.call('SCARD', KEYS[i + 1]) == 0 then
+		redis.call('SREM', KEYS[i], ARGV[i / 2 + 1])
+	end
+end
+return 1
+`)
+
+// sendUnindexTaggedMetric removes tagged series without data left from tag index.
+// Series is forgotten by retention saving cache, so it is indexed again by next SaveMetrics
+func (connector *DbConnector) sendUnindexTaggedMetric(c redis.Conn, metric string) {
+	if keysAndArgs, ok := taggedMetricIndexKeysAndArgs(metric); ok {
+		unindexTaggedMetricScript.Send(c, keysAndArgs...)
+		connector.retentionSavingCache.Delete(metric)
+	}
+}
+
+// taggedMetricIndexKeysAndArgs returns keys and args of unindexTaggedMetricScript for given tagged series
+func taggedMetricIndexKeysAndArgs(metric string) ([]interface{}, bool) {
+	if !moira.IsTaggedMetric(metric) {
+		return nil, false
+	}
+	name, tags, err := moira.ParseTaggedMetric(metric)
+	if err != nil {
+		return nil, false
+	}
+	tags[moira.MetricNameTag] = name
+	keys := []interface{}{metricDataKey(metric)}
+	args := []interface{}{metric}
+	for tagName, tagValue := range tags {
+		keys = append(keys, metricTagValuesKey(tagName), metricTagSeriesKey(tagName, tagValue))
+		args = append(args, tagValue)
+	}
+	keysAndArgs := append([]interface{}{len(keys)}, keys...)
+	return append(keysAndArgs, args...), true
+}
+
+// GetTaggedMetrics gets all tagged series matching given seriesByTag tag expressions
+func (connector *DbConnector) GetTaggedMetrics(tagSpecs []moira.TagSpec) ([]string, error) {
+	matcher, err := moira.NewSeriesByTagMatcher(tagSpecs)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := connector.getTaggedMetricsCandidates(tagSpecs)
+	if err != nil {
+		return nil, err
+	}
+	metrics := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		name, tags, err := moira.ParseTaggedMetric(candidate)
+		if err != nil {
+			continue
+		}
+		if matcher.Match(name, tags) {
+			metrics = append(metrics, candidate)
+		}
+	}
+	return metrics, nil
+}
+
+// getTaggedMetricsCandidates uses tag index to get series that satisfy positive tag expressions.
+// Every series is checked against all tag expressions afterwards
+func (connector *DbConnector) getTaggedMetricsCandidates(tagSpecs []moira.TagSpec) ([]string, error) {
+	c := connector.pool.Get()
+	defer c.Close()
+
+	equalKeys := make([]interface{}, 0)
+	for _, tagSpec := range tagSpecs {
+		if tagSpec.Operator == moira.EqualOperator && tagSpec.Value != "" {
+			equalKeys = append(equalKeys, metricTagSeriesKey(tagSpec.Name, tagSpec.Value))
+		}
+	}
+	if len(equalKeys) > 0 {
+		metrics, err := redis.Strings(c.Do("SINTER", equalKeys...))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get tagged metrics, error: %v", err)
+		}
+		return metrics, nil
+	}
+
+	for _, tagSpec := range tagSpecs {
+		if tagSpec.Operator != moira.MatchOperator || tagSpec.Value == "" {
+			continue
+		}
+		valueMatcher, err := moira.CompileTagSpec(tagSpec)
+		if err != nil {
+			return nil, err
+		}
+		values, err := redis.Strings(c.Do("SMEMBERS", metricTagValuesKey(tagSpec.Name)))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get values of tag %s, error: %v", tagSpec.Name, err)
+		}
+		matchedKeys := make([]interface{}, 0)
+		for _, value := range values {
+			if valueMatcher(value) {
+				matchedKeys = append(matchedKeys, metricTagSeriesKey(tagSpec.Name, value))
+			}
+		}
+		if len(matchedKeys) == 0 {
+			return make([]string, 0), nil
+		}
+		metrics, err := redis.Strings(c.Do("SUNION", matchedKeys...))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get tagged metrics, error: %v", err)
+		}
+		return metrics, nil
+	}
+	return nil, fmt.Errorf("seriesByTag has no tag expressions matching non-empty value: %s", moira.FormatSeriesByTag(tagSpecs))
+}
+
 // SubscribeMetricEvents creates subscription for new metrics and return channel for this events
 func (connector *DbConnector) SubscribeMetricEvents(tomb *tomb.Tomb) (<-chan *moira.MetricEvent, error) {
 	metricsChannel := make(chan *moira.MetricEvent, pubSubWorkerChannelSize)
@@ -145,6 +410,34 @@ func (connector *DbConnector) SubscribeMetricEvents(tomb *tomb.Tomb) (<-chan *mo
 	return metricsChannel, nil
 }
 
+// SubscribePatternEvents creates subscription for pattern adding and removing and return channel for this events
+func (connector *DbConnector) SubscribePatternEvents(tomb *tomb.Tomb) (<-chan *moira.PatternEvent, error) {
+	patternsChannel := make(chan *moira.PatternEvent, pubSubWorkerChannelSize)
+	dataChannel, err := connector.manageSubscriptions(tomb, patternEventKey)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		for {
+			data, ok := <-dataChannel
+			if !ok {
+				connector.logger.Info("No more subscriptions, channel is closed. Stop process data...")
+				close(patternsChannel)
+				return
+			}
+			patternEvent := &moira.PatternEvent{}
+			if err := json.Unmarshal(data, patternEvent); err != nil {
+				connector.logger.Errorf("Failed to parse PatternEvent: %s, error : %v", string(data), err)
+				continue
+			}
+			patternsChannel <- patternEvent
+		}
+	}()
+
+	return patternsChannel, nil
+}
+
 // AddPatternMetric adds new metrics by given pattern
 func (connector *DbConnector) AddPatternMetric(pattern, metric string) error {
 	c := connector.pool.Get()
@@ -174,7 +467,10 @@ func (connector *DbConnector) GetPatternMetrics(pattern string) ([]string, error
 func (connector *DbConnector) RemovePattern(pattern string) error {
 	c := connector.pool.Get()
 	defer c.Close()
-	if _, err := c.Do("SREM", patternsListKey, pattern); err != nil {
+	c.Send("MULTI")
+	c.Send("SREM", patternsListKey, pattern)
+	c.Send("PUBLISH", patternEventKey, patternEventBytes(pattern, true))
+	if _, err := c.Do("EXEC"); err != nil {
 		return fmt.Errorf("Failed to remove pattern: %s, error: %v", pattern, err)
 	}
 	return nil
@@ -200,12 +496,24 @@ func (connector *DbConnector) RemovePatternWithMetrics(pattern string) error {
 	if err != nil {
 		return err
 	}
+	metricsArchives := make(map[string][]moira.MetricArchive, len(metrics))
+	for _, metric := range metrics {
+		if metricsArchives[metric], err = connector.GetMetricArchives(metric); err != nil {
+			return err
+		}
+	}
 	c := connector.pool.Get()
 	defer c.Close()
 	c.Send("MULTI")
 	c.Send("SREM", patternsListKey, pattern)
+	c.Send("PUBLISH", patternEventKey, patternEventBytes(pattern, true))
 	for _, metric := range metrics {
 		c.Send("DEL", metricDataKey(metric))
+		for _, archive := range metricsArchives[metric][1:] {
+			c.Send("DEL", metricArchiveDataKey(metric, archive.Precision))
+		}
+		c.Send("DEL", metricArchivesKey(metric))
+		connector.sendUnindexTaggedMetric(c, metric)
 	}
 	c.Send("DEL", patternMetricsKey(pattern))
 	if _, err = c.Do("EXEC"); err != nil {
@@ -221,7 +529,10 @@ func (connector *DbConnector) RemoveMetricValues(metric string, toTime int64) er
 	}
 	c := connector.pool.Get()
 	defer c.Close()
-	if _, err := c.Do("ZREMRANGEBYSCORE", metricDataKey(metric), "-inf", toTime); err != nil {
+	c.Send("MULTI")
+	c.Send("ZREMRANGEBYSCORE", metricDataKey(metric), "-inf", toTime)
+	connector.sendUnindexTaggedMetric(c, metric)
+	if _, err := c.Do("EXEC"); err != nil {
 		return fmt.Errorf("Failed to remove metrics from -inf to %v, error: %v", toTime, err)
 	}
 	return nil
@@ -236,6 +547,7 @@ func (connector *DbConnector) RemoveMetricsValues(metrics []string, toTime int64
 	for _, metric := range metrics {
 		if connector.needRemoveMetrics(metric) {
 			c.Send("ZREMRANGEBYSCORE", metricDataKey(metric), "-inf", toTime)
+			connector.sendUnindexTaggedMetric(c, metric)
 		}
 	}
 	if _, err := c.Do("EXEC"); err != nil {
@@ -251,6 +563,12 @@ func (connector *DbConnector) needRemoveMetrics(metric string) bool {
 
 var patternsListKey = "moira-pattern-list"
 var metricEventKey = "metric-event"
+var patternEventKey = "pattern-event"
+
+func patternEventBytes(pattern string, removed bool) []byte {
+	event, _ := json.Marshal(&moira.PatternEvent{Pattern: pattern, Removed: removed})
+	return event
+}
 
 func patternMetricsKey(pattern string) string {
 	return fmt.Sprintf("moira-pattern-metrics:%s", pattern)
@@ -260,6 +578,26 @@ func metricDataKey(metric string) string {
 	return fmt.Sprintf("moira-metric-data:%s", metric)
 }
 
+func metricArchiveDataKey(metric string, precision int64) string {
+	return fmt.Sprintf("moira-metric-archive-data:%d:%s", precision, metric)
+}
+
+func metricAggregateKey(metric string, precision int64, retentionTimestamp int64) string {
+	return fmt.Sprintf("moira-metric-aggregate:%d:%d:%s", precision, retentionTimestamp, metric)
+}
+
+func metricArchivesKey(metric string) string {
+	return fmt.Sprintf("moira-metric-archives:%s", metric)
+}
+
 func metricRetentionKey(metric string) string {
 	return fmt.Sprintf("moira-metric-retention:%s", metric)
 }
+
+func metricTagValuesKey(tagName string) string {
+	return fmt.Sprintf("moira-metric-tag-values:%s", tagName)
+}
+
+func metricTagSeriesKey(tagName, tagValue string) string {
+	return fmt.Sprintf("moira-metric-tag-series:%s=%s", tagName, tagValue)
+}
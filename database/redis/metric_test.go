@@ -1,6 +1,7 @@
 package redis
 
 import (
+	"sort"
 	"testing"
 	"time"
 
@@ -183,6 +184,124 @@ func TestMetricsStoring(t *testing.T) {
 	})
 }
 
+func TestSaveMetricsMergesAggregates(t *testing.T) {
+	logger, _ := logging.GetLogger("dataBase")
+	dataBase := NewDatabase(logger, config)
+	dataBase.flush()
+	defer dataBase.flush()
+	metric1 := "my.test.super.metric"
+	aggregated := func(aggregate moira.MetricAggregate) map[string]*moira.MatchedMetric {
+		return map[string]*moira.MatchedMetric{metric1: {
+			Patterns:           []string{},
+			Metric:             metric1,
+			Retention:          10,
+			RetentionTimestamp: 10,
+			Timestamp:          aggregate.Timestamp,
+			Value:              aggregate.Last,
+			Aggregate:          &aggregate,
+		}}
+	}
+
+	Convey("Aggregates saved by different filters should be merged into single value of retention interval", t, func() {
+		err := dataBase.SaveMetrics(aggregated(moira.MetricAggregate{Method: "sum", Count: 1, Sum: 1, Min: 1, Max: 1, Timestamp: 12, Last: 1}))
+		So(err, ShouldBeNil)
+		err = dataBase.SaveMetrics(aggregated(moira.MetricAggregate{Method: "sum", Count: 2, Sum: 5, Min: 2, Max: 3, Timestamp: 14, Last: 3}))
+		So(err, ShouldBeNil)
+
+		actualValues, err := dataBase.GetMetricsValues([]string{metric1}, 1, 99)
+		So(err, ShouldBeNil)
+		So(actualValues, ShouldResemble, map[string][]*moira.MetricValue{
+			metric1: {&moira.MetricValue{Timestamp: 14, RetentionTimestamp: 10, Value: 6}},
+		})
+
+		Convey("Late values should not overwrite value of interval after its aggregate expired", func() {
+			c := dataBase.pool.Get()
+			_, err := c.Do("DEL", metricAggregateKey(metric1, 10, 10))
+			c.Close()
+			So(err, ShouldBeNil)
+
+			err = dataBase.SaveMetrics(aggregated(moira.MetricAggregate{Method: "sum", Count: 1, Sum: 1, Min: 1, Max: 1, Timestamp: 13, Last: 1}))
+			So(err, ShouldBeNil)
+			actualValues, err := dataBase.GetMetricsValues([]string{metric1}, 1, 99)
+			So(err, ShouldBeNil)
+			So(actualValues, ShouldResemble, map[string][]*moira.MetricValue{
+				metric1: {&moira.MetricValue{Timestamp: 14, RetentionTimestamp: 10, Value: 6}},
+			})
+		})
+	})
+
+	Convey("Merged aggregate should give value of its method", t, func() {
+		dataBase.flush()
+		err := dataBase.SaveMetrics(aggregated(moira.MetricAggregate{Method: "average", Count: 1, Sum: 1, Min: 1, Max: 1, Timestamp: 12, Last: 1}))
+		So(err, ShouldBeNil)
+		err = dataBase.SaveMetrics(aggregated(moira.MetricAggregate{Method: "average", Count: 1, Sum: 2, Min: 2, Max: 2, Timestamp: 11, Last: 2}))
+		So(err, ShouldBeNil)
+
+		actualValues, err := dataBase.GetMetricsValues([]string{metric1}, 1, 99)
+		So(err, ShouldBeNil)
+		So(actualValues, ShouldResemble, map[string][]*moira.MetricValue{
+			metric1: {&moira.MetricValue{Timestamp: 12, RetentionTimestamp: 10, Value: 1.5}},
+		})
+	})
+}
+
+func TestMetricArchives(t *testing.T) {
+	logger, _ := logging.GetLogger("dataBase")
+	dataBase := NewDatabase(logger, config)
+	dataBase.flush()
+	defer dataBase.flush()
+	metric1 := "my.test.super.metric"
+	metric2 := "my.test.super.metric2"
+	pattern := "my.test.*"
+	archives := []moira.MetricArchive{{Precision: 10, Duration: 100}, {Precision: 60, Duration: 600}}
+
+	Convey("Metric without archives should have single archive of its retention", t, func() {
+		actual, err := dataBase.GetMetricArchives(metric2)
+		So(err, ShouldBeNil)
+		So(actual, ShouldResemble, []moira.MetricArchive{{Precision: 60}})
+	})
+
+	Convey("Rollups should be merged into archive with its precision", t, func() {
+		for i, timestamp := range []int64{1195, 1805, 1815} {
+			err := dataBase.SaveMetrics(map[string]*moira.MatchedMetric{metric1: {
+				Patterns:           []string{pattern},
+				Metric:             metric1,
+				Retention:          10,
+				RetentionTimestamp: timestamp - timestamp%10,
+				Timestamp:          timestamp,
+				Value:              float64(i),
+				Archives:           archives,
+				Rollups: []*moira.MetricRollup{
+					{Archive: archives[1], RetentionTimestamp: timestamp - timestamp%60, Aggregate: moira.MetricAggregate{
+						Method: "average", Count: 1, Sum: float64(i * 10), Min: float64(i * 10), Max: float64(i * 10), Timestamp: timestamp, Last: float64(i * 10),
+					}},
+				},
+			}})
+			So(err, ShouldBeNil)
+		}
+
+		actualArchives, err := dataBase.GetMetricArchives(metric1)
+		So(err, ShouldBeNil)
+		So(actualArchives, ShouldResemble, archives)
+
+		actualValues, err := dataBase.GetMetricsArchiveValues([]string{metric1}, 60, 0, 2000)
+		So(err, ShouldBeNil)
+		So(actualValues, ShouldResemble, map[string][]*moira.MetricValue{
+			metric1: {&moira.MetricValue{Timestamp: 1815, RetentionTimestamp: 1800, Value: 15}},
+		})
+	})
+
+	Convey("Removed pattern should remove archives data", t, func() {
+		err := dataBase.AddPatternMetric(pattern, metric1)
+		So(err, ShouldBeNil)
+		err = dataBase.RemovePatternWithMetrics(pattern)
+		So(err, ShouldBeNil)
+		actualValues, err := dataBase.GetMetricsArchiveValues([]string{metric1}, 60, 0, 2000)
+		So(err, ShouldBeNil)
+		So(actualValues, ShouldResemble, map[string][]*moira.MetricValue{metric1: {}})
+	})
+}
+
 func TestRemoveMetricValues(t *testing.T) {
 	logger, _ := logging.GetLogger("dataBase")
 	dataBase := NewDatabase(logger, config)
@@ -400,6 +519,107 @@ func TestMetricSubscription(t *testing.T) {
 	})
 }
 
+func TestPatternSubscription(t *testing.T) {
+	logger, _ := logging.GetLogger("dataBase")
+	dataBase := NewDatabase(logger, config)
+	dataBase.flush()
+	defer dataBase.flush()
+	pattern := "my.test.*.metric*"
+	trigger := &moira.Trigger{ID: "pattern-subscription-trigger", Patterns: []string{pattern}}
+	Convey("Saving and removing triggers should publish pattern events", t, func() {
+		var tomb1 tomb.Tomb
+		ch, err := dataBase.SubscribePatternEvents(&tomb1)
+		So(err, ShouldBeNil)
+		So(ch, ShouldNotBeNil)
+
+		err = dataBase.SaveTrigger(trigger.ID, trigger)
+		So(err, ShouldBeNil)
+		So(<-ch, ShouldResemble, &moira.PatternEvent{Pattern: pattern})
+
+		err = dataBase.RemoveTrigger(trigger.ID)
+		So(err, ShouldBeNil)
+		So(<-ch, ShouldResemble, &moira.PatternEvent{Pattern: pattern, Removed: true})
+
+		tomb1.Kill(nil)
+		_, ok := <-ch
+		So(ok, ShouldBeFalse)
+	})
+}
+
+func TestTaggedMetricsStoring(t *testing.T) {
+	logger, _ := logging.GetLogger("dataBase")
+	dataBase := NewDatabase(logger, config)
+	dataBase.flush()
+	defer dataBase.flush()
+	metric1 := "cpu.load;dc=eu;host=web1"
+	metric2 := "cpu.load;dc=us;host=web2"
+	metric3 := "cpu.idle;dc=eu;host=web1"
+	Convey("Saved tagged metrics should be indexed by tags", t, func() {
+		for _, metric := range []string{metric1, metric2, metric3} {
+			err := dataBase.SaveMetrics(map[string]*moira.MatchedMetric{metric: {
+				Patterns:           []string{},
+				Metric:             metric,
+				Retention:          60,
+				RetentionTimestamp: 60,
+				Timestamp:          61,
+				Value:              1,
+			}})
+			So(err, ShouldBeNil)
+		}
+
+		cases := []struct {
+			pattern  string
+			expected []string
+		}{
+			{"seriesByTag('name=cpu.load','dc=eu')", []string{metric1}},
+			{"seriesByTag('name=cpu.load','dc!=eu')", []string{metric2}},
+			{"seriesByTag('name=~cpu','host=web1')", []string{metric3, metric1}},
+			{"seriesByTag('name=~cpu\\.(load|idle)','host=~web')", []string{metric3, metric1, metric2}},
+			{"seriesByTag('name=cpu.load','rack=r1')", []string{}},
+			{"seriesByTag('name=~mem')", []string{}},
+		}
+		for _, testCase := range cases {
+			tagSpecs, err := moira.ParseSeriesByTag(testCase.pattern)
+			So(err, ShouldBeNil)
+			actual, err := dataBase.GetTaggedMetrics(tagSpecs)
+			So(err, ShouldBeNil)
+			sort.Strings(actual)
+			So(actual, ShouldResemble, testCase.expected)
+		}
+	})
+
+	Convey("Removed tagged metrics should be removed from tags index", t, func() {
+		err := dataBase.RemoveMetricValues(metric2, 120)
+		So(err, ShouldBeNil)
+		actual, err := dataBase.GetTaggedMetrics([]moira.TagSpec{{Name: "dc", Operator: moira.MatchOperator, Value: "u"}})
+		So(err, ShouldBeNil)
+		So(actual, ShouldResemble, []string{})
+
+		err = dataBase.AddPatternMetric("seriesByTag('name=cpu.load')", metric1)
+		So(err, ShouldBeNil)
+		err = dataBase.RemovePatternWithMetrics("seriesByTag('name=cpu.load')")
+		So(err, ShouldBeNil)
+		actual, err = dataBase.GetTaggedMetrics([]moira.TagSpec{{Name: "host", Operator: moira.EqualOperator, Value: "web1"}})
+		So(err, ShouldBeNil)
+		So(actual, ShouldResemble, []string{metric3})
+	})
+
+	Convey("Resumed tagged metrics should be indexed by tags again", t, func() {
+		err := dataBase.SaveMetrics(map[string]*moira.MatchedMetric{metric2: {
+			Patterns:           []string{},
+			Metric:             metric2,
+			Retention:          60,
+			RetentionTimestamp: 180,
+			Timestamp:          181,
+			Value:              1,
+		}})
+		So(err, ShouldBeNil)
+		actual, err := dataBase.GetTaggedMetrics([]moira.TagSpec{{Name: "dc", Operator: moira.MatchOperator, Value: "u"}})
+		So(err, ShouldBeNil)
+		So(actual, ShouldResemble, []string{metric2})
+	})
+}
+
 func TestMetricsStoringErrorConnection(t *testing.T) {
 	logger, _ := logging.GetLogger("dataBase")
 	dataBase := NewDatabase(logger, emptyConfig)
@@ -440,9 +660,17 @@ func TestMetricsStoringErrorConnection(t *testing.T) {
 		err = dataBase.RemoveMetricValues("123", 1)
 		So(err, ShouldNotBeNil)
 
+		actual, err = dataBase.GetTaggedMetrics([]moira.TagSpec{{Name: "name", Operator: moira.EqualOperator, Value: "123"}})
+		So(actual, ShouldBeEmpty)
+		So(err, ShouldNotBeNil)
+
 		var tomb1 tomb.Tomb
 		ch, err := dataBase.SubscribeMetricEvents(&tomb1)
 		So(err, ShouldNotBeNil)
 		So(ch, ShouldBeNil)
+
+		patternsChannel, err := dataBase.SubscribePatternEvents(&tomb1)
+		So(err, ShouldNotBeNil)
+		So(patternsChannel, ShouldBeNil)
 	})
 }
@@ -12,12 +12,13 @@ import (
 	"github.com/moira-alert/moira/database/redis/reply"
 )
 
-// GetNotifications gets ScheduledNotifications in given range and full range
+// GetNotifications gets ScheduledNotifications in given range and full range,
+// notifications leased by notifier go first followed by scheduled ones
 func (connector *DbConnector) GetNotifications(start, end int64) ([]*moira.ScheduledNotification, int64, error) {
 	c := connector.pool.Get()
 	defer c.Close()
 	c.Send("MULTI")
-	c.Send("ZRANGE", notifierNotificationsKey, start, end)
+	c.Send("ZRANGE", notifierInFlightNotificationsKey, 0, -1)
 	c.Send("ZCARD", notifierNotificationsKey)
 	rawResponse, err := redis.Values(c.Do("EXEC"))
 	if err != nil {
@@ -26,14 +27,52 @@ func (connector *DbConnector) GetNotifications(start, end int64) ([]*moira.Sched
 	if len(rawResponse) == 0 {
 		return make([]*moira.ScheduledNotification, 0), 0, nil
 	}
-	total, err := redis.Int64(rawResponse[1], nil)
+	inFlight, err := reply.Notifications(rawResponse[0], nil)
 	if err != nil {
 		return nil, 0, err
 	}
-	notifications, err := reply.Notifications(rawResponse[0], nil)
+	scheduledTotal, err := redis.Int64(rawResponse[1], nil)
 	if err != nil {
 		return nil, 0, err
 	}
+	inFlightTotal := int64(len(inFlight))
+	total := inFlightTotal + scheduledTotal
+
+	// range is given in redis ZRANGE manner, negative indexes are counted from the end
+	if start < 0 {
+		start += total
+	}
+	if start < 0 {
+		start = 0
+	}
+	if end < 0 {
+		end += total
+	}
+	if end >= total {
+		end = total - 1
+	}
+	notifications := make([]*moira.ScheduledNotification, 0)
+	if start > end {
+		return notifications, total, nil
+	}
+	if start < inFlightTotal {
+		inFlightEnd := end + 1
+		if inFlightEnd > inFlightTotal {
+			inFlightEnd = inFlightTotal
+		}
+		notifications = append(notifications, inFlight[start:inFlightEnd]...)
+	}
+	if end >= inFlightTotal {
+		scheduledStart := start - inFlightTotal
+		if scheduledStart < 0 {
+			scheduledStart = 0
+		}
+		scheduled, err := reply.Notifications(c.Do("ZRANGE", notifierNotificationsKey, scheduledStart, end-inFlightTotal))
+		if err != nil {
+			return nil, 0, err
+		}
+		notifications = append(notifications, scheduled...)
+	}
 	return notifications, total, nil
 }
 
@@ -42,7 +81,19 @@ func (connector *DbConnector) RemoveAllNotifications() error {
 	c := connector.pool.Get()
 	defer c.Close()
 
-	if _, err := c.Do("DEL", notifierNotificationsKey); err != nil {
+	notifications, _, err := connector.GetNotifications(0, -1)
+	if err != nil {
+		return err
+	}
+
+	c.Send("MULTI")
+	c.Send("DEL", notifierNotificationsKey, notifierInFlightNotificationsKey)
+	for _, notification := range notifications {
+		if notification.EscalationLevel > 0 {
+			c.Send("DEL", notifierTriggerEscalationsKey(notification.Event.TriggerID))
+		}
+	}
+	if _, err := c.Do("EXEC"); err != nil {
 		return fmt.Errorf("failed to remove %s: %s", notifierNotificationsKey, err.Error())
 	}
 
@@ -67,40 +118,107 @@ func (connector *DbConnector) RemoveNotification(notificationKey string) (int64,
 		subID := moira.UseString(notification.Event.SubscriptionID)
 		idstr := strings.Join([]string{timestamp, contactID, subID}, "")
 		if idstr == notificationKey {
-			notificationString, err2 := json.Marshal(notification)
+			notificationString, err2 := notificationMember(notification)
 			if err2 != nil {
 				return 0, err2
 			}
 			c.Send("ZREM", notifierNotificationsKey, notificationString)
+			c.Send("ZREM", notifierInFlightNotificationsKey, notificationString)
+			c.Send("SREM", notifierTriggerEscalationsKey(notification.Event.TriggerID), notificationString)
 		}
 	}
 	response, err := redis.Ints(c.Do("EXEC"))
 	if err != nil {
 		return 0, fmt.Errorf("Failed to remove notifier-notification: %s", err.Error())
 	}
+	return countRemovedNotifications(response), nil
+}
+
+// RemoveEscalationNotifications delete not yet sent escalation notifications about given trigger metric,
+// escalations about all metrics of trigger are deleted if metric is empty
+func (connector *DbConnector) RemoveEscalationNotifications(triggerID, metric string) (int64, error) {
+	c := connector.pool.Get()
+	defer c.Close()
+
+	escalations, err := reply.Notifications(c.Do("SMEMBERS", notifierTriggerEscalationsKey(triggerID)))
+	if err != nil {
+		return 0, err
+	}
+
+	c.Send("MULTI")
+	for _, escalation := range escalations {
+		if metric != "" && escalation.Event.Metric != metric {
+			continue
+		}
+		c.Send("ZREM", notifierNotificationsKey, escalation.Raw)
+		c.Send("ZREM", notifierInFlightNotificationsKey, escalation.Raw)
+		c.Send("SREM", notifierTriggerEscalationsKey(triggerID), escalation.Raw)
+	}
+	response, err := redis.Ints(c.Do("EXEC"))
+	if err != nil {
+		return 0, fmt.Errorf("Failed to remove escalation notifications: %s", err.Error())
+	}
+	return countRemovedNotifications(response), nil
+}
+
+// countRemovedNotifications sums ZREM replies from scheduled and in-flight notifications sets,
+// which are followed by SREM reply from trigger escalations set for every removed notification
+func countRemovedNotifications(response []int) int64 {
 	total := 0
-	for _, val := range response {
-		total += val
+	for i := 0; i+1 < len(response); i += 3 {
+		total += response[i] + response[i+1]
 	}
-	return int64(total), nil
+	return int64(total)
 }
 
-// FetchNotifications fetch notifications by given timestamp and delete it
-func (connector *DbConnector) FetchNotifications(to int64) ([]*moira.ScheduledNotification, error) {
+// fetchNotificationsScript moves notifications scheduled up to ARGV[1] and in-flight notifications
+// with lease expired by ARGV[1] to in-flight set with new lease deadline ARGV[2]
+var fetchNotificationsScript = redis.NewScript(2, `
+local notifications = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+local expired = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[1])
+for _, notification in ipairs(expired) do
+	table.insert(notifications, notification)
+end
+for _, notification in ipairs(notifications) do
+	redis.call('ZREM', KEYS[1], notification)
+	redis.call('ZADD', KEYS[2], ARGV[2], notification)
+end
+return notifications
+`)
+
+// FetchNotifications fetch notifications by given timestamp and lease them until given deadline.
+// Leased notifications, which are not acknowledged by AckNotifications until deadline, are fetched again
+func (connector *DbConnector) FetchNotifications(to, leaseDeadline int64) ([]*moira.ScheduledNotification, error) {
 	c := connector.pool.Get()
 	defer c.Close()
 
-	c.Send("MULTI")
-	c.Send("ZRANGEBYSCORE", notifierNotificationsKey, "-inf", to)
-	c.Send("ZREMRANGEBYSCORE", notifierNotificationsKey, "-inf", to)
-	response, err := redis.Values(c.Do("EXEC"))
+	response, err := fetchNotificationsScript.Do(c, notifierNotificationsKey, notifierInFlightNotificationsKey, to, leaseDeadline)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to EXEC: %s", err)
+		return nil, fmt.Errorf("Failed to fetch notifications: %s", err.Error())
 	}
-	if len(response) == 0 {
-		return make([]*moira.ScheduledNotification, 0), nil
+	return reply.Notifications(response, nil)
+}
+
+// AckNotifications removes leased notifications after they are sent or rescheduled
+func (connector *DbConnector) AckNotifications(notifications []*moira.ScheduledNotification) error {
+	c := connector.pool.Get()
+	defer c.Close()
+
+	c.Send("MULTI")
+	for _, notification := range notifications {
+		bytes, err := notificationMember(notification)
+		if err != nil {
+			return err
+		}
+		c.Send("ZREM", notifierInFlightNotificationsKey, bytes)
+		if notification.EscalationLevel > 0 {
+			c.Send("SREM", notifierTriggerEscalationsKey(notification.Event.TriggerID), bytes)
+		}
+	}
+	if _, err := c.Do("EXEC"); err != nil {
+		return fmt.Errorf("Failed to EXEC: %s", err.Error())
 	}
-	return reply.Notifications(response[0], nil)
+	return nil
 }
 
 // AddNotification store notification at given timestamp
@@ -111,24 +229,37 @@ func (connector *DbConnector) AddNotification(notification *moira.ScheduledNotif
 	}
 	c := connector.pool.Get()
 	defer c.Close()
-	_, err = c.Do("ZADD", notifierNotificationsKey, notification.Timestamp, bytes)
+	c.Send("MULTI")
+	c.Send("ZADD", notifierNotificationsKey, notification.Timestamp, bytes)
+	if notification.EscalationLevel > 0 {
+		c.Send("SADD", notifierTriggerEscalationsKey(notification.Event.TriggerID), bytes)
+	}
+	_, err = c.Do("EXEC")
 	if err != nil {
 		return fmt.Errorf("Failed to add scheduled notification: %s, error: %s", string(bytes), err.Error())
 	}
 	return err
 }
 
-// AddNotifications store notification at given timestamp
+// AddNotifications store notification at given timestamp,
+// notifications read from database are only rescheduled if they are not leased by notifier yet
 func (connector *DbConnector) AddNotifications(notifications []*moira.ScheduledNotification, timestamp int64) error {
 	c := connector.pool.Get()
 	defer c.Close()
 	c.Send("MULTI")
 	for _, notification := range notifications {
+		if notification.Raw != nil {
+			c.Send("ZADD", notifierNotificationsKey, "XX", timestamp, notification.Raw)
+			continue
+		}
 		bytes, err := json.Marshal(notification)
 		if err != nil {
 			return err
 		}
 		c.Send("ZADD", notifierNotificationsKey, timestamp, bytes)
+		if notification.EscalationLevel > 0 {
+			c.Send("SADD", notifierTriggerEscalationsKey(notification.Event.TriggerID), bytes)
+		}
 	}
 	_, err := c.Do("EXEC")
 	if err != nil {
@@ -137,4 +268,20 @@ func (connector *DbConnector) AddNotifications(notifications []*moira.ScheduledN
 	return nil
 }
 
+// notificationMember returns notification as it is stored in notifications sets,
+// notification read from database is returned as is, because its marshaling may differ from stored one
+func notificationMember(notification *moira.ScheduledNotification) ([]byte, error) {
+	if notification.Raw != nil {
+		return notification.Raw, nil
+	}
+	return json.Marshal(notification)
+}
+
 var notifierNotificationsKey = "moira-notifier-notifications"
+var notifierInFlightNotificationsKey = "moira-notifier-notifications-inflight"
+
+// notifierTriggerEscalationsKey is set of pending escalation notifications of trigger
+// stored in notifications sets, it allows to remove them without scanning all notifications
+func notifierTriggerEscalationsKey(triggerID string) string {
+	return fmt.Sprintf("moira-notifier-trigger-escalations:%s", triggerID)
+}
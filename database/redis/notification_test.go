@@ -1,11 +1,13 @@
 package redis
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/garyburd/redigo/redis"
 	"github.com/op/go-logging"
 	. "github.com/smartystreets/goconvey/convey"
 
@@ -38,32 +40,45 @@ func TestScheduledNotification(t *testing.T) {
 			actual, total, err := dataBase.GetNotifications(0, -1)
 			So(err, ShouldBeNil)
 			So(total, ShouldEqual, 3)
-			So(actual, ShouldResemble, []*moira.ScheduledNotification{&notificationOld, &notification, &notificationNew})
+			So(actual, ShouldResemble, stored(notificationOld, notification, notificationNew))
 
 			actual, total, err = dataBase.GetNotifications(0, 0)
 			So(err, ShouldBeNil)
 			So(total, ShouldEqual, 3)
-			So(actual, ShouldResemble, []*moira.ScheduledNotification{&notificationOld})
+			So(actual, ShouldResemble, stored(notificationOld))
 
 			actual, total, err = dataBase.GetNotifications(1, 2)
 			So(err, ShouldBeNil)
 			So(total, ShouldEqual, 3)
-			So(actual, ShouldResemble, []*moira.ScheduledNotification{&notification, &notificationNew})
+			So(actual, ShouldResemble, stored(notification, notificationNew))
 		})
 
 		Convey("Test fetch notifications", func() {
-			actual, err := dataBase.FetchNotifications(now - 3600)
+			leased, err := dataBase.FetchNotifications(now-3600, now+7200)
 			So(err, ShouldBeNil)
-			So(actual, ShouldResemble, []*moira.ScheduledNotification{&notificationOld})
+			So(leased, ShouldResemble, stored(notificationOld))
 
 			actual, total, err := dataBase.GetNotifications(0, -1)
 			So(err, ShouldBeNil)
-			So(total, ShouldEqual, 2)
-			So(actual, ShouldResemble, []*moira.ScheduledNotification{&notification, &notificationNew})
+			So(total, ShouldEqual, 3)
+			So(actual, ShouldResemble, stored(notificationOld, notification, notificationNew))
+
+			actual, total, err = dataBase.GetNotifications(0, 1)
+			So(err, ShouldBeNil)
+			So(total, ShouldEqual, 3)
+			So(actual, ShouldResemble, stored(notificationOld, notification))
 
-			actual, err = dataBase.FetchNotifications(now + 3600)
+			actual, total, err = dataBase.GetNotifications(1, -1)
+			So(err, ShouldBeNil)
+			So(total, ShouldEqual, 3)
+			So(actual, ShouldResemble, stored(notification, notificationNew))
+
+			actual, err = dataBase.FetchNotifications(now+3600, now+7200)
+			So(err, ShouldBeNil)
+			So(actual, ShouldResemble, stored(notification, notificationNew))
+
+			err = dataBase.AckNotifications(append(leased, actual...))
 			So(err, ShouldBeNil)
-			So(actual, ShouldResemble, []*moira.ScheduledNotification{&notification, &notificationNew})
 
 			actual, total, err = dataBase.GetNotifications(0, -1)
 			So(err, ShouldBeNil)
@@ -71,6 +86,52 @@ func TestScheduledNotification(t *testing.T) {
 			So(actual, ShouldResemble, make([]*moira.ScheduledNotification, 0))
 		})
 
+		Convey("Test fetched notifications are leased until acknowledgement", func() {
+			addNotifications(dataBase, []moira.ScheduledNotification{notification, notificationOld})
+			actual, err := dataBase.FetchNotifications(now, now+60)
+			So(err, ShouldBeNil)
+			So(actual, ShouldResemble, stored(notificationOld, notification))
+
+			actual, err = dataBase.FetchNotifications(now+59, now+120)
+			So(err, ShouldBeNil)
+			So(actual, ShouldResemble, []*moira.ScheduledNotification{})
+
+			err = dataBase.AckNotifications([]*moira.ScheduledNotification{&notificationOld})
+			So(err, ShouldBeNil)
+
+			actual, err = dataBase.FetchNotifications(now+60, now+120)
+			So(err, ShouldBeNil)
+			So(actual, ShouldResemble, stored(notification))
+
+			err = dataBase.AckNotifications(actual)
+			So(err, ShouldBeNil)
+
+			actual, err = dataBase.FetchNotifications(now+120, now+180)
+			So(err, ShouldBeNil)
+			So(actual, ShouldResemble, []*moira.ScheduledNotification{})
+		})
+
+		Convey("Test acknowledge notification stored with different marshaling", func() {
+			c := dataBase.pool.Get()
+			member := fmt.Sprintf(`{"timestamp":%d,"send_fail":4,"unknown_field":true}`, now)
+			_, err := c.Do("ZADD", notifierNotificationsKey, now, member)
+			c.Close()
+			So(err, ShouldBeNil)
+
+			actual, err := dataBase.FetchNotifications(now, now+60)
+			So(err, ShouldBeNil)
+			So(actual, ShouldHaveLength, 1)
+			So(actual[0].SendFail, ShouldEqual, 4)
+			So(string(actual[0].Raw), ShouldEqual, member)
+
+			err = dataBase.AckNotifications(actual)
+			So(err, ShouldBeNil)
+
+			actual, err = dataBase.FetchNotifications(now+60, now+120)
+			So(err, ShouldBeNil)
+			So(actual, ShouldResemble, []*moira.ScheduledNotification{})
+		})
+
 		Convey("Test remove notifications by key", func() {
 			now := time.Now().Unix()
 			id1 := "id1"
@@ -96,7 +157,11 @@ func TestScheduledNotification(t *testing.T) {
 			actual, total, err := dataBase.GetNotifications(0, -1)
 			So(err, ShouldBeNil)
 			So(total, ShouldEqual, 3)
-			So(actual, ShouldResemble, []*moira.ScheduledNotification{&notification1, &notification2, &notification3})
+			So(actual, ShouldResemble, stored(notification1, notification2, notification3))
+
+			leased, err := dataBase.FetchNotifications(now, now+60)
+			So(err, ShouldBeNil)
+			So(leased, ShouldResemble, stored(notification1, notification2))
 
 			total, err = dataBase.RemoveNotification(strings.Join([]string{fmt.Sprintf("%v", now), id1, id1}, ""))
 			So(err, ShouldBeNil)
@@ -105,7 +170,7 @@ func TestScheduledNotification(t *testing.T) {
 			actual, total, err = dataBase.GetNotifications(0, -1)
 			So(err, ShouldBeNil)
 			So(total, ShouldEqual, 1)
-			So(actual, ShouldResemble, []*moira.ScheduledNotification{&notification3})
+			So(actual, ShouldResemble, stored(notification3))
 
 			total, err = dataBase.RemoveNotification(strings.Join([]string{fmt.Sprintf("%v", now+3600), id1, id1}, ""))
 			So(err, ShouldBeNil)
@@ -116,11 +181,85 @@ func TestScheduledNotification(t *testing.T) {
 			So(total, ShouldEqual, 0)
 			So(actual, ShouldResemble, []*moira.ScheduledNotification{})
 
-			actual, err = dataBase.FetchNotifications(now + 3600)
+			actual, err = dataBase.FetchNotifications(now+3600, now+7200)
 			So(err, ShouldBeNil)
 			So(actual, ShouldResemble, []*moira.ScheduledNotification{})
 		})
 
+		Convey("Test remove escalation notifications", func() {
+			now := time.Now().Unix()
+			event := moira.NotificationEvent{TriggerID: "trigger1", Metric: "metric1"}
+			notification := moira.ScheduledNotification{
+				Event:     event,
+				Timestamp: now,
+			}
+			escalation := moira.ScheduledNotification{
+				Event:           event,
+				Timestamp:       now + 600,
+				EscalationLevel: 1,
+			}
+			otherMetricEscalation := moira.ScheduledNotification{
+				Event:           moira.NotificationEvent{TriggerID: "trigger1", Metric: "metric2"},
+				Timestamp:       now + 1200,
+				EscalationLevel: 1,
+			}
+			addNotifications(dataBase, []moira.ScheduledNotification{notification, escalation, otherMetricEscalation})
+			leased, err := dataBase.FetchNotifications(now+600, now+3600)
+			So(err, ShouldBeNil)
+			So(leased, ShouldResemble, stored(notification, escalation))
+
+			total, err := dataBase.RemoveEscalationNotifications("trigger1", "metric1")
+			So(err, ShouldBeNil)
+			So(total, ShouldEqual, 1)
+
+			actual, total, err := dataBase.GetNotifications(0, -1)
+			So(err, ShouldBeNil)
+			So(total, ShouldEqual, 2)
+			So(actual, ShouldResemble, stored(notification, otherMetricEscalation))
+
+			total, err = dataBase.RemoveEscalationNotifications("trigger1", "")
+			So(err, ShouldBeNil)
+			So(total, ShouldEqual, 1)
+
+			actual, total, err = dataBase.GetNotifications(0, -1)
+			So(err, ShouldBeNil)
+			So(total, ShouldEqual, 1)
+			So(actual, ShouldResemble, stored(notification))
+
+			err = dataBase.RemoveAllNotifications()
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Test sent escalation notifications are not pending", func() {
+			now := time.Now().Unix()
+			escalation := moira.ScheduledNotification{
+				Event:           moira.NotificationEvent{TriggerID: "trigger2", Metric: "metric1"},
+				Timestamp:       now,
+				EscalationLevel: 1,
+			}
+			addNotifications(dataBase, []moira.ScheduledNotification{escalation})
+
+			c := dataBase.pool.Get()
+			defer c.Close()
+			pending, err := redis.Int(c.Do("SCARD", notifierTriggerEscalationsKey("trigger2")))
+			So(err, ShouldBeNil)
+			So(pending, ShouldEqual, 1)
+
+			leased, err := dataBase.FetchNotifications(now, now+60)
+			So(err, ShouldBeNil)
+			So(leased, ShouldResemble, stored(escalation))
+			err = dataBase.AckNotifications(leased)
+			So(err, ShouldBeNil)
+
+			pending, err = redis.Int(c.Do("SCARD", notifierTriggerEscalationsKey("trigger2")))
+			So(err, ShouldBeNil)
+			So(pending, ShouldEqual, 0)
+
+			total, err := dataBase.RemoveEscalationNotifications("trigger2", "")
+			So(err, ShouldBeNil)
+			So(total, ShouldEqual, 0)
+		})
+
 		Convey("Test remove all notifications", func() {
 			now := time.Now().Unix()
 			id1 := "id1"
@@ -146,7 +285,7 @@ func TestScheduledNotification(t *testing.T) {
 			actual, total, err := dataBase.GetNotifications(0, -1)
 			So(err, ShouldBeNil)
 			So(total, ShouldEqual, 3)
-			So(actual, ShouldResemble, []*moira.ScheduledNotification{&notification1, &notification2, &notification3})
+			So(actual, ShouldResemble, stored(notification1, notification2, notification3))
 
 			err = dataBase.RemoveAllNotifications()
 			So(err, ShouldBeNil)
@@ -156,13 +295,24 @@ func TestScheduledNotification(t *testing.T) {
 			So(total, ShouldEqual, 0)
 			So(actual, ShouldResemble, []*moira.ScheduledNotification{})
 
-			actual, err = dataBase.FetchNotifications(now + 3600)
+			actual, err = dataBase.FetchNotifications(now+3600, now+7200)
 			So(err, ShouldBeNil)
 			So(actual, ShouldResemble, []*moira.ScheduledNotification{})
 		})
 	})
 }
 
+// stored returns given notifications as they are read from database
+func stored(notifications ...moira.ScheduledNotification) []*moira.ScheduledNotification {
+	result := make([]*moira.ScheduledNotification, 0, len(notifications))
+	for _, notification := range notifications {
+		storedNotification := notification
+		storedNotification.Raw, _ = json.Marshal(notification)
+		result = append(result, &storedNotification)
+	}
+	return result
+}
+
 func addNotifications(dataBase moira.Database, notifications []moira.ScheduledNotification) {
 	for _, notification := range notifications {
 		err := dataBase.AddNotification(&notification)
@@ -186,11 +336,18 @@ func TestScheduledNotificationErrorConnection(t *testing.T) {
 		So(err, ShouldNotBeNil)
 		So(total, ShouldEqual, 0)
 
-		actual2, err := dataBase.FetchNotifications(0)
+		total, err = dataBase.RemoveEscalationNotifications("123", "metric")
+		So(err, ShouldNotBeNil)
+		So(total, ShouldEqual, 0)
+
+		actual2, err := dataBase.FetchNotifications(0, 60)
 		So(err, ShouldNotBeNil)
 		So(actual2, ShouldBeNil)
 
 		notification := moira.ScheduledNotification{}
+		err = dataBase.AckNotifications([]*moira.ScheduledNotification{&notification})
+		So(err, ShouldNotBeNil)
+
 		err = dataBase.AddNotification(&notification)
 		So(err, ShouldNotBeNil)
 
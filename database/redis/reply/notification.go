@@ -22,6 +22,7 @@ func Notification(rep interface{}, err error) (moira.ScheduledNotification, erro
 	if err != nil {
 		return notification, fmt.Errorf("Failed to parse notification json %s: %s", string(bytes), err.Error())
 	}
+	notification.Raw = bytes
 	return notification, nil
 }
 
@@ -11,54 +11,87 @@ import (
 
 // Duty hack for moira.Trigger TTL int64 and stored trigger TTL string compatibility
 type triggerStorageElement struct {
-	ID               string              `json:"id"`
-	Name             string              `json:"name"`
-	Desc             *string             `json:"desc,omitempty"`
-	Targets          []string            `json:"targets"`
-	WarnValue        *float64            `json:"warn_value"`
-	ErrorValue       *float64            `json:"error_value"`
-	Tags             []string            `json:"tags"`
-	TTLState         *string             `json:"ttl_state,omitempty"`
-	Schedule         *moira.ScheduleData `json:"sched,omitempty"`
-	Expression       *string             `json:"expr,omitempty"`
-	PythonExpression *string             `json:"expression,omitempty"`
-	Patterns         []string            `json:"patterns"`
-	TTL              string              `json:"ttl,omitempty"`
+	ID                 string                     `json:"id"`
+	Name               string                     `json:"name"`
+	Desc               *string                    `json:"desc,omitempty"`
+	Targets            []string                   `json:"targets"`
+	WarnValue          *float64                   `json:"warn_value"`
+	ErrorValue         *float64                   `json:"error_value"`
+	Tags               []string                   `json:"tags"`
+	TTLState           *string                    `json:"ttl_state,omitempty"`
+	Schedule           *moira.ScheduleData        `json:"sched,omitempty"`
+	Expression         *string                    `json:"expr,omitempty"`
+	PythonExpression   *string                    `json:"expression,omitempty"`
+	Patterns           []string                   `json:"patterns"`
+	TTL                string                     `json:"ttl,omitempty"`
+	TriggerSource      moira.TriggerSource        `json:"trigger_source,omitempty"`
+	WarnRecoveryValue  *float64                   `json:"warn_recovery_value,omitempty"`
+	ErrorRecoveryValue *float64                   `json:"error_recovery_value,omitempty"`
+	ConfirmPoints      int64                      `json:"confirm_points,omitempty"`
+	ConfirmDuration    int64                      `json:"confirm_duration,omitempty"`
+	Anomaly            *moira.AnomalyDetection    `json:"anomaly,omitempty"`
+	StateAggregation   *moira.StateAggregation    `json:"state_aggregation,omitempty"`
+	ReminderIntervals  map[string]int64           `json:"reminder_intervals,omitempty"`
+	CheckInterval      int64                      `json:"check_interval,omitempty"`
+	CheckSchedule      string                     `json:"check_schedule,omitempty"`
+	Dependencies       *moira.TriggerDependencies `json:"dependencies,omitempty"`
 }
 
 func (storageElement *triggerStorageElement) toTrigger() moira.Trigger {
 	return moira.Trigger{
-		ID:               storageElement.ID,
-		Name:             storageElement.Name,
-		Desc:             storageElement.Desc,
-		Targets:          storageElement.Targets,
-		WarnValue:        storageElement.WarnValue,
-		ErrorValue:       storageElement.ErrorValue,
-		Tags:             storageElement.Tags,
-		TTLState:         storageElement.TTLState,
-		Schedule:         storageElement.Schedule,
-		Expression:       storageElement.Expression,
-		PythonExpression: storageElement.PythonExpression,
-		Patterns:         storageElement.Patterns,
-		TTL:              getTriggerTTL(storageElement.TTL),
+		ID:                 storageElement.ID,
+		Name:               storageElement.Name,
+		Desc:               storageElement.Desc,
+		Targets:            storageElement.Targets,
+		WarnValue:          storageElement.WarnValue,
+		ErrorValue:         storageElement.ErrorValue,
+		Tags:               storageElement.Tags,
+		TTLState:           storageElement.TTLState,
+		Schedule:           storageElement.Schedule,
+		Expression:         storageElement.Expression,
+		PythonExpression:   storageElement.PythonExpression,
+		Patterns:           storageElement.Patterns,
+		TTL:                getTriggerTTL(storageElement.TTL),
+		TriggerSource:      storageElement.TriggerSource,
+		WarnRecoveryValue:  storageElement.WarnRecoveryValue,
+		ErrorRecoveryValue: storageElement.ErrorRecoveryValue,
+		ConfirmPoints:      storageElement.ConfirmPoints,
+		ConfirmDuration:    storageElement.ConfirmDuration,
+		Anomaly:            storageElement.Anomaly,
+		StateAggregation:   storageElement.StateAggregation,
+		ReminderIntervals:  storageElement.ReminderIntervals,
+		CheckInterval:      storageElement.CheckInterval,
+		CheckSchedule:      storageElement.CheckSchedule,
+		Dependencies:       storageElement.Dependencies,
 	}
 }
 
 func toTriggerStorageElement(trigger *moira.Trigger, triggerID string) *triggerStorageElement {
 	return &triggerStorageElement{
-		ID:               triggerID,
-		Name:             trigger.Name,
-		Desc:             trigger.Desc,
-		Targets:          trigger.Targets,
-		WarnValue:        trigger.WarnValue,
-		ErrorValue:       trigger.ErrorValue,
-		Tags:             trigger.Tags,
-		TTLState:         trigger.TTLState,
-		Schedule:         trigger.Schedule,
-		Expression:       trigger.Expression,
-		PythonExpression: trigger.PythonExpression,
-		Patterns:         trigger.Patterns,
-		TTL:              getTriggerTTLString(trigger.TTL),
+		ID:                 triggerID,
+		Name:               trigger.Name,
+		Desc:               trigger.Desc,
+		Targets:            trigger.Targets,
+		WarnValue:          trigger.WarnValue,
+		ErrorValue:         trigger.ErrorValue,
+		Tags:               trigger.Tags,
+		TTLState:           trigger.TTLState,
+		Schedule:           trigger.Schedule,
+		Expression:         trigger.Expression,
+		PythonExpression:   trigger.PythonExpression,
+		Patterns:           trigger.Patterns,
+		TTL:                getTriggerTTLString(trigger.TTL),
+		TriggerSource:      trigger.TriggerSource,
+		WarnRecoveryValue:  trigger.WarnRecoveryValue,
+		ErrorRecoveryValue: trigger.ErrorRecoveryValue,
+		ConfirmPoints:      trigger.ConfirmPoints,
+		ConfirmDuration:    trigger.ConfirmDuration,
+		Anomaly:            trigger.Anomaly,
+		StateAggregation:   trigger.StateAggregation,
+		ReminderIntervals:  trigger.ReminderIntervals,
+		CheckInterval:      trigger.CheckInterval,
+		CheckSchedule:      trigger.CheckSchedule,
+		Dependencies:       trigger.Dependencies,
 	}
 }
 
@@ -18,12 +18,67 @@ func (connector *DbConnector) GetTriggerThrottling(triggerID string) (time.Time,
 	return time.Unix(next, 0), time.Unix(beginning, 0)
 }
 
-// SetTriggerThrottling store throttling or scheduled notifications delay for given triggerID
-func (connector *DbConnector) SetTriggerThrottling(triggerID string, next time.Time) error {
+// SetTriggerThrottling store throttling or scheduled notifications delay and its reason for given triggerID
+func (connector *DbConnector) SetTriggerThrottling(triggerID string, next time.Time, reason string) error {
 	c := connector.pool.Get()
 	defer c.Close()
-	_, err := c.Do("SET", notifierNextKey(triggerID), next.Unix())
-	return err
+
+	c.Send("MULTI")
+	c.Send("SET", notifierNextKey(triggerID), next.Unix())
+	c.Send("SET", notifierThrottlingReasonKey(triggerID), reason)
+	_, err := c.Do("EXEC")
+	if err != nil {
+		return fmt.Errorf("Failed to EXEC: %s", err.Error())
+	}
+	return nil
+}
+
+// GetTriggerThrottlingReason gets reason of last throttling of given triggerID, empty string if trigger was not throttled
+func (connector *DbConnector) GetTriggerThrottlingReason(triggerID string) (string, error) {
+	c := connector.pool.Get()
+	defer c.Close()
+
+	reason, err := redis.String(c.Do("GET", notifierThrottlingReasonKey(triggerID)))
+	if err != nil && err != redis.ErrNil {
+		return "", fmt.Errorf("Failed to get throttling reason: %s", err.Error())
+	}
+	return reason, nil
+}
+
+// GetSubscriptionThrottling gets throttling of given triggerID notifications to subscription with own throttling levels
+func (connector *DbConnector) GetSubscriptionThrottling(triggerID, subscriptionID string) time.Time {
+	c := connector.pool.Get()
+	defer c.Close()
+
+	next, _ := redis.Int64(c.Do("HGET", notifierSubscriptionsNextKey(triggerID), subscriptionID))
+	return time.Unix(next, 0)
+}
+
+// GetSubscriptionsThrottling gets throttling of given triggerID notifications for every subscription with own throttling levels
+func (connector *DbConnector) GetSubscriptionsThrottling(triggerID string) (map[string]time.Time, error) {
+	c := connector.pool.Get()
+	defer c.Close()
+
+	values, err := redis.Int64Map(c.Do("HGETALL", notifierSubscriptionsNextKey(triggerID)))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to HGETALL: %s", err.Error())
+	}
+	throttling := make(map[string]time.Time, len(values))
+	for subscriptionID, next := range values {
+		throttling[subscriptionID] = time.Unix(next, 0)
+	}
+	return throttling, nil
+}
+
+// SetSubscriptionThrottling stores throttling of given triggerID notifications to subscription with own throttling levels
+func (connector *DbConnector) SetSubscriptionThrottling(triggerID, subscriptionID string, next time.Time) error {
+	c := connector.pool.Get()
+	defer c.Close()
+
+	if _, err := c.Do("HSET", notifierSubscriptionsNextKey(triggerID), subscriptionID, next.Unix()); err != nil {
+		return fmt.Errorf("Failed to HSET: %s", err.Error())
+	}
+	return nil
 }
 
 // DeleteTriggerThrottling deletes throttling and scheduled notifications delay for given triggerID
@@ -34,6 +89,8 @@ func (connector *DbConnector) DeleteTriggerThrottling(triggerID string) error {
 	c.Send("MULTI")
 	c.Send("SET", notifierThrottlingBeginningKey(triggerID), time.Now().Unix())
 	c.Send("DEL", notifierNextKey(triggerID))
+	c.Send("DEL", notifierSubscriptionsNextKey(triggerID))
+	c.Send("DEL", notifierThrottlingReasonKey(triggerID))
 	_, err := c.Do("EXEC")
 	if err != nil {
 		return fmt.Errorf("Failed to EXEC: %s", err.Error())
@@ -45,6 +102,14 @@ func notifierThrottlingBeginningKey(triggerID string) string {
 	return fmt.Sprintf("moira-notifier-throttling-beginning:%s", triggerID)
 }
 
+func notifierThrottlingReasonKey(triggerID string) string {
+	return fmt.Sprintf("moira-notifier-throttling-reason:%s", triggerID)
+}
+
 func notifierNextKey(triggerID string) string {
 	return fmt.Sprintf("moira-notifier-next:%s", triggerID)
 }
+
+func notifierSubscriptionsNextKey(triggerID string) string {
+	return fmt.Sprintf("moira-notifier-next-subscriptions:%s", triggerID)
+}
@@ -9,6 +9,36 @@ import (
 	"time"
 )
 
+func TestSubscriptionThrottling(t *testing.T) {
+	logger, _ := logging.GetLogger("dataBase")
+	dataBase := NewDatabase(logger, config)
+	dataBase.flush()
+	defer dataBase.flush()
+
+	Convey("Subscription throttling does not affect trigger and other subscriptions", t, func() {
+		next := time.Unix(1504509981, 0)
+		err := dataBase.SetSubscriptionThrottling("trigger", "subscription1", next)
+		So(err, ShouldBeNil)
+
+		So(dataBase.GetSubscriptionThrottling("trigger", "subscription1"), ShouldResemble, next)
+		So(dataBase.GetSubscriptionThrottling("trigger", "subscription2"), ShouldResemble, time.Unix(0, 0))
+		throttling, err := dataBase.GetSubscriptionsThrottling("trigger")
+		So(err, ShouldBeNil)
+		So(throttling, ShouldResemble, map[string]time.Time{"subscription1": next})
+		triggerNext, _ := dataBase.GetTriggerThrottling("trigger")
+		So(triggerNext, ShouldResemble, time.Unix(0, 0))
+
+		Convey("Deleted with trigger throttling", func() {
+			err = dataBase.DeleteTriggerThrottling("trigger")
+			So(err, ShouldBeNil)
+			So(dataBase.GetSubscriptionThrottling("trigger", "subscription1"), ShouldResemble, time.Unix(0, 0))
+			throttling, err := dataBase.GetSubscriptionsThrottling("trigger")
+			So(err, ShouldBeNil)
+			So(throttling, ShouldBeEmpty)
+		})
+	})
+}
+
 func TestThrottlingErrorConnection(t *testing.T) {
 	logger, _ := logging.GetLogger("dataBase")
 	dataBase := NewDatabase(logger, emptyConfig)
@@ -19,7 +49,21 @@ func TestThrottlingErrorConnection(t *testing.T) {
 		So(t1, ShouldResemble, time.Unix(0, 0))
 		So(t2, ShouldResemble, time.Unix(0, 0))
 
-		err := dataBase.SetTriggerThrottling("", time.Now())
+		err := dataBase.SetTriggerThrottling("", time.Now(), "")
+		So(err, ShouldNotBeNil)
+
+		reason, err := dataBase.GetTriggerThrottlingReason("")
+		So(err, ShouldNotBeNil)
+		So(reason, ShouldBeEmpty)
+
+		next := dataBase.GetSubscriptionThrottling("", "")
+		So(next, ShouldResemble, time.Unix(0, 0))
+
+		throttling, err := dataBase.GetSubscriptionsThrottling("")
+		So(err, ShouldNotBeNil)
+		So(throttling, ShouldBeNil)
+
+		err = dataBase.SetSubscriptionThrottling("", "", time.Now())
 		So(err, ShouldNotBeNil)
 
 		err = dataBase.DeleteTriggerThrottling("")
@@ -22,6 +22,39 @@ func (connector *DbConnector) GetTriggerIDs() ([]string, error) {
 	return triggerIds, nil
 }
 
+// GetRemoteTriggerIDs gets triggerIDs of triggers checked against remote graphite
+func (connector *DbConnector) GetRemoteTriggerIDs() ([]string, error) {
+	c := connector.pool.Get()
+	defer c.Close()
+	triggerIds, err := redis.Strings(c.Do("SMEMBERS", remoteTriggersListKey))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get remote triggers-list: %s", err.Error())
+	}
+	return triggerIds, nil
+}
+
+// GetScheduledTriggerIDs gets triggerIDs of triggers checked on their own schedule
+func (connector *DbConnector) GetScheduledTriggerIDs() ([]string, error) {
+	c := connector.pool.Get()
+	defer c.Close()
+	triggerIds, err := redis.Strings(c.Do("SMEMBERS", scheduledTriggersListKey))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get scheduled triggers-list: %s", err.Error())
+	}
+	return triggerIds, nil
+}
+
+// GetPrometheusTriggerIDs gets triggerIDs of triggers checked against Prometheus
+func (connector *DbConnector) GetPrometheusTriggerIDs() ([]string, error) {
+	c := connector.pool.Get()
+	defer c.Close()
+	triggerIds, err := redis.Strings(c.Do("SMEMBERS", prometheusTriggersListKey))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get prometheus triggers-list: %s", err.Error())
+	}
+	return triggerIds, nil
+}
+
 // GetTrigger gets trigger and trigger tags by given ID and return it in merged object
 func (connector *DbConnector) GetTrigger(triggerID string) (moira.Trigger, error) {
 	c := connector.pool.Get()
@@ -120,9 +153,23 @@ func (connector *DbConnector) SaveTrigger(triggerID string, trigger *moira.Trigg
 	}
 	c.Do("SET", triggerKey(triggerID), bytes)
 	c.Do("SADD", triggersListKey, triggerID)
+	c.Send("SREM", remoteTriggersListKey, triggerID)
+	c.Send("SREM", prometheusTriggersListKey, triggerID)
+	switch trigger.GetTriggerSource() {
+	case moira.GraphiteRemote:
+		c.Send("SADD", remoteTriggersListKey, triggerID)
+	case moira.PrometheusRemote:
+		c.Send("SADD", prometheusTriggersListKey, triggerID)
+	}
+	if trigger.IsScheduledCheck() {
+		c.Send("SADD", scheduledTriggersListKey, triggerID)
+	} else {
+		c.Send("SREM", scheduledTriggersListKey, triggerID)
+	}
 	for _, pattern := range trigger.Patterns {
 		c.Do("SADD", patternsListKey, pattern)
 		c.Do("SADD", patternTriggersKey(pattern), triggerID)
+		c.Send("PUBLISH", patternEventKey, patternEventBytes(pattern, false))
 	}
 	for _, tag := range trigger.Tags {
 		c.Send("SADD", triggerTagsKey(triggerID), tag)
@@ -166,6 +213,9 @@ func (connector *DbConnector) RemoveTrigger(triggerID string) error {
 	c.Send("DEL", triggerKey(triggerID))
 	c.Send("DEL", triggerTagsKey(triggerID))
 	c.Send("SREM", triggersListKey, triggerID)
+	c.Send("SREM", remoteTriggersListKey, triggerID)
+	c.Send("SREM", prometheusTriggersListKey, triggerID)
+	c.Send("SREM", scheduledTriggersListKey, triggerID)
 	for _, tag := range trigger.Tags {
 		c.Send("SREM", tagTriggersKey(tag), triggerID)
 	}
@@ -274,6 +324,9 @@ func leftJoin(left, right []string) []string {
 }
 
 var triggersListKey = "moira-triggers-list"
+var remoteTriggersListKey = "moira-remote-triggers-list"
+var prometheusTriggersListKey = "moira-prometheus-triggers-list"
+var scheduledTriggersListKey = "moira-scheduled-triggers-list"
 
 func triggerKey(triggerID string) string {
 	return fmt.Sprintf("moira-trigger:%s", triggerID)
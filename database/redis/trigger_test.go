@@ -221,7 +221,7 @@ func TestTriggerStoring(t *testing.T) {
 			So(actualTriggerChecks, ShouldResemble, []*moira.TriggerCheck{triggerCheck})
 
 			//And throttling
-			err = dataBase.SetTriggerThrottling(trigger.ID, time.Now().Add(-time.Minute))
+			err = dataBase.SetTriggerThrottling(trigger.ID, time.Now().Add(-time.Minute), "")
 			So(err, ShouldBeNil)
 
 			//But it is foul
@@ -231,9 +231,13 @@ func TestTriggerStoring(t *testing.T) {
 
 			//Now good throttling
 			th := time.Now().Add(time.Minute)
-			err = dataBase.SetTriggerThrottling(trigger.ID, th)
+			err = dataBase.SetTriggerThrottling(trigger.ID, th, "Trigger switched 10 times in last 1h0m0s")
 			So(err, ShouldBeNil)
 
+			reason, err := dataBase.GetTriggerThrottlingReason(trigger.ID)
+			So(err, ShouldBeNil)
+			So(reason, ShouldEqual, "Trigger switched 10 times in last 1h0m0s")
+
 			triggerCheck.Throttling = th.Unix()
 			actualTriggerChecks, err = dataBase.GetTriggerChecks([]string{trigger.ID})
 			So(err, ShouldBeNil)
@@ -256,6 +260,118 @@ func TestTriggerStoring(t *testing.T) {
 			So(err, ShouldBeNil)
 			So(actualTriggerChecks, ShouldResemble, []*moira.TriggerCheck{nil})
 		})
+
+		Convey("Save remote trigger and GetRemoteTriggerIDs", func() {
+			trigger := triggers[0]
+			trigger.TriggerSource = moira.GraphiteRemote
+
+			err := dataBase.SaveTrigger(trigger.ID, &trigger)
+			So(err, ShouldBeNil)
+
+			actual, err := dataBase.GetTrigger(trigger.ID)
+			So(err, ShouldBeNil)
+			So(actual, ShouldResemble, trigger)
+
+			remoteIDs, err := dataBase.GetRemoteTriggerIDs()
+			So(err, ShouldBeNil)
+			So(remoteIDs, ShouldResemble, []string{trigger.ID})
+
+			//Trigger is not remote anymore
+			trigger.TriggerSource = moira.GraphiteLocal
+			err = dataBase.SaveTrigger(trigger.ID, &trigger)
+			So(err, ShouldBeNil)
+
+			remoteIDs, err = dataBase.GetRemoteTriggerIDs()
+			So(err, ShouldBeNil)
+			So(remoteIDs, ShouldBeEmpty)
+
+			trigger.TriggerSource = moira.GraphiteRemote
+			err = dataBase.SaveTrigger(trigger.ID, &trigger)
+			So(err, ShouldBeNil)
+
+			err = dataBase.RemoveTrigger(trigger.ID)
+			So(err, ShouldBeNil)
+
+			remoteIDs, err = dataBase.GetRemoteTriggerIDs()
+			So(err, ShouldBeNil)
+			So(remoteIDs, ShouldBeEmpty)
+		})
+
+		Convey("Save prometheus trigger and GetPrometheusTriggerIDs", func() {
+			trigger := triggers[0]
+			trigger.TriggerSource = moira.PrometheusRemote
+
+			err := dataBase.SaveTrigger(trigger.ID, &trigger)
+			So(err, ShouldBeNil)
+
+			actual, err := dataBase.GetTrigger(trigger.ID)
+			So(err, ShouldBeNil)
+			So(actual, ShouldResemble, trigger)
+
+			prometheusIDs, err := dataBase.GetPrometheusTriggerIDs()
+			So(err, ShouldBeNil)
+			So(prometheusIDs, ShouldResemble, []string{trigger.ID})
+
+			remoteIDs, err := dataBase.GetRemoteTriggerIDs()
+			So(err, ShouldBeNil)
+			So(remoteIDs, ShouldBeEmpty)
+
+			//Trigger moved to remote graphite
+			trigger.TriggerSource = moira.GraphiteRemote
+			err = dataBase.SaveTrigger(trigger.ID, &trigger)
+			So(err, ShouldBeNil)
+
+			prometheusIDs, err = dataBase.GetPrometheusTriggerIDs()
+			So(err, ShouldBeNil)
+			So(prometheusIDs, ShouldBeEmpty)
+
+			remoteIDs, err = dataBase.GetRemoteTriggerIDs()
+			So(err, ShouldBeNil)
+			So(remoteIDs, ShouldResemble, []string{trigger.ID})
+
+			err = dataBase.RemoveTrigger(trigger.ID)
+			So(err, ShouldBeNil)
+
+			remoteIDs, err = dataBase.GetRemoteTriggerIDs()
+			So(err, ShouldBeNil)
+			So(remoteIDs, ShouldBeEmpty)
+		})
+
+		Convey("Save scheduled trigger and GetScheduledTriggerIDs", func() {
+			trigger := triggers[0]
+			trigger.CheckSchedule = "*/10 * * * *"
+
+			err := dataBase.SaveTrigger(trigger.ID, &trigger)
+			So(err, ShouldBeNil)
+
+			actual, err := dataBase.GetTrigger(trigger.ID)
+			So(err, ShouldBeNil)
+			So(actual, ShouldResemble, trigger)
+
+			scheduledIDs, err := dataBase.GetScheduledTriggerIDs()
+			So(err, ShouldBeNil)
+			So(scheduledIDs, ShouldResemble, []string{trigger.ID})
+
+			//Trigger is checked by incoming metrics again
+			trigger.CheckSchedule = ""
+			err = dataBase.SaveTrigger(trigger.ID, &trigger)
+			So(err, ShouldBeNil)
+
+			scheduledIDs, err = dataBase.GetScheduledTriggerIDs()
+			So(err, ShouldBeNil)
+			So(scheduledIDs, ShouldBeEmpty)
+
+			trigger.CheckInterval = 600
+			err = dataBase.SaveTrigger(trigger.ID, &trigger)
+			So(err, ShouldBeNil)
+
+			err = dataBase.RemoveTrigger(trigger.ID)
+			So(err, ShouldBeNil)
+
+			scheduledIDs, err = dataBase.GetScheduledTriggerIDs()
+			So(err, ShouldBeNil)
+			So(scheduledIDs, ShouldBeEmpty)
+		})
 	})
 }
 
@@ -269,6 +385,18 @@ func TestTriggerErrorConnection(t *testing.T) {
 		So(err, ShouldNotBeNil)
 		So(actual, ShouldBeNil)
 
+		actual, err = dataBase.GetRemoteTriggerIDs()
+		So(err, ShouldNotBeNil)
+		So(actual, ShouldBeNil)
+
+		actual, err = dataBase.GetPrometheusTriggerIDs()
+		So(err, ShouldNotBeNil)
+		So(actual, ShouldBeNil)
+
+		actual, err = dataBase.GetScheduledTriggerIDs()
+		So(err, ShouldNotBeNil)
+		So(actual, ShouldBeNil)
+
 		actual1, err := dataBase.GetTrigger("")
 		So(err, ShouldNotBeNil)
 		So(actual1, ShouldResemble, moira.Trigger{})
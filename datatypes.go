@@ -33,6 +33,9 @@ type NotificationEvent struct {
 	ContactID      string   `json:"contactId,omitempty"`
 	OldState       string   `json:"old_state"`
 	Message        *string  `json:"msg,omitempty"`
+	// StateTimestamp and LastEventTimestamp are set for reminders about metric staying in the same state
+	StateTimestamp     int64 `json:"state_timestamp,omitempty"`
+	LastEventTimestamp int64 `json:"last_event_timestamp,omitempty"`
 }
 
 // NotificationEvents represents slice of NotificationEvent
@@ -66,6 +69,31 @@ type SubscriptionData struct {
 	Enabled           bool         `json:"enabled"`
 	ThrottlingEnabled bool         `json:"throttling"`
 	User              string       `json:"user"`
+	// ReminderInterval is minimal interval in seconds between reminders sent to subscription, 0 disables reminders.
+	// Reminders are generated at trigger ReminderIntervals, so it can only make them less frequent:
+	// interval shorter than trigger one has no effect
+	ReminderInterval *int64 `json:"reminder_interval,omitempty"`
+	// Escalations are next levels of contacts notified if metric is still in bad state after their offsets
+	Escalations []EscalationData `json:"escalations,omitempty"`
+	// ThrottlingLevels override notifier throttling levels for subscription with enabled throttling
+	ThrottlingLevels []ThrottlingLevel `json:"throttling_levels,omitempty"`
+	// DigestInterval in seconds makes notifications collected and sent to every contact as one message about all triggers
+	DigestInterval int64 `json:"digest_interval,omitempty"`
+}
+
+// ThrottlingLevel represents throttling rule: if trigger switches Count times in Duration seconds,
+// its next notifications are delayed for Delay seconds
+type ThrottlingLevel struct {
+	Duration int64 `json:"duration"`
+	Delay    int64 `json:"delay"`
+	Count    int64 `json:"count"`
+}
+
+// EscalationData represents escalation level of subscription,
+// its contacts are notified OffsetInSeconds after event unless metric is recovered
+type EscalationData struct {
+	Contacts        []string `json:"contacts"`
+	OffsetInSeconds int64    `json:"offset_in_seconds"`
 }
 
 // ScheduleData represent subscription schedule
@@ -90,6 +118,12 @@ type ScheduledNotification struct {
 	Throttled bool              `json:"throttled"`
 	SendFail  int               `json:"send_fail"`
 	Timestamp int64             `json:"timestamp"`
+	// EscalationLevel is number of subscription escalation, 0 for notifications sent right after event
+	EscalationLevel int `json:"escalation_level,omitempty"`
+	// Digest notifications of contact are sent together with its other digest notifications
+	Digest bool `json:"digest,omitempty"`
+	// Raw is notification as it is stored in database, set when notification is read from database to remove exactly it
+	Raw []byte `json:"-"`
 }
 
 // MatchedMetric represent parsed and matched metric data
@@ -100,6 +134,54 @@ type MatchedMetric struct {
 	Timestamp          int64
 	RetentionTimestamp int64
 	Retention          int
+	Archives           []MetricArchive
+	Rollups            []*MetricRollup
+	// Aggregate of values received within retention interval is merged with aggregate saved before,
+	// nil means value is saved as is
+	Aggregate *MetricAggregate
+}
+
+// MetricAggregate represent values of metric received by filter within one retention interval since its buffer was saved.
+// It is merged with aggregate of the same interval saved by other filters or before restart
+type MetricAggregate struct {
+	Method    string
+	Count     int64
+	Sum       float64
+	Min       float64
+	Max       float64
+	Timestamp int64
+	Last      float64
+}
+
+// Add adds received value to aggregate
+func (aggregate *MetricAggregate) Add(timestamp int64, value float64) {
+	if aggregate.Count == 0 || value < aggregate.Min {
+		aggregate.Min = value
+	}
+	if aggregate.Count == 0 || value > aggregate.Max {
+		aggregate.Max = value
+	}
+	if aggregate.Count == 0 || timestamp >= aggregate.Timestamp {
+		aggregate.Timestamp = timestamp
+		aggregate.Last = value
+	}
+	aggregate.Count++
+	aggregate.Sum += value
+}
+
+// MetricArchive represent one precision:duration archive of storage-schemas.conf retentions in seconds.
+// Zero duration means archive is not limited by time
+type MetricArchive struct {
+	Precision int64 `json:"precision"`
+	Duration  int64 `json:"duration"`
+}
+
+// MetricRollup represent metric values aggregated into bucket of coarser archive.
+// Like aggregate of retention interval it is merged with aggregate of the bucket saved before
+type MetricRollup struct {
+	Archive            MetricArchive
+	RetentionTimestamp int64
+	Aggregate          MetricAggregate
 }
 
 // MetricValue represent metric data
@@ -111,21 +193,82 @@ type MetricValue struct {
 
 // Trigger represents trigger data object
 type Trigger struct {
-	ID               string        `json:"id"`
-	Name             string        `json:"name"`
-	Desc             *string       `json:"desc,omitempty"`
-	Targets          []string      `json:"targets"`
-	WarnValue        *float64      `json:"warn_value"`
-	ErrorValue       *float64      `json:"error_value"`
-	Tags             []string      `json:"tags"`
-	TTLState         *string       `json:"ttl_state,omitempty"`
-	TTL              int64         `json:"ttl,omitempty"`
-	Schedule         *ScheduleData `json:"sched,omitempty"`
-	Expression       *string       `json:"expression,omitempty"`
-	PythonExpression *string       `json:"python_expression,omitempty"`
-	Patterns         []string      `json:"patterns"`
+	ID                 string            `json:"id"`
+	Name               string            `json:"name"`
+	Desc               *string           `json:"desc,omitempty"`
+	Targets            []string          `json:"targets"`
+	WarnValue          *float64          `json:"warn_value"`
+	ErrorValue         *float64          `json:"error_value"`
+	Tags               []string          `json:"tags"`
+	TTLState           *string           `json:"ttl_state,omitempty"`
+	TTL                int64             `json:"ttl,omitempty"`
+	Schedule           *ScheduleData     `json:"sched,omitempty"`
+	Expression         *string           `json:"expression,omitempty"`
+	PythonExpression   *string           `json:"python_expression,omitempty"`
+	Patterns           []string          `json:"patterns"`
+	TriggerSource      TriggerSource     `json:"trigger_source,omitempty"`
+	WarnRecoveryValue  *float64          `json:"warn_recovery_value,omitempty"`
+	ErrorRecoveryValue *float64          `json:"error_recovery_value,omitempty"`
+	ConfirmPoints      int64             `json:"confirm_points,omitempty"`
+	ConfirmDuration    int64             `json:"confirm_duration,omitempty"`
+	Anomaly            *AnomalyDetection `json:"anomaly,omitempty"`
+	StateAggregation   *StateAggregation `json:"state_aggregation,omitempty"`
+	// ReminderIntervals are intervals in seconds between reminders about metric staying in WARN, ERROR or NODATA state,
+	// 0 disables reminders. States not listed here use default intervals.
+	// They are the most frequent reminders, subscriptions can only receive them less often
+	ReminderIntervals map[string]int64 `json:"reminder_intervals,omitempty"`
+	// CheckInterval in seconds or cron-like CheckSchedule make trigger checked on schedule instead of incoming metrics
+	CheckInterval int64  `json:"check_interval,omitempty"`
+	CheckSchedule string `json:"check_schedule,omitempty"`
+	// Dependencies are parent triggers, events of trigger are suppressed while any of parents is in bad state:
+	// its trigger state or state of any its metric not under maintenance is ERROR, NODATA or EXCEPTION
+	Dependencies *TriggerDependencies `json:"dependencies,omitempty"`
+}
+
+// TriggerDependencies represents parent triggers given by IDs or by tags
+type TriggerDependencies struct {
+	TriggerIDs []string `json:"trigger_ids,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+}
+
+// StateAggregation represents rule to define trigger state by number of its metrics in bad states.
+// Metrics in ERROR or NODATA states are counted for ERROR, metrics in WARN, ERROR or NODATA states are counted for WARN.
+// Values are percents of all trigger metrics if Percent is set, and numbers of metrics otherwise
+type StateAggregation struct {
+	Percent              bool     `json:"percent,omitempty"`
+	WarnValue            *float64 `json:"warn_value,omitempty"`
+	ErrorValue           *float64 `json:"error_value,omitempty"`
+	SuppressMetricEvents bool     `json:"suppress_metric_events,omitempty"`
 }
 
+// Anomaly detection methods
+const (
+	RollingAnomaly  = "rolling"
+	SeasonalAnomaly = "seasonal"
+)
+
+// AnomalyDetection represents trigger settings to check deviation of metric values from their historical baseline.
+// Baseline is mean of values within Window seconds before checked value for rolling method
+// and within Window seconds around the same time Season seconds ago for seasonal method.
+// Deviation is measured in standard deviations of these values
+type AnomalyDetection struct {
+	Method         string  `json:"method"`
+	Window         int64   `json:"window"`
+	Season         int64   `json:"season,omitempty"`
+	WarnDeviation  float64 `json:"warn_deviation"`
+	ErrorDeviation float64 `json:"error_deviation"`
+}
+
+// TriggerSource is type of storage trigger targets are evaluated against
+type TriggerSource string
+
+// Trigger sources
+const (
+	GraphiteLocal    TriggerSource = "graphite_local"
+	GraphiteRemote   TriggerSource = "graphite_remote"
+	PrometheusRemote TriggerSource = "prometheus_remote"
+)
+
 // TriggerCheck represent trigger data with last check data and check timestamp
 type TriggerCheck struct {
 	Trigger
@@ -143,17 +286,39 @@ type CheckData struct {
 	Suppressed      bool                   `json:"suppressed,omitempty"`
 	SuppressedState string                 `json:"suppressed_state,omitempty"`
 	Message         string                 `json:"msg,omitempty"`
+	StateTimestamp  int64                  `json:"state_timestamp,omitempty"`
+	// SuppressedBy is ID of parent trigger which bad state suppressed events of trigger
+	SuppressedBy    string           `json:"suppressed_by,omitempty"`
+	Acknowledgement *Acknowledgement `json:"acknowledgement,omitempty"`
+	// SuppressedByParent is set instead of Suppressed when events are suppressed by parent trigger, not by maintenance
+	SuppressedByParent bool `json:"suppressed_by_parent,omitempty"`
 }
 
 // MetricState represent metric state data for given timestamp
 type MetricState struct {
-	EventTimestamp  int64    `json:"event_timestamp"`
-	State           string   `json:"state"`
-	Suppressed      bool     `json:"suppressed"`
-	SuppressedState string   `json:"suppressed_state,omitempty"`
-	Timestamp       int64    `json:"timestamp"`
-	Value           *float64 `json:"value,omitempty"`
-	Maintenance     int64    `json:"maintenance,omitempty"`
+	EventTimestamp   int64            `json:"event_timestamp"`
+	State            string           `json:"state"`
+	Suppressed       bool             `json:"suppressed"`
+	SuppressedState  string           `json:"suppressed_state,omitempty"`
+	Timestamp        int64            `json:"timestamp"`
+	Value            *float64         `json:"value,omitempty"`
+	Maintenance      int64            `json:"maintenance,omitempty"`
+	PendingState     string           `json:"pending_state,omitempty"`
+	PendingTimestamp int64            `json:"pending_timestamp,omitempty"`
+	StateTimestamp   int64            `json:"state_timestamp,omitempty"`
+	Acknowledgement  *Acknowledgement `json:"acknowledgement,omitempty"`
+	// SuppressedByParent is set instead of Suppressed when events are suppressed by parent trigger, not by maintenance
+	SuppressedByParent bool `json:"suppressed_by_parent,omitempty"`
+	// PendingPoints is number of consecutive points with PendingState
+	PendingPoints int64 `json:"pending_points,omitempty"`
+}
+
+// Acknowledgement represents user's confirmation that bad state of trigger or metric is known and being fixed,
+// reminders and escalations are not sent until Until timestamp or metric recovery
+type Acknowledgement struct {
+	User      string `json:"user"`
+	Timestamp int64  `json:"timestamp"`
+	Until     int64  `json:"until"`
 }
 
 // MetricEvent represent filter metric event
@@ -162,6 +327,12 @@ type MetricEvent struct {
 	Pattern string `json:"pattern"`
 }
 
+// PatternEvent represent pattern adding or removing event, published when triggers are saved or removed
+type PatternEvent struct {
+	Pattern string `json:"pattern"`
+	Removed bool   `json:"removed,omitempty"`
+}
+
 // GetSubjectState returns the most critical state of events
 func (events NotificationEvents) GetSubjectState() string {
 	result := ""
@@ -202,6 +373,40 @@ func (notification *ScheduledNotification) GetKey() string {
 	)
 }
 
+// IsActive checks if acknowledgement is set and not expired at given timestamp
+func (acknowledgement *Acknowledgement) IsActive(timestamp int64) bool {
+	return acknowledgement != nil && acknowledgement.Until >= timestamp
+}
+
+// IsAcknowledged checks if trigger or given metric of trigger is acknowledged at given timestamp
+func (checkData *CheckData) IsAcknowledged(metric string, timestamp int64) bool {
+	if checkData.Acknowledgement.IsActive(timestamp) {
+		return true
+	}
+	metricState, ok := checkData.Metrics[metric]
+	return ok && metricState.Acknowledgement.IsActive(timestamp)
+}
+
+// IsReminder returns true if event reminds about metric staying in the same state
+func (event *NotificationEvent) IsReminder() bool {
+	return event.StateTimestamp != 0
+}
+
+// NeedsReminder checks if reminder event should be sent to subscription.
+// Reminder is sent if metric has crossed next ReminderInterval in its state since last event.
+// Only reminder events generated by checker at trigger reminder intervals are filtered, so subscription
+// with shorter ReminderInterval gets all of them and no more
+func (subscription *SubscriptionData) NeedsReminder(event *NotificationEvent) bool {
+	if !event.IsReminder() || subscription.ReminderInterval == nil {
+		return true
+	}
+	interval := *subscription.ReminderInterval
+	if interval <= 0 {
+		return false
+	}
+	return (event.Timestamp-event.StateTimestamp)/interval > (event.LastEventTimestamp-event.StateTimestamp)/interval
+}
+
 // IsScheduleAllows check if the time is in the allowed schedule interval
 func (schedule *ScheduleData) IsScheduleAllows(ts int64) bool {
 	if schedule == nil {
@@ -250,6 +455,15 @@ func (metricState *MetricState) GetCheckPoint(checkPointGap int64) int64 {
 	return int64(math.Max(float64(metricState.Timestamp-checkPointGap), float64(metricState.EventTimestamp)))
 }
 
+// GetStateTimestamp gets timestamp metric has changed its state.
+// StateTimestamp is stored only after reminders, otherwise state was changed with last event
+func (metricState MetricState) GetStateTimestamp() int64 {
+	if metricState.StateTimestamp == 0 {
+		return metricState.GetEventTimestamp()
+	}
+	return metricState.StateTimestamp
+}
+
 // GetEventTimestamp gets event timestamp for given metric
 func (metricState MetricState) GetEventTimestamp() int64 {
 	if metricState.EventTimestamp == 0 {
@@ -258,6 +472,15 @@ func (metricState MetricState) GetEventTimestamp() int64 {
 	return metricState.EventTimestamp
 }
 
+// GetStateTimestamp gets timestamp trigger has changed its state.
+// StateTimestamp is stored only after reminders, otherwise state was changed with last event
+func (checkData CheckData) GetStateTimestamp() int64 {
+	if checkData.StateTimestamp == 0 {
+		return checkData.GetEventTimestamp()
+	}
+	return checkData.StateTimestamp
+}
+
 // GetEventTimestamp gets event timestamp for given check
 func (checkData CheckData) GetEventTimestamp() int64 {
 	if checkData.EventTimestamp == 0 {
@@ -274,13 +497,34 @@ func (trigger *Trigger) IsSimple() bool {
 		return false
 	}
 	for _, pattern := range trigger.Patterns {
-		if strings.ContainsAny(pattern, "*{?[") {
+		if strings.ContainsAny(pattern, "*{?[") || IsSeriesByTagPattern(pattern) {
 			return false
 		}
 	}
 	return true
 }
 
+// IsScheduledCheck returns true if trigger is checked on its own schedule instead of incoming metrics
+func (trigger *Trigger) IsScheduledCheck() bool {
+	return trigger.CheckInterval > 0 || trigger.CheckSchedule != ""
+}
+
+// GetTriggerSource returns trigger source, trigger without source is checked against local graphite storage
+func (trigger *Trigger) GetTriggerSource() TriggerSource {
+	if trigger.TriggerSource != "" {
+		return trigger.TriggerSource
+	}
+	return GraphiteLocal
+}
+
+// GetReferenceInterval returns interval of history values baseline of value at given timestamp is calculated from
+func (anomaly *AnomalyDetection) GetReferenceInterval(valueTimestamp int64) (int64, int64) {
+	if anomaly.Method == SeasonalAnomaly {
+		return valueTimestamp - anomaly.Season - anomaly.Window/2, valueTimestamp - anomaly.Season + anomaly.Window/2
+	}
+	return valueTimestamp - anomaly.Window, valueTimestamp - 1
+}
+
 // UpdateScore update and return checkData score, based on metric states and checkData state
 func (checkData *CheckData) UpdateScore() int64 {
 	checkData.Score = scores[checkData.State]
@@ -154,6 +154,18 @@ func TestCheckData_GetOrCreateMetricState(t *testing.T) {
 	})
 }
 
+func TestMetricAggregate_Add(t *testing.T) {
+	Convey("Add values to aggregate", t, func() {
+		aggregate := MetricAggregate{Method: "sum"}
+		aggregate.Add(20, 5)
+		So(aggregate, ShouldResemble, MetricAggregate{Method: "sum", Count: 1, Sum: 5, Min: 5, Max: 5, Timestamp: 20, Last: 5})
+
+		aggregate.Add(10, -1)
+		aggregate.Add(25, 2)
+		So(aggregate, ShouldResemble, MetricAggregate{Method: "sum", Count: 3, Sum: 6, Min: -1, Max: 5, Timestamp: 25, Last: 2})
+	})
+}
+
 func TestMetricState_GetCheckPoint(t *testing.T) {
 	Convey("Get check point", t, func() {
 		metricState := MetricState{Timestamp: 800, EventTimestamp: 700}
@@ -177,6 +189,36 @@ func TestMetricState_GetEventTimestamp(t *testing.T) {
 	})
 }
 
+func TestMetricState_GetStateTimestamp(t *testing.T) {
+	Convey("Get state timestamp", t, func() {
+		metricState := MetricState{Timestamp: 800, EventTimestamp: 700}
+		So(metricState.GetStateTimestamp(), ShouldEqual, 700)
+
+		metricState = MetricState{Timestamp: 800, EventTimestamp: 700, StateTimestamp: 500}
+		So(metricState.GetStateTimestamp(), ShouldEqual, 500)
+	})
+}
+
+func TestSubscriptionData_NeedsReminder(t *testing.T) {
+	Convey("Needs reminder", t, func() {
+		event := &NotificationEvent{Timestamp: 5000, StateTimestamp: 1000, LastEventTimestamp: 3000}
+		disabled, crossed, notCrossed := int64(0), int64(1500), int64(4500)
+
+		subscription := SubscriptionData{}
+		So(subscription.NeedsReminder(event), ShouldBeTrue)
+
+		subscription = SubscriptionData{ReminderInterval: &disabled}
+		So(subscription.NeedsReminder(&NotificationEvent{Timestamp: 5000}), ShouldBeTrue)
+		So(subscription.NeedsReminder(event), ShouldBeFalse)
+
+		subscription = SubscriptionData{ReminderInterval: &crossed}
+		So(subscription.NeedsReminder(event), ShouldBeTrue)
+
+		subscription = SubscriptionData{ReminderInterval: &notCrossed}
+		So(subscription.NeedsReminder(event), ShouldBeFalse)
+	})
+}
+
 func TestTrigger_IsSimple(t *testing.T) {
 	Convey("Is Simple", t, func() {
 		trigger := Trigger{
@@ -206,6 +248,42 @@ func TestTrigger_IsSimple(t *testing.T) {
 	})
 }
 
+func TestTrigger_IsScheduledCheck(t *testing.T) {
+	Convey("Is scheduled check", t, func() {
+		So((&Trigger{}).IsScheduledCheck(), ShouldBeFalse)
+		So((&Trigger{CheckInterval: 600}).IsScheduledCheck(), ShouldBeTrue)
+		So((&Trigger{CheckSchedule: "0 * * * *"}).IsScheduledCheck(), ShouldBeTrue)
+	})
+}
+
+func TestTrigger_GetTriggerSource(t *testing.T) {
+	Convey("Local graphite by default", t, func() {
+		trigger := Trigger{}
+		So(trigger.GetTriggerSource(), ShouldEqual, GraphiteLocal)
+	})
+
+	Convey("Trigger source if set", t, func() {
+		trigger := Trigger{TriggerSource: PrometheusRemote}
+		So(trigger.GetTriggerSource(), ShouldEqual, PrometheusRemote)
+	})
+}
+
+func TestAnomalyDetection_GetReferenceInterval(t *testing.T) {
+	Convey("Rolling window before value", t, func() {
+		anomaly := AnomalyDetection{Method: RollingAnomaly, Window: 600}
+		from, until := anomaly.GetReferenceInterval(1000)
+		So(from, ShouldEqual, 400)
+		So(until, ShouldEqual, 999)
+	})
+
+	Convey("Seasonal window around value of previous season", t, func() {
+		anomaly := AnomalyDetection{Method: SeasonalAnomaly, Window: 600, Season: 86400}
+		from, until := anomaly.GetReferenceInterval(100000)
+		So(from, ShouldEqual, 13300)
+		So(until, ShouldEqual, 13900)
+	})
+}
+
 func TestCheckData_GetEventTimestamp(t *testing.T) {
 	Convey("Get event timestamp", t, func() {
 		checkData := CheckData{Timestamp: 800, EventTimestamp: 0}
@@ -11,6 +11,13 @@ import (
 var default1, _ = govaluate.NewEvaluableExpression("t1 >= ERROR_VALUE ? ERROR : (t1 >= WARN_VALUE ? WARN : OK)")
 var default2, _ = govaluate.NewEvaluableExpression("t1 <= ERROR_VALUE ? ERROR : (t1 <= WARN_VALUE ? WARN : OK)")
 
+// Default expressions with recovery thresholds: metric stays in WARN or ERROR state until its value crosses recovery value
+var hysteresis1, _ = govaluate.NewEvaluableExpression("t1 >= ERROR_VALUE || (PREV_STATE == ERROR && t1 >= ERROR_RECOVERY_VALUE) ? ERROR : (t1 >= WARN_VALUE || ((PREV_STATE == WARN || PREV_STATE == ERROR) && t1 >= WARN_RECOVERY_VALUE) ? WARN : OK)")
+var hysteresis2, _ = govaluate.NewEvaluableExpression("t1 <= ERROR_VALUE || (PREV_STATE == ERROR && t1 <= ERROR_RECOVERY_VALUE) ? ERROR : (t1 <= WARN_VALUE || ((PREV_STATE == WARN || PREV_STATE == ERROR) && t1 <= WARN_RECOVERY_VALUE) ? WARN : OK)")
+
+// Default expression of anomaly detection triggers: state is defined by absolute deviation from baseline
+var anomaly, _ = govaluate.NewEvaluableExpression("DEVIATION >= ERROR_DEVIATION || DEVIATION <= -ERROR_DEVIATION ? ERROR : (DEVIATION >= WARN_DEVIATION || DEVIATION <= -WARN_DEVIATION ? WARN : OK)")
+
 var cache = make(map[string]*govaluate.EvaluableExpression)
 var cacheLock sync.Mutex
 
@@ -30,6 +37,14 @@ type TriggerExpression struct {
 	WarnValue  *float64
 	ErrorValue *float64
 
+	WarnRecoveryValue  *float64
+	ErrorRecoveryValue *float64
+
+	Baseline       *float64
+	Deviation      *float64
+	WarnDeviation  *float64
+	ErrorDeviation *float64
+
 	MainTargetValue         float64
 	AdditionalTargetsValues map[string]float64
 	PreviousState           string
@@ -56,6 +71,36 @@ func (triggerExpression TriggerExpression) Get(name string) (interface{}, error)
 			return nil, fmt.Errorf("no value with name ERROR_VALUE")
 		}
 		return *triggerExpression.ErrorValue, nil
+	case "WARN_RECOVERY_VALUE":
+		if triggerExpression.WarnRecoveryValue == nil {
+			return triggerExpression.Get("WARN_VALUE")
+		}
+		return *triggerExpression.WarnRecoveryValue, nil
+	case "ERROR_RECOVERY_VALUE":
+		if triggerExpression.ErrorRecoveryValue == nil {
+			return triggerExpression.Get("ERROR_VALUE")
+		}
+		return *triggerExpression.ErrorRecoveryValue, nil
+	case "BASELINE":
+		if triggerExpression.Baseline == nil {
+			return nil, fmt.Errorf("no value with name BASELINE")
+		}
+		return *triggerExpression.Baseline, nil
+	case "DEVIATION":
+		if triggerExpression.Deviation == nil {
+			return nil, fmt.Errorf("no value with name DEVIATION")
+		}
+		return *triggerExpression.Deviation, nil
+	case "WARN_DEVIATION":
+		if triggerExpression.WarnDeviation == nil {
+			return nil, fmt.Errorf("no value with name WARN_DEVIATION")
+		}
+		return *triggerExpression.WarnDeviation, nil
+	case "ERROR_DEVIATION":
+		if triggerExpression.ErrorDeviation == nil {
+			return nil, fmt.Errorf("no value with name ERROR_DEVIATION")
+		}
+		return *triggerExpression.ErrorDeviation, nil
 	case "t1":
 		return triggerExpression.MainTargetValue, nil
 	case "PREV_STATE":
@@ -91,19 +136,54 @@ func getExpression(triggerExpression *TriggerExpression) (*govaluate.EvaluableEx
 	if triggerExpression.Expression != nil && *triggerExpression.Expression != "" {
 		return getUserExpression(*triggerExpression.Expression)
 	}
+	if triggerExpression.WarnDeviation != nil || triggerExpression.ErrorDeviation != nil {
+		return getAnomalyExpression(triggerExpression)
+	}
 	return getSimpleExpression(triggerExpression)
 }
 
+func getAnomalyExpression(triggerExpression *TriggerExpression) (*govaluate.EvaluableExpression, error) {
+	if triggerExpression.ErrorDeviation == nil || triggerExpression.WarnDeviation == nil {
+		return nil, fmt.Errorf("error deviation and warning deviation can not be empty")
+	}
+	return anomaly, nil
+}
+
 func getSimpleExpression(triggerExpression *TriggerExpression) (*govaluate.EvaluableExpression, error) {
 	if triggerExpression.ErrorValue == nil || triggerExpression.WarnValue == nil {
 		return nil, fmt.Errorf("error value and Warning value can not be empty")
 	}
+	hasRecoveryValues := triggerExpression.WarnRecoveryValue != nil || triggerExpression.ErrorRecoveryValue != nil
 	if *triggerExpression.ErrorValue >= *triggerExpression.WarnValue {
+		if hasRecoveryValues {
+			return hysteresis1, nil
+		}
 		return default1, nil
 	}
+	if hasRecoveryValues {
+		return hysteresis2, nil
+	}
 	return default2, nil
 }
 
+// ValidateRecoveryValues checks recovery values are on the side of OK state from warn and error values
+func ValidateRecoveryValues(warnValue, errorValue, warnRecoveryValue, errorRecoveryValue *float64) error {
+	if warnRecoveryValue == nil && errorRecoveryValue == nil {
+		return nil
+	}
+	if warnValue == nil || errorValue == nil {
+		return fmt.Errorf("recovery values can be used only with warn and error values")
+	}
+	rising := *errorValue >= *warnValue
+	if warnRecoveryValue != nil && (rising && *warnRecoveryValue > *warnValue || !rising && *warnRecoveryValue < *warnValue) {
+		return fmt.Errorf("warn recovery value must be on the side of OK state from warn value")
+	}
+	if errorRecoveryValue != nil && (rising && *errorRecoveryValue > *errorValue || !rising && *errorRecoveryValue < *errorValue) {
+		return fmt.Errorf("error recovery value must be on the side of OK state from error value")
+	}
+	return nil
+}
+
 func getUserExpression(triggerExpression string) (*govaluate.EvaluableExpression, error) {
 	err := evaluateAndCacheExpressionIfNeed(triggerExpression)
 	if err != nil {
@@ -50,6 +50,84 @@ func TestExpression(t *testing.T) {
 		So(result, ShouldBeEmpty)
 	})
 
+	Convey("Test Default with recovery values", t, func() {
+		warnValue := 60.0
+		errorValue := 90.0
+		warnRecoveryValue := 50.0
+		errorRecoveryValue := 80.0
+		values := TriggerExpression{WarnValue: &warnValue, ErrorValue: &errorValue, WarnRecoveryValue: &warnRecoveryValue, ErrorRecoveryValue: &errorRecoveryValue}
+
+		states := []struct {
+			value         float64
+			previousState string
+			expected      string
+		}{
+			{85, "OK", "WARN"},
+			{85, "WARN", "WARN"},
+			{85, "ERROR", "ERROR"},
+			{80, "ERROR", "ERROR"},
+			{79, "ERROR", "WARN"},
+			{55, "OK", "OK"},
+			{55, "WARN", "WARN"},
+			{55, "ERROR", "WARN"},
+			{49, "WARN", "OK"},
+			{90, "OK", "ERROR"},
+		}
+		for _, state := range states {
+			values.MainTargetValue = state.value
+			values.PreviousState = state.previousState
+			result, err := values.Evaluate()
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, state.expected)
+		}
+
+		warnValue = 30.0
+		errorValue = 10.0
+		warnRecoveryValue = 40.0
+
+		result, err := (&TriggerExpression{MainTargetValue: 15.0, PreviousState: "ERROR", WarnValue: &warnValue, ErrorValue: &errorValue, WarnRecoveryValue: &warnRecoveryValue}).Evaluate()
+		So(err, ShouldBeNil)
+		So(result, ShouldResemble, "WARN")
+
+		result, err = (&TriggerExpression{MainTargetValue: 35.0, PreviousState: "WARN", WarnValue: &warnValue, ErrorValue: &errorValue, WarnRecoveryValue: &warnRecoveryValue}).Evaluate()
+		So(err, ShouldBeNil)
+		So(result, ShouldResemble, "WARN")
+
+		result, err = (&TriggerExpression{MainTargetValue: 41.0, PreviousState: "WARN", WarnValue: &warnValue, ErrorValue: &errorValue, WarnRecoveryValue: &warnRecoveryValue}).Evaluate()
+		So(err, ShouldBeNil)
+		So(result, ShouldResemble, "OK")
+	})
+
+	Convey("Test Anomaly", t, func() {
+		warnDeviation := 2.0
+		errorDeviation := 3.0
+		states := map[float64]string{
+			0:    "OK",
+			1.9:  "OK",
+			2:    "WARN",
+			-2.5: "WARN",
+			3:    "ERROR",
+			-3:   "ERROR",
+		}
+		for deviation, expected := range states {
+			deviation := deviation
+			result, err := (&TriggerExpression{Deviation: &deviation, WarnDeviation: &warnDeviation, ErrorDeviation: &errorDeviation}).Evaluate()
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, expected)
+		}
+
+		deviation := 1.0
+		result, err := (&TriggerExpression{Deviation: &deviation, WarnDeviation: &warnDeviation}).Evaluate()
+		So(err, ShouldResemble, ErrInvalidExpression{fmt.Errorf("error deviation and warning deviation can not be empty")})
+		So(result, ShouldBeEmpty)
+
+		expression := "t1 > BASELINE * 2 ? ERROR : OK"
+		baseline := 10.0
+		result, err = (&TriggerExpression{Expression: &expression, MainTargetValue: 21, Baseline: &baseline, Deviation: &deviation}).Evaluate()
+		So(err, ShouldBeNil)
+		So(result, ShouldResemble, "ERROR")
+	})
+
 	Convey("Test Custom", t, func() {
 		expression := "t1 > 10 && t2 > 3 ? ERROR : OK"
 		result, err := (&TriggerExpression{Expression: &expression, MainTargetValue: 11.0, AdditionalTargetsValues: map[string]float64{"t2": 4.0}}).Evaluate()
@@ -63,8 +141,36 @@ func TestExpression(t *testing.T) {
 	})
 }
 
+func TestValidateRecoveryValues(t *testing.T) {
+	warnValue := 60.0
+	errorValue := 90.0
+	lower := 50.0
+	higher := 95.0
+
+	Convey("No recovery values", t, func() {
+		So(ValidateRecoveryValues(nil, nil, nil, nil), ShouldBeNil)
+	})
+
+	Convey("Recovery values without thresholds", t, func() {
+		So(ValidateRecoveryValues(nil, nil, &lower, nil), ShouldNotBeNil)
+	})
+
+	Convey("Rising thresholds", t, func() {
+		So(ValidateRecoveryValues(&warnValue, &errorValue, &lower, &lower), ShouldBeNil)
+		So(ValidateRecoveryValues(&warnValue, &errorValue, &higher, nil), ShouldNotBeNil)
+		So(ValidateRecoveryValues(&warnValue, &errorValue, nil, &higher), ShouldNotBeNil)
+	})
+
+	Convey("Falling thresholds", t, func() {
+		So(ValidateRecoveryValues(&errorValue, &warnValue, &higher, &higher), ShouldBeNil)
+		So(ValidateRecoveryValues(&errorValue, &warnValue, &lower, nil), ShouldNotBeNil)
+		So(ValidateRecoveryValues(&errorValue, &warnValue, nil, &lower), ShouldNotBeNil)
+	})
+}
+
 func TestGetExpressionValue(t *testing.T) {
 	floatVal := 10.0
+	recoveryVal := 8.0
 	Convey("Test basic strings", t, func() {
 		getExpressionValuesTests := []getExpressionValuesTest{
 			{
@@ -104,6 +210,26 @@ func TestGetExpressionValue(t *testing.T) {
 					name:          "ERROR_VALUE",
 					expectedValue: floatVal,
 				},
+				{
+					values:        TriggerExpression{WarnValue: &floatVal},
+					name:          "WARN_RECOVERY_VALUE",
+					expectedValue: floatVal,
+				},
+				{
+					values:        TriggerExpression{ErrorValue: &floatVal, ErrorRecoveryValue: &recoveryVal},
+					name:          "ERROR_RECOVERY_VALUE",
+					expectedValue: recoveryVal,
+				},
+				{
+					values:        TriggerExpression{Baseline: &floatVal},
+					name:          "BASELINE",
+					expectedValue: floatVal,
+				},
+				{
+					values:        TriggerExpression{Deviation: &recoveryVal},
+					name:          "DEVIATION",
+					expectedValue: recoveryVal,
+				},
 				{
 					values:        TriggerExpression{MainTargetValue: 11.0},
 					name:          "t1",
@@ -0,0 +1,187 @@
+package filter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/moira-alert/moira"
+)
+
+// Aggregation methods, named as in graphite storage-aggregation.conf
+const (
+	AverageAggregation = "average"
+	SumAggregation     = "sum"
+	MinAggregation     = "min"
+	MaxAggregation     = "max"
+	LastAggregation    = "last"
+)
+
+var aggregationMethods = map[string]bool{
+	AverageAggregation: true,
+	SumAggregation:     true,
+	MinAggregation:     true,
+	MaxAggregation:     true,
+	LastAggregation:    true,
+}
+
+type aggregationMatcher struct {
+	pattern *regexp.Regexp
+	method  string
+}
+
+// LoadAggregations reads aggregation rules in graphite storage-aggregation.conf format.
+// The first rule with pattern matching metric name defines how values received within one retention interval
+// are combined before saving. Metrics not matching any rule keep the last received value
+// and are averaged into coarser archives.
+// xFilesFactor is ignored, because every received value is saved
+func (storage *Storage) LoadAggregations(reader io.Reader) error {
+	aggregations := make([]aggregationMatcher, 0)
+	scanner := bufio.NewScanner(reader)
+	var section string
+	var current *aggregationMatcher
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			if current != nil {
+				if err := validateAggregation(section, current); err != nil {
+					return err
+				}
+				aggregations = append(aggregations, *current)
+			}
+			section = line[1 : len(line)-1]
+			current = &aggregationMatcher{method: AverageAggregation}
+			continue
+		}
+		keyValue := strings.SplitN(line, "=", 2)
+		if current == nil || len(keyValue) != 2 {
+			return fmt.Errorf("Invalid aggregation config line: '%s'", line)
+		}
+		value := strings.TrimSpace(keyValue[1])
+		switch strings.TrimSpace(keyValue[0]) {
+		case "pattern":
+			pattern, err := regexp.Compile(value)
+			if err != nil {
+				return fmt.Errorf("Invalid pattern in aggregation section [%s]: %s", section, err.Error())
+			}
+			current.pattern = pattern
+		case "aggregationMethod":
+			current.method = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if current != nil {
+		if err := validateAggregation(section, current); err != nil {
+			return err
+		}
+		aggregations = append(aggregations, *current)
+	}
+	storage.aggregations = aggregations
+	storage.aggregationsCache = make(map[string]string)
+	return nil
+}
+
+func validateAggregation(section string, aggregation *aggregationMatcher) error {
+	if aggregation.pattern == nil {
+		return fmt.Errorf("Aggregation section [%s] has no pattern", section)
+	}
+	if !aggregationMethods[aggregation.method] {
+		return fmt.Errorf("Aggregation section [%s] has unknown method '%s'", section, aggregation.method)
+	}
+	return nil
+}
+
+// getAggregationMethod returns method of values aggregation within retention interval,
+// metric not matching any rule keeps the last value
+func (storage *Storage) getAggregationMethod(metric string) string {
+	if method := storage.matchAggregationMethod(metric); method != "" {
+		return method
+	}
+	return LastAggregation
+}
+
+// getRollupMethod returns method of values aggregation into coarser archives,
+// metric not matching any rule is averaged as in graphite
+func (storage *Storage) getRollupMethod(metric string) string {
+	if method := storage.matchAggregationMethod(metric); method != "" {
+		return method
+	}
+	return AverageAggregation
+}
+
+// matchAggregationMethod returns method of the first aggregation rule matching metric or empty string
+func (storage *Storage) matchAggregationMethod(metric string) string {
+	if len(storage.aggregations) == 0 {
+		return ""
+	}
+	if method, ok := storage.aggregationsCache[metric]; ok {
+		return method
+	}
+	method := ""
+	for _, matcher := range storage.aggregations {
+		if matcher.pattern.MatchString(metric) {
+			method = matcher.method
+			break
+		}
+	}
+	storage.aggregationsCache[metric] = method
+	return method
+}
+
+// aggregateMatchedMetric adds value to aggregate of its retention interval buffered before.
+// Aggregate holds only values received since buffer was saved, it is merged with aggregate saved before on saving,
+// so values received by other filters or before restart are kept
+func aggregateMatchedMetric(buffered *moira.MatchedMetric, m *moira.MatchedMetric, method string) {
+	if buffered != nil {
+		m.Aggregate = buffered.Aggregate
+	} else {
+		m.Aggregate = &moira.MetricAggregate{Method: method}
+	}
+	m.Aggregate.Add(m.Timestamp, m.Value)
+}
+
+// aggregateBufferKey returns key of metric aggregate in buffer, so aggregates of different retention intervals are saved apart
+func aggregateBufferKey(metric string, retentionTimestamp int64) string {
+	return fmt.Sprintf("%s:%d", metric, retentionTimestamp)
+}
+
+// rollupMatchedMetric adds value to aggregates of coarser archives buffered before for the same metric.
+// Like aggregate of retention interval they hold only values received since buffer was saved
+func (storage *Storage) rollupMatchedMetric(buffered *moira.MatchedMetric, m *moira.MatchedMetric, archives []moira.MetricArchive) []*moira.MetricRollup {
+	method := storage.getRollupMethod(m.Metric)
+	var rollups []*moira.MetricRollup
+	if buffered != nil {
+		rollups = buffered.Rollups
+	}
+	for _, archive := range archives {
+		retentionTimestamp := roundToNearestRetention(m.Timestamp, archive.Precision)
+		rollup := findRollup(rollups, archive.Precision, retentionTimestamp)
+		if rollup == nil {
+			rollup = &moira.MetricRollup{
+				Archive:            archive,
+				RetentionTimestamp: retentionTimestamp,
+				Aggregate:          moira.MetricAggregate{Method: method},
+			}
+			rollups = append(rollups, rollup)
+		}
+		rollup.Aggregate.Add(m.Timestamp, m.Value)
+	}
+	return rollups
+}
+
+// findRollup returns rollup of archive with given precision into bucket with given timestamp or nil
+func findRollup(rollups []*moira.MetricRollup, precision int64, retentionTimestamp int64) *moira.MetricRollup {
+	for _, rollup := range rollups {
+		if rollup.Archive.Precision == precision && rollup.RetentionTimestamp == retentionTimestamp {
+			return rollup
+		}
+	}
+	return nil
+}
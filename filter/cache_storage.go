@@ -2,6 +2,7 @@ package filter
 
 import (
 	"bufio"
+	"fmt"
 	"github.com/moira-alert/moira"
 	"github.com/moira-alert/moira/metrics/graphite"
 	"io"
@@ -15,29 +16,33 @@ var defaultRetention = 60
 type retentionMatcher struct {
 	pattern   *regexp.Regexp
 	retention int
+	archives  []moira.MetricArchive
 }
 
 type retentionCacheItem struct {
-	value     int
+	value     []moira.MetricArchive
 	timestamp int64
 }
 
 // Storage struct to store retention matchers
 type Storage struct {
-	metrics         *graphite.FilterMetrics
-	retentions      []retentionMatcher
-	retentionsCache map[string]*retentionCacheItem
-	metricsCache    map[string]*moira.MatchedMetric
-	logger 					moira.Logger
+	metrics           *graphite.FilterMetrics
+	retentions        []retentionMatcher
+	retentionsCache   map[string]*retentionCacheItem
+	metricsCache      map[string]*moira.MatchedMetric
+	logger            moira.Logger
+	aggregations      []aggregationMatcher
+	aggregationsCache map[string]string
 }
 
 // NewCacheStorage create new Storage
 func NewCacheStorage(logger moira.Logger, metrics *graphite.FilterMetrics, reader io.Reader) (*Storage, error) {
 	storage := &Storage{
-		retentionsCache: make(map[string]*retentionCacheItem),
-		metricsCache:    make(map[string]*moira.MatchedMetric),
-		metrics:         metrics,
-		logger: 				 logger,
+		retentionsCache:   make(map[string]*retentionCacheItem),
+		metricsCache:      make(map[string]*moira.MatchedMetric),
+		metrics:           metrics,
+		logger:            logger,
+		aggregationsCache: make(map[string]string),
 	}
 
 	if err := storage.buildRetentions(bufio.NewScanner(reader)); err != nil {
@@ -46,32 +51,47 @@ func NewCacheStorage(logger moira.Logger, metrics *graphite.FilterMetrics, reade
 	return storage, nil
 }
 
-// EnrichMatchedMetric calculate retention and filter cached values
+// EnrichMatchedMetric calculate retention, aggregate values into retention interval and coarser archives and filter cached values
 func (storage *Storage) EnrichMatchedMetric(buffer map[string]*moira.MatchedMetric, m *moira.MatchedMetric) {
-	m.Retention = storage.getRetention(m)
+	m.Archives = storage.getArchives(m)
+	m.Retention = int(m.Archives[0].Precision)
 	m.RetentionTimestamp = roundToNearestRetention(m.Timestamp, int64(m.Retention))
-	if ex, ok := storage.metricsCache[m.Metric]; ok && ex.RetentionTimestamp == m.RetentionTimestamp && ex.Value == m.Value {
-		return
+	method := storage.getAggregationMethod(m.Metric)
+	key := m.Metric
+	if method == LastAggregation {
+		if ex, ok := storage.metricsCache[m.Metric]; ok && ex.RetentionTimestamp == m.RetentionTimestamp && ex.Value == m.Value {
+			return
+		}
+	} else {
+		key = aggregateBufferKey(m.Metric, m.RetentionTimestamp)
+	}
+	buffered := buffer[key]
+	if len(m.Archives) > 1 {
+		m.Rollups = storage.rollupMatchedMetric(buffered, m, m.Archives[1:])
+	}
+	if method != LastAggregation {
+		aggregateMatchedMetric(buffered, m, method)
+	} else {
+		storage.metricsCache[m.Metric] = m
 	}
-	storage.metricsCache[m.Metric] = m
-	buffer[m.Metric] = m
+	buffer[key] = m
 }
 
-// getRetention returns first matched retention for metric
-func (storage *Storage) getRetention(m *moira.MatchedMetric) int {
+// getArchives returns archives of first matched retention for metric
+func (storage *Storage) getArchives(m *moira.MatchedMetric) []moira.MetricArchive {
 	if item, ok := storage.retentionsCache[m.Metric]; ok && item.timestamp+60 > m.Timestamp {
 		return item.value
 	}
 	for _, matcher := range storage.retentions {
 		if matcher.pattern.MatchString(m.Metric) {
 			storage.retentionsCache[m.Metric] = &retentionCacheItem{
-				value:     matcher.retention,
+				value:     matcher.archives,
 				timestamp: m.Timestamp,
 			}
-			return matcher.retention
+			return matcher.archives
 		}
 	}
-	return defaultRetention
+	return []moira.MetricArchive{{Precision: int64(defaultRetention)}}
 }
 
 func (storage *Storage) buildRetentions(retentionScanner *bufio.Scanner) error {
@@ -93,25 +113,49 @@ func (storage *Storage) buildRetentions(retentionScanner *bufio.Scanner) error {
 		line2 := retentionScanner.Text()
 		splitted := strings.Split(line2, "=")
 
-		if len(splitted) < 2{
+		if len(splitted) < 2 {
 			storage.logger.Errorf("Invalid pattern found: '%s'", patternString)
 			continue
 		}
 
-		retentions := strings.TrimSpace(splitted[1])
-		retention, err := rawRetentionToSeconds(retentions[0:strings.Index(retentions, ":")])
+		archives, err := parseArchives(strings.TrimSpace(splitted[1]))
 		if err != nil {
 			return err
 		}
 
 		storage.retentions = append(storage.retentions, retentionMatcher{
 			pattern:   pattern,
-			retention: retention,
+			retention: int(archives[0].Precision),
+			archives:  archives,
 		})
 	}
 	return retentionScanner.Err()
 }
 
+// parseArchives parses retentions line like "10s:1d,1m:30d" into archives from the finest to the coarsest one
+func parseArchives(retentions string) ([]moira.MetricArchive, error) {
+	archives := make([]moira.MetricArchive, 0)
+	for _, rawArchive := range strings.Split(retentions, ",") {
+		precisionDuration := strings.Split(strings.TrimSpace(rawArchive), ":")
+		if len(precisionDuration) != 2 {
+			return nil, fmt.Errorf("Invalid archive '%s'", rawArchive)
+		}
+		precision, err := rawRetentionToSeconds(precisionDuration[0])
+		if err != nil {
+			return nil, err
+		}
+		duration, err := rawRetentionToSeconds(precisionDuration[1])
+		if err != nil {
+			return nil, err
+		}
+		if precision <= 0 {
+			return nil, fmt.Errorf("Invalid archive '%s': precision must be positive", rawArchive)
+		}
+		archives = append(archives, moira.MetricArchive{Precision: int64(precision), Duration: int64(duration)})
+	}
+	return archives, nil
+}
+
 func rawRetentionToSeconds(rawRetention string) (int, error) {
 	retention, err := strconv.Atoi(rawRetention)
 	if err == nil {
@@ -230,3 +230,132 @@ func TestRetentions(t *testing.T) {
 		So(metr.RetentionTimestamp, should.Equal, 120)
 	})
 }
+
+var testAggregations = `
+	# comment
+	[min]
+	pattern = \.min$
+	xFilesFactor = 0
+	aggregationMethod = min
+
+	[sum]
+	pattern = \.count$
+	aggregationMethod = sum
+
+	[average]
+	pattern = ^Avg\.
+	`
+
+func TestLoadAggregations(t *testing.T) {
+	metrics2 := metrics.ConfigureFilterMetrics("test")
+	storage, _ := NewCacheStorage(nil, metrics2, strings.NewReader(testRetentions))
+
+	Convey("Test good aggregations", t, func() {
+		So(storage.LoadAggregations(strings.NewReader(testAggregations)), ShouldBeNil)
+		So(storage.aggregations, ShouldHaveLength, 3)
+		So(storage.getAggregationMethod("Simple.cpu.min"), ShouldEqual, MinAggregation)
+		So(storage.getAggregationMethod("Simple.requests.count"), ShouldEqual, SumAggregation)
+		So(storage.getAggregationMethod("Avg.cpu.count"), ShouldEqual, SumAggregation)
+		So(storage.getAggregationMethod("Avg.cpu"), ShouldEqual, AverageAggregation)
+		So(storage.getAggregationMethod("Simple.cpu"), ShouldEqual, LastAggregation)
+	})
+
+	Convey("Test bad aggregations", t, func() {
+		for _, config := range []string{"pattern = a", "[a]\naggregationMethod = sum", "[a]\npattern = [a", "[a]\npattern = a\naggregationMethod = median"} {
+			So(storage.LoadAggregations(strings.NewReader(config)), ShouldNotBeNil)
+		}
+	})
+}
+
+func TestAggregateMatchedMetrics(t *testing.T) {
+	metrics2 := metrics.ConfigureFilterMetrics("test")
+	storage, _ := NewCacheStorage(nil, metrics2, strings.NewReader(testRetentions))
+	storage.LoadAggregations(strings.NewReader(testAggregations))
+
+	enrich := func(buffer map[string]*moira.MatchedMetric, metric string, timestamp int64, value float64) {
+		storage.EnrichMatchedMetric(buffer, &moira.MatchedMetric{Metric: metric, Patterns: []string{metric}, Timestamp: timestamp, Value: value})
+	}
+
+	Convey("Values within one retention interval should be aggregated", t, func() {
+		buffer := make(map[string]*moira.MatchedMetric)
+		enrich(buffer, "Simple.requests.count", 50, 1)
+		enrich(buffer, "Simple.requests.count", 61, 2)
+		enrich(buffer, "Simple.cpu.min", 61, 5)
+		enrich(buffer, "Simple.cpu.min", 55, 3)
+		enrich(buffer, "Avg.cpu", 61, 1)
+		enrich(buffer, "Avg.cpu", 62, 2)
+		So(buffer, ShouldHaveLength, 3)
+		So(buffer[aggregateBufferKey("Simple.requests.count", 60)].Aggregate, ShouldResemble, &moira.MetricAggregate{Method: SumAggregation, Count: 2, Sum: 3, Min: 1, Max: 2, Timestamp: 61, Last: 2})
+		So(buffer[aggregateBufferKey("Simple.requests.count", 60)].RetentionTimestamp, ShouldEqual, 60)
+		So(buffer[aggregateBufferKey("Simple.cpu.min", 60)].Aggregate, ShouldResemble, &moira.MetricAggregate{Method: MinAggregation, Count: 2, Sum: 8, Min: 3, Max: 5, Timestamp: 61, Last: 5})
+		So(buffer[aggregateBufferKey("Avg.cpu", 60)].Aggregate, ShouldResemble, &moira.MetricAggregate{Method: AverageAggregation, Count: 2, Sum: 3, Min: 1, Max: 2, Timestamp: 62, Last: 2})
+	})
+
+	Convey("Aggregate should hold only values received after buffer is saved", t, func() {
+		buffer := make(map[string]*moira.MatchedMetric)
+		enrich(buffer, "Simple.requests.count", 70, 4)
+		So(buffer[aggregateBufferKey("Simple.requests.count", 60)].Aggregate, ShouldResemble, &moira.MetricAggregate{Method: SumAggregation, Count: 1, Sum: 4, Min: 4, Max: 4, Timestamp: 70, Last: 4})
+	})
+
+	Convey("Values of different retention intervals should be aggregated apart", t, func() {
+		buffer := make(map[string]*moira.MatchedMetric)
+		enrich(buffer, "Simple.requests.count", 120, 10)
+		enrich(buffer, "Simple.requests.count", 65, 100)
+		So(buffer, ShouldHaveLength, 2)
+		So(buffer[aggregateBufferKey("Simple.requests.count", 120)].Aggregate.Sum, ShouldEqual, 10)
+		So(buffer[aggregateBufferKey("Simple.requests.count", 60)].Aggregate.Sum, ShouldEqual, 100)
+	})
+
+	Convey("Metric without aggregation rule should be saved as is", t, func() {
+		buffer := make(map[string]*moira.MatchedMetric)
+		enrich(buffer, "Simple.cpu", 61, 1)
+		So(buffer["Simple.cpu"].Value, ShouldEqual, 1)
+		So(buffer["Simple.cpu"].Aggregate, ShouldBeNil)
+	})
+}
+
+func TestArchives(t *testing.T) {
+	metrics2 := metrics.ConfigureFilterMetrics("test")
+	storage, _ := NewCacheStorage(nil, metrics2, strings.NewReader(testRetentions))
+
+	Convey("Test all archives are parsed", t, func() {
+		So(storage.retentions[0].archives, ShouldResemble, []moira.MetricArchive{
+			{Precision: 60, Duration: 2 * 86400},
+			{Precision: 600, Duration: 30 * 86400},
+			{Precision: 6000, Duration: 90 * 86400},
+		})
+		So(storage.retentions[6].archives, ShouldResemble, []moira.MetricArchive{{Precision: 120, Duration: 7 * 86400}})
+	})
+
+	Convey("Test bad archives", t, func() {
+		for _, retentions := range []string{"60s", "60s:1d,", "0:1d", "a:1d"} {
+			_, err := NewCacheStorage(nil, metrics2, strings.NewReader("[a]\npattern = a\nretentions = "+retentions))
+			So(err, ShouldNotBeNil)
+		}
+	})
+
+	Convey("Values should be rolled up into coarser archives", t, func() {
+		buffer := make(map[string]*moira.MatchedMetric)
+		for i, value := range []float64{1, 2, 3} {
+			storage.EnrichMatchedMetric(buffer, &moira.MatchedMetric{Metric: "Simple.cpu", Timestamp: int64(1200 + 60*i), Value: value})
+		}
+		saved := buffer["Simple.cpu"]
+		So(saved.Value, ShouldEqual, 3)
+		So(saved.Archives, ShouldHaveLength, 3)
+		So(saved.Rollups, ShouldResemble, []*moira.MetricRollup{
+			{Archive: saved.Archives[1], RetentionTimestamp: 1200, Aggregate: moira.MetricAggregate{Method: AverageAggregation, Count: 3, Sum: 6, Min: 1, Max: 3, Timestamp: 1320, Last: 3}},
+			{Archive: saved.Archives[2], RetentionTimestamp: 0, Aggregate: moira.MetricAggregate{Method: AverageAggregation, Count: 3, Sum: 6, Min: 1, Max: 3, Timestamp: 1320, Last: 3}},
+		})
+	})
+
+	Convey("Rollups should use aggregation method of metric", t, func() {
+		storage.LoadAggregations(strings.NewReader("[max]\npattern = max$\naggregationMethod = max"))
+		buffer := make(map[string]*moira.MatchedMetric)
+		for i, value := range []float64{5, 7, 6} {
+			storage.EnrichMatchedMetric(buffer, &moira.MatchedMetric{Metric: "Simple.cpu.max", Timestamp: int64(1200 + 60*i), Value: value})
+		}
+		So(buffer, ShouldHaveLength, 3)
+		So(buffer[aggregateBufferKey("Simple.cpu.max", 1260)].Rollups[0].Aggregate, ShouldResemble, moira.MetricAggregate{Method: MaxAggregation, Count: 1, Sum: 7, Min: 7, Max: 7, Timestamp: 1260, Last: 7})
+		So(buffer[aggregateBufferKey("Simple.cpu.max", 1320)].Rollups[0].Aggregate.Max, ShouldEqual, 6)
+	})
+}
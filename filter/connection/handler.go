@@ -14,15 +14,17 @@ import (
 type Handler struct {
 	logger          moira.Logger
 	patternsStorage *filter.PatternStorage
+	sources         *Sources
 	wg              sync.WaitGroup
 	terminate       chan bool
 }
 
 // NewConnectionsHandler creates new Handler
-func NewConnectionsHandler(logger moira.Logger, patternsStorage *filter.PatternStorage) *Handler {
+func NewConnectionsHandler(logger moira.Logger, patternsStorage *filter.PatternStorage, sources *Sources) *Handler {
 	return &Handler{
 		logger:          logger,
 		patternsStorage: patternsStorage,
+		sources:         sources,
 		terminate:       make(chan bool, 1),
 	}
 }
@@ -38,6 +40,7 @@ func (handler *Handler) HandleConnection(connection net.Conn, matchedMetricsChan
 
 func (handler *Handler) handle(connection net.Conn, matchedMetricsChan chan *moira.MatchedMetric) {
 	buffer := bufio.NewReader(connection)
+	src := handler.sources.get(connection.RemoteAddr().String())
 
 	go func(conn net.Conn) {
 		<-handler.terminate
@@ -57,7 +60,7 @@ func (handler *Handler) handle(connection net.Conn, matchedMetricsChan chan *moi
 		handler.wg.Add(1)
 		func(ch chan *moira.MatchedMetric) {
 			defer handler.wg.Done()
-			if m := handler.patternsStorage.ProcessIncomingMetric(lineBytes); m != nil {
+			if m := src.processLine(handler.patternsStorage, lineBytes); m != nil {
 				ch <- m
 			}
 		}(matchedMetricsChan)
@@ -0,0 +1,70 @@
+package connection
+
+import (
+	"time"
+
+	"gopkg.in/tomb.v2"
+
+	"github.com/moira-alert/moira"
+	"github.com/moira-alert/moira/metrics/graphite"
+)
+
+// Listener accepts metrics data from some transport and shifts matched metrics to given channel
+type Listener interface {
+	Listen(metricsChan chan *moira.MatchedMetric)
+	Stop() error
+}
+
+// Listeners runs all configured metrics listeners that feed the single matched metrics channel
+type Listeners struct {
+	listeners   []Listener
+	logger      moira.Logger
+	metrics     *graphite.FilterMetrics
+	metricsChan chan *moira.MatchedMetric
+	tomb        tomb.Tomb
+}
+
+// NewListeners creates new Listeners
+func NewListeners(logger moira.Logger, metrics *graphite.FilterMetrics, listeners ...Listener) *Listeners {
+	return &Listeners{
+		listeners: listeners,
+		logger:    logger,
+		metrics:   metrics,
+	}
+}
+
+// Listen starts all listeners and returns channel with matched metrics
+func (listeners *Listeners) Listen() chan *moira.MatchedMetric {
+	listeners.metricsChan = make(chan *moira.MatchedMetric, 16384)
+	for _, listener := range listeners.listeners {
+		listener.Listen(listeners.metricsChan)
+	}
+	listeners.tomb.Go(listeners.checkNewMetricsChannelLen)
+	return listeners.metricsChan
+}
+
+func (listeners *Listeners) checkNewMetricsChannelLen() error {
+	checkTicker := time.NewTicker(time.Millisecond * 100)
+	for {
+		select {
+		case <-listeners.tomb.Dying():
+			return nil
+		case <-checkTicker.C:
+			listeners.metrics.MetricChannelLen.Update(int64(len(listeners.metricsChan)))
+		}
+	}
+}
+
+// Stop stops all listeners and closes matched metrics channel after all of them handled remaining data
+func (listeners *Listeners) Stop() error {
+	var stopErr error
+	for _, listener := range listeners.listeners {
+		if err := listener.Stop(); err != nil {
+			stopErr = err
+		}
+	}
+	listeners.tomb.Kill(nil)
+	listeners.tomb.Wait()
+	close(listeners.metricsChan)
+	return stopErr
+}
@@ -9,20 +9,34 @@ import (
 
 	"github.com/moira-alert/moira"
 	"github.com/moira-alert/moira/filter"
-	"github.com/moira-alert/moira/metrics/graphite"
 )
 
+// connectionHandler handles accepted connections using specified wire protocol
+type connectionHandler interface {
+	HandleConnection(connection net.Conn, matchedMetricsChan chan *moira.MatchedMetric)
+	StopHandlingConnections()
+}
+
 // MetricsListener is facade for standard net.MetricsListener and accept connection for handling it
 type MetricsListener struct {
 	listener *net.TCPListener
-	handler  *Handler
+	handler  connectionHandler
+	sources  *Sources
 	logger   moira.Logger
 	tomb     tomb.Tomb
-	metrics  *graphite.FilterMetrics
 }
 
-// NewListener creates new listener
-func NewListener(port string, logger moira.Logger, metrics *graphite.FilterMetrics, patternStorage *filter.PatternStorage) (*MetricsListener, error) {
+// NewListener creates new listener for plaintext protocol
+func NewListener(port string, logger moira.Logger, patternStorage *filter.PatternStorage, sources *Sources) (*MetricsListener, error) {
+	return newTCPListener(port, logger, sources, NewConnectionsHandler(logger, patternStorage, sources))
+}
+
+// NewPickleListener creates new listener for carbon pickle protocol
+func NewPickleListener(port string, logger moira.Logger, patternStorage *filter.PatternStorage, sources *Sources) (*MetricsListener, error) {
+	return newTCPListener(port, logger, sources, NewPickleConnectionsHandler(logger, patternStorage, sources))
+}
+
+func newTCPListener(port string, logger moira.Logger, sources *Sources, handler connectionHandler) (*MetricsListener, error) {
 	address, err := net.ResolveTCPAddr("tcp", port)
 	if nil != err {
 		return nil, fmt.Errorf("Failed to resolve tcp address [%s]: %s", port, err.Error())
@@ -34,26 +48,24 @@ func NewListener(port string, logger moira.Logger, metrics *graphite.FilterMetri
 	listener := MetricsListener{
 		listener: newListener,
 		logger:   logger,
-		handler:  NewConnectionsHandler(logger, patternStorage),
-		metrics: 	metrics,
+		handler:  handler,
+		sources:  sources,
 	}
 	return &listener, nil
 }
 
 // Listen waits for new data in connection and handles it in ConnectionHandler
 // All handled data sets to metricsChan
-func (listener *MetricsListener) Listen() chan *moira.MatchedMetric {
-	metricsChan := make(chan *moira.MatchedMetric, 16384)
+func (listener *MetricsListener) Listen(metricsChan chan *moira.MatchedMetric) {
 	listener.tomb.Go(func() error {
 		for {
 			select {
 			case <-listener.tomb.Dying():
 				{
-					listener.logger.Info("Stopping listener...")
+					listener.logger.Infof("Stopping listener [%s]...", listener.listener.Addr())
 					listener.listener.Close()
 					listener.handler.StopHandlingConnections()
-					close(metricsChan)
-					listener.logger.Info("Moira Filter Listener stopped")
+					listener.logger.Infof("Moira Filter Listener [%s] stopped", listener.listener.Addr())
 					return nil
 				}
 			default:
@@ -67,26 +79,15 @@ func (listener *MetricsListener) Listen() chan *moira.MatchedMetric {
 				listener.logger.Infof("Failed to accept connection: %s", err.Error())
 				continue
 			}
+			conn, accepted := listener.sources.acceptConnection(conn)
+			if !accepted {
+				continue
+			}
 			listener.logger.Infof("%s connected", conn.RemoteAddr())
 			listener.handler.HandleConnection(conn, metricsChan)
 		}
 	})
-
-	listener.tomb.Go(func() error { return listener.checkNewMetricsChannelLen(metricsChan) })
-	listener.logger.Info("Moira Filter Listener Started")
-	return metricsChan
-}
-
-func (listener *MetricsListener) checkNewMetricsChannelLen(channel <-chan *moira.MatchedMetric) error {
-	checkTicker := time.NewTicker(time.Millisecond * 100)
-	for {
-		select {
-		case <-listener.tomb.Dying():
-			return nil
-		case <-checkTicker.C:
-			listener.metrics.MetricChannelLen.Update(int64(len(channel)))
-		}
-	}
+	listener.logger.Infof("Moira Filter Listener [%s] Started", listener.listener.Addr())
 }
 
 // Stop stops listening connection
@@ -0,0 +1,180 @@
+package connection
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"reflect"
+	"strconv"
+	"sync"
+
+	pickle "github.com/lomik/og-rek"
+
+	"github.com/moira-alert/moira"
+	"github.com/moira-alert/moira/filter"
+)
+
+// maxPickleMessageSize is the same limit carbon-cache uses for pickle receiver
+const maxPickleMessageSize = 1 << 20
+
+// PickleHandler handling carbon pickle protocol connection data and shift it to MatchedMetrics channel
+type PickleHandler struct {
+	logger          moira.Logger
+	patternsStorage *filter.PatternStorage
+	sources         *Sources
+	wg              sync.WaitGroup
+	terminate       chan bool
+}
+
+// NewPickleConnectionsHandler creates new PickleHandler
+func NewPickleConnectionsHandler(logger moira.Logger, patternsStorage *filter.PatternStorage, sources *Sources) *PickleHandler {
+	return &PickleHandler{
+		logger:          logger,
+		patternsStorage: patternsStorage,
+		sources:         sources,
+		terminate:       make(chan bool, 1),
+	}
+}
+
+// HandleConnection reads every length-prefixed pickle message from connection,
+// converts it to metrics and sends them to MatchedMetric channel
+func (handler *PickleHandler) HandleConnection(connection net.Conn, matchedMetricsChan chan *moira.MatchedMetric) {
+	handler.wg.Add(1)
+	go func() {
+		defer handler.wg.Done()
+		handler.handle(connection, matchedMetricsChan)
+	}()
+}
+
+func (handler *PickleHandler) handle(connection net.Conn, matchedMetricsChan chan *moira.MatchedMetric) {
+	buffer := bufio.NewReader(connection)
+	src := handler.sources.get(connection.RemoteAddr().String())
+
+	go func(conn net.Conn) {
+		<-handler.terminate
+		conn.Close()
+	}(connection)
+
+	defer connection.Close()
+	for {
+		message, err := readPickleMessage(buffer)
+		if err != nil {
+			if err != io.EOF {
+				handler.logger.Errorf("read failed: %s", err)
+			}
+			return
+		}
+		lines, err := parsePickleMessage(message)
+		if err != nil {
+			handler.logger.Infof("cannot parse pickle input: %s", err)
+			continue
+		}
+		for _, lineBytes := range lines {
+			if m := src.processLine(handler.patternsStorage, lineBytes); m != nil {
+				matchedMetricsChan <- m
+			}
+		}
+	}
+}
+
+// StopHandlingConnections closes all open connections and wait for handling ramaining metrics
+func (handler *PickleHandler) StopHandlingConnections() {
+	close(handler.terminate)
+	handler.wg.Wait()
+}
+
+func readPickleMessage(reader io.Reader) ([]byte, error) {
+	var size uint32
+	if err := binary.Read(reader, binary.BigEndian, &size); err != nil {
+		return nil, err
+	}
+	if size > maxPickleMessageSize {
+		return nil, fmt.Errorf("pickle message size %d exceeds limit %d", size, maxPickleMessageSize)
+	}
+	message := make([]byte, size)
+	if _, err := io.ReadFull(reader, message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// parsePickleMessage decodes pickled list of (path, (timestamp, value)) tuples
+// and returns every metric as plaintext protocol line "<path> <value> <timestamp>"
+func parsePickleMessage(message []byte) ([][]byte, error) {
+	decoded, err := pickle.NewDecoder(bytes.NewReader(message)).Decode()
+	if err != nil {
+		return nil, err
+	}
+	metrics, ok := toSlice(decoded)
+	if !ok {
+		return nil, fmt.Errorf("unexpected pickle message type %T, list expected", decoded)
+	}
+	lines := make([][]byte, 0, len(metrics))
+	for _, rawMetric := range metrics {
+		metric, ok := toSlice(rawMetric)
+		if !ok || len(metric) != 2 {
+			return nil, fmt.Errorf("unexpected metric item %v, (path, (timestamp, value)) expected", rawMetric)
+		}
+		datapoint, ok := toSlice(metric[1])
+		if !ok || len(datapoint) != 2 {
+			return nil, fmt.Errorf("unexpected datapoint %v, (timestamp, value) expected", metric[1])
+		}
+		path, ok := pickleString(metric[0])
+		if !ok {
+			return nil, fmt.Errorf("unexpected metric path %v", metric[0])
+		}
+		timestamp, ok := pickleNumber(datapoint[0])
+		if !ok {
+			return nil, fmt.Errorf("unexpected timestamp %v of metric %s", datapoint[0], path)
+		}
+		value, ok := pickleNumber(datapoint[1])
+		if !ok {
+			return nil, fmt.Errorf("unexpected value %v of metric %s", datapoint[1], path)
+		}
+		lines = append(lines, []byte(fmt.Sprintf("%s %s %s", path, value, timestamp)))
+	}
+	return lines, nil
+}
+
+// toSlice converts decoded pickle list or tuple to slice
+func toSlice(value interface{}) ([]interface{}, bool) {
+	reflectValue := reflect.ValueOf(value)
+	if reflectValue.Kind() != reflect.Slice || reflectValue.Type().Elem().Kind() != reflect.Interface {
+		return nil, false
+	}
+	result := make([]interface{}, reflectValue.Len())
+	for i := range result {
+		result[i] = reflectValue.Index(i).Interface()
+	}
+	return result, true
+}
+
+func pickleString(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case []byte:
+		return string(v), true
+	}
+	return "", false
+}
+
+func pickleNumber(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case int:
+		return strconv.Itoa(v), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case *big.Int:
+		return v.String(), true
+	case string:
+		return v, true
+	}
+	return "", false
+}
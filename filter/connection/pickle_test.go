@@ -0,0 +1,55 @@
+package connection
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// pickle.dumps([('One.two.three', (1234567890, 123.5)), ('One.two.four', (1234567890.5, 7))], protocol=2)
+var validPickleMessage = []byte("\x80\x02]q\x00(X\r\x00\x00\x00One.two.threeq\x01J\xd2\x02\x96IG@^\xe0\x00\x00\x00\x00\x00\x86q\x02\x86q\x03X\x0c\x00\x00\x00One.two.fourq\x04GA\xd2e\x80\xb4\xa0\x00\x00K\x07\x86q\x05\x86q\x06e.")
+
+func TestParsePickleMessage(t *testing.T) {
+	Convey("Given valid pickle message, should return plaintext lines", t, func() {
+		lines, err := parsePickleMessage(validPickleMessage)
+		So(err, ShouldBeNil)
+		So(lines, ShouldResemble, [][]byte{
+			[]byte("One.two.three 123.5 1234567890"),
+			[]byte("One.two.four 7 1234567890.5"),
+		})
+	})
+
+	Convey("Given pickled string instead of list, should return error", t, func() {
+		// pickle.dumps('One.two.three', protocol=2)
+		lines, err := parsePickleMessage([]byte("\x80\x02X\r\x00\x00\x00One.two.threeq\x00."))
+		So(err, ShouldNotBeNil)
+		So(lines, ShouldBeNil)
+	})
+
+	Convey("Given broken pickle message, should return error", t, func() {
+		lines, err := parsePickleMessage(validPickleMessage[:20])
+		So(err, ShouldNotBeNil)
+		So(lines, ShouldBeNil)
+	})
+}
+
+func TestReadPickleMessage(t *testing.T) {
+	Convey("Given length-prefixed message, should read it", t, func() {
+		buffer := &bytes.Buffer{}
+		binary.Write(buffer, binary.BigEndian, uint32(len(validPickleMessage)))
+		buffer.Write(validPickleMessage)
+		message, err := readPickleMessage(buffer)
+		So(err, ShouldBeNil)
+		So(message, ShouldResemble, validPickleMessage)
+	})
+
+	Convey("Given too large message size, should return error", t, func() {
+		buffer := &bytes.Buffer{}
+		binary.Write(buffer, binary.BigEndian, uint32(maxPickleMessageSize+1))
+		message, err := readPickleMessage(buffer)
+		So(err, ShouldNotBeNil)
+		So(message, ShouldBeNil)
+	})
+}
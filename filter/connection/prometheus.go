@@ -0,0 +1,122 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/moira-alert/moira"
+	"github.com/moira-alert/moira/filter"
+	"github.com/moira-alert/moira/filter/prometheus"
+)
+
+// PrometheusWritePath is the path of Prometheus remote-write receiver endpoint
+const PrometheusWritePath = "/api/v1/write"
+
+// prometheusMaxRequestSize limits compressed remote-write request body
+const prometheusMaxRequestSize = 32 * 1024 * 1024
+
+// PrometheusListener receives Prometheus remote-write requests over HTTP and handles every sample of them
+type PrometheusListener struct {
+	listener        net.Listener
+	server          *http.Server
+	patternsStorage *filter.PatternStorage
+	sources         *Sources
+	naming          string
+	logger          moira.Logger
+	done            chan struct{}
+}
+
+// NewPrometheusListener creates new Prometheus remote-write receiver.
+// naming is one of prometheus.TaggedNaming or prometheus.GraphiteNaming
+func NewPrometheusListener(port string, naming string, logger moira.Logger, patternStorage *filter.PatternStorage, sources *Sources) (*PrometheusListener, error) {
+	if naming != prometheus.TaggedNaming && naming != prometheus.GraphiteNaming {
+		return nil, fmt.Errorf("Unknown prometheus naming [%s], use '%s' or '%s'", naming, prometheus.TaggedNaming, prometheus.GraphiteNaming)
+	}
+	listener, err := net.Listen("tcp", port)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to listen on [%s]: %s", port, err.Error())
+	}
+	return &PrometheusListener{
+		listener:        listener,
+		patternsStorage: patternStorage,
+		sources:         sources,
+		naming:          naming,
+		logger:          logger,
+		done:            make(chan struct{}),
+	}, nil
+}
+
+// Listen serves remote-write requests and sends matched metrics to metricsChan
+func (listener *PrometheusListener) Listen(metricsChan chan *moira.MatchedMetric) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(PrometheusWritePath, func(writer http.ResponseWriter, request *http.Request) {
+		listener.handleWrite(writer, request, metricsChan)
+	})
+	listener.server = &http.Server{Handler: mux}
+	go func() {
+		defer close(listener.done)
+		if err := listener.server.Serve(listener.listener); err != nil && err != http.ErrServerClosed {
+			listener.logger.Errorf("Prometheus remote-write receiver failed: %s", err.Error())
+		}
+	}()
+	listener.logger.Infof("Moira Filter Prometheus Listener [%s] Started", listener.listener.Addr())
+}
+
+func (listener *PrometheusListener) handleWrite(writer http.ResponseWriter, request *http.Request, metricsChan chan *moira.MatchedMetric) {
+	if request.Method != http.MethodPost {
+		http.Error(writer, "only POST is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(writer, request.Body, prometheusMaxRequestSize))
+	if err != nil {
+		// MaxBytesReader fails only after the whole limit has been read
+		if len(body) >= prometheusMaxRequestSize {
+			http.Error(writer, err.Error(), http.StatusRequestEntityTooLarge)
+		} else {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+	timeSeries, err := prometheus.DecodeWriteRequest(body)
+	if err != nil {
+		listener.logger.Infof("cannot parse prometheus remote-write request: %s", err.Error())
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+	src := listener.sources.get(request.RemoteAddr)
+	for i := range timeSeries {
+		metric, err := timeSeries[i].MetricName(listener.naming)
+		if err != nil {
+			listener.logger.Infof("cannot convert prometheus time series: %s", err.Error())
+			src.account(nil, err)
+			continue
+		}
+		for _, sample := range timeSeries[i].Samples {
+			// Prometheus sends NaN values as staleness markers
+			if math.IsNaN(sample.Value) || !src.allow() {
+				continue
+			}
+			m := listener.patternsStorage.ProcessParsedMetric(metric, sample.Value, sample.Timestamp/1000)
+			src.account(m, nil)
+			if m != nil {
+				metricsChan <- m
+			}
+		}
+	}
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// Stop gracefully shuts down HTTP server waiting for handling requests in progress
+func (listener *PrometheusListener) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := listener.server.Shutdown(ctx)
+	<-listener.done
+	listener.logger.Infof("Moira Filter Prometheus Listener [%s] stopped", listener.listener.Addr())
+	return err
+}
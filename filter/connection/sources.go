@@ -0,0 +1,180 @@
+package connection
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/moira-alert/moira"
+	"github.com/moira-alert/moira/filter"
+	"github.com/moira-alert/moira/metrics/graphite"
+)
+
+// SourceLimits contains limits applied to metrics senders. Zero value of any limit means unlimited
+type SourceLimits struct {
+	MaxConnections int   // Max number of simultaneous connections over all TCP listeners
+	LinesPerSecond int64 // Max number of lines per second accepted from single remote address
+}
+
+// sourceIdleTimeout is time after the last line since which remote address statistics are forgotten
+const sourceIdleTimeout = 10 * time.Minute
+
+// Sources accounts lines received from every remote address and applies SourceLimits to them.
+// Excess connections are closed and excess lines are dropped, so one sender can not block others
+type Sources struct {
+	limits       SourceLimits
+	metrics      *graphite.FilterMetrics
+	logger       moira.Logger
+	connections  int64
+	sources      map[string]*source
+	lastEviction time.Time
+	lock         sync.Mutex
+}
+
+// source is single remote address statistics and rate limiter state
+type source struct {
+	received   graphite.Meter
+	invalid    graphite.Meter
+	matching   graphite.Meter
+	dropped    graphite.Meter
+	lastSeen   int64
+	limit      int64
+	tokens     float64
+	lastRefill time.Time
+	lock       sync.Mutex
+}
+
+// NewSources creates new Sources
+func NewSources(limits SourceLimits, metrics *graphite.FilterMetrics, logger moira.Logger) *Sources {
+	return &Sources{
+		limits:       limits,
+		metrics:      metrics,
+		logger:       logger,
+		sources:      make(map[string]*source),
+		lastEviction: time.Now(),
+	}
+}
+
+// acceptConnection returns connection which releases its slot on close
+// or false if connections limit is reached and connection was closed
+func (sources *Sources) acceptConnection(connection net.Conn) (net.Conn, bool) {
+	connections := atomic.AddInt64(&sources.connections, 1)
+	if sources.limits.MaxConnections > 0 && connections > int64(sources.limits.MaxConnections) {
+		atomic.AddInt64(&sources.connections, -1)
+		sources.metrics.ConnectionsRejected.Mark(1)
+		sources.logger.Warningf("%s rejected: max connections limit %d is reached", connection.RemoteAddr(), sources.limits.MaxConnections)
+		connection.Close()
+		return nil, false
+	}
+	sources.metrics.ConnectionsActive.Inc(1)
+	return &sourceConnection{Conn: connection, sources: sources}, true
+}
+
+func (sources *Sources) releaseConnection() {
+	atomic.AddInt64(&sources.connections, -1)
+	sources.metrics.ConnectionsActive.Dec(1)
+}
+
+// get returns statistics of remote address host, ports are ignored
+func (sources *Sources) get(address string) *source {
+	host := address
+	if h, _, err := net.SplitHostPort(address); err == nil {
+		host = h
+	}
+	sources.lock.Lock()
+	defer sources.lock.Unlock()
+	now := time.Now()
+	if now.Sub(sources.lastEviction) >= sourceIdleTimeout {
+		sources.evictIdle(now)
+	}
+	if src, ok := sources.sources[host]; ok {
+		return src
+	}
+	graphitePath := strings.NewReplacer(".", "_", ":", "_").Replace(host)
+	src := &source{
+		received:   sources.metrics.SourceLinesReceived.GetOrAdd(host, graphitePath),
+		invalid:    sources.metrics.SourceLinesInvalid.GetOrAdd(host, graphitePath),
+		matching:   sources.metrics.SourceLinesMatching.GetOrAdd(host, graphitePath),
+		dropped:    sources.metrics.SourceLinesDropped.GetOrAdd(host, graphitePath),
+		limit:      sources.limits.LinesPerSecond,
+		tokens:     float64(sources.limits.LinesPerSecond),
+		lastSeen:   now.Unix(),
+		lastRefill: now,
+	}
+	sources.sources[host] = src
+	return src
+}
+
+// evictIdle forgets hosts which sent nothing for sourceIdleTimeout and unregisters their meters.
+// Connection still holding evicted source keeps working, but its lines are not reported anymore
+func (sources *Sources) evictIdle(now time.Time) {
+	sources.lastEviction = now
+	idleSince := now.Add(-sourceIdleTimeout).Unix()
+	for host, src := range sources.sources {
+		if atomic.LoadInt64(&src.lastSeen) >= idleSince {
+			continue
+		}
+		delete(sources.sources, host)
+		sources.metrics.SourceLinesReceived.Remove(host)
+		sources.metrics.SourceLinesInvalid.Remove(host)
+		sources.metrics.SourceLinesMatching.Remove(host)
+		sources.metrics.SourceLinesDropped.Remove(host)
+	}
+}
+
+// processLine accounts plaintext line received from source and matches it unless source exceeded its rate limit
+func (src *source) processLine(patternsStorage *filter.PatternStorage, lineBytes []byte) *moira.MatchedMetric {
+	if !src.allow() {
+		return nil
+	}
+	matchedMetric, err := patternsStorage.ProcessIncomingLine(lineBytes)
+	src.account(matchedMetric, err)
+	return matchedMetric
+}
+
+// allow marks line as received and checks source rate limit with token bucket of one second capacity
+func (src *source) allow() bool {
+	src.received.Mark(1)
+	atomic.StoreInt64(&src.lastSeen, time.Now().Unix())
+	if src.limit <= 0 {
+		return true
+	}
+	src.lock.Lock()
+	defer src.lock.Unlock()
+	now := time.Now()
+	src.tokens += now.Sub(src.lastRefill).Seconds() * float64(src.limit)
+	if src.tokens > float64(src.limit) {
+		src.tokens = float64(src.limit)
+	}
+	src.lastRefill = now
+	if src.tokens < 1 {
+		src.dropped.Mark(1)
+		return false
+	}
+	src.tokens--
+	return true
+}
+
+func (src *source) account(matchedMetric *moira.MatchedMetric, err error) {
+	if err != nil {
+		src.invalid.Mark(1)
+	}
+	if matchedMetric != nil {
+		src.matching.Mark(1)
+	}
+}
+
+// sourceConnection releases connection slot on the first Close call
+type sourceConnection struct {
+	net.Conn
+	sources *Sources
+	once    sync.Once
+}
+
+// Close closes connection and releases its slot
+func (connection *sourceConnection) Close() error {
+	connection.once.Do(connection.sources.releaseConnection)
+	return connection.Conn.Close()
+}
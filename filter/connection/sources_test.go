@@ -0,0 +1,103 @@
+package connection
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/moira-alert/moira/filter"
+	"github.com/moira-alert/moira/metrics/graphite/go-metrics"
+	"github.com/moira-alert/moira/mock/moira-alert"
+	"github.com/op/go-logging"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSourcesConnections(t *testing.T) {
+	logger, _ := logging.GetLogger("Filter")
+	filterMetrics := metrics.ConfigureFilterMetrics("test")
+	sources := NewSources(SourceLimits{MaxConnections: 1}, filterMetrics, logger)
+
+	first, firstRemote := net.Pipe()
+	defer firstRemote.Close()
+	second, secondRemote := net.Pipe()
+	defer secondRemote.Close()
+	third, thirdRemote := net.Pipe()
+	defer thirdRemote.Close()
+
+	var firstConnection net.Conn
+	Convey("Connections over the limit should be rejected", t, func() {
+		var accepted bool
+		firstConnection, accepted = sources.acceptConnection(first)
+		So(accepted, ShouldBeTrue)
+		_, accepted = sources.acceptConnection(second)
+		So(accepted, ShouldBeFalse)
+		So(filterMetrics.ConnectionsRejected.Count(), ShouldEqual, 1)
+	})
+
+	Convey("Closed connection should release its slot only once", t, func() {
+		firstConnection.Close()
+		firstConnection.Close()
+		So(sources.connections, ShouldEqual, 0)
+		thirdConnection, accepted := sources.acceptConnection(third)
+		So(accepted, ShouldBeTrue)
+		thirdConnection.Close()
+		So(sources.connections, ShouldEqual, 0)
+	})
+}
+
+func TestSourcesLines(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	database := mock_moira_alert.NewMockDatabase(mockCtrl)
+	database.EXPECT().GetPatterns().Return([]string{"One.two.three"}, nil)
+	logger, _ := logging.GetLogger("Filter")
+	filterMetrics := metrics.ConfigureFilterMetrics("test")
+	patternStorage, _ := filter.NewPatternStorage(database, filterMetrics, logger)
+	sources := NewSources(SourceLimits{LinesPerSecond: 3}, filterMetrics, logger)
+
+	Convey("Lines should be accounted per remote host", t, func() {
+		src := sources.get("127.0.0.1:12345")
+		So(sources.get("127.0.0.1:54321"), ShouldPointTo, src)
+		So(sources.get("127.0.0.2:12345"), ShouldNotPointTo, src)
+
+		So(src.processLine(patternStorage, []byte("One.two.three 1 1234567890")), ShouldNotBeNil)
+		So(src.processLine(patternStorage, []byte("One.two.four 1 1234567890")), ShouldBeNil)
+		So(src.processLine(patternStorage, []byte("One.two.three")), ShouldBeNil)
+		So(src.received.Count(), ShouldEqual, 3)
+		So(src.matching.Count(), ShouldEqual, 1)
+		So(src.invalid.Count(), ShouldEqual, 1)
+		So(src.dropped.Count(), ShouldEqual, 0)
+	})
+
+	Convey("Lines over the rate limit should be dropped", t, func() {
+		src := sources.get("127.0.0.1:12345")
+		So(src.processLine(patternStorage, []byte("One.two.three 1 1234567890")), ShouldBeNil)
+		So(src.dropped.Count(), ShouldEqual, 1)
+		So(src.matching.Count(), ShouldEqual, 1)
+	})
+}
+
+func TestSourcesEviction(t *testing.T) {
+	logger, _ := logging.GetLogger("Filter")
+	filterMetrics := metrics.ConfigureFilterMetrics("test")
+	sources := NewSources(SourceLimits{}, filterMetrics, logger)
+
+	Convey("Idle sources should be evicted with their meters", t, func() {
+		idle := sources.get("127.0.0.3:12345")
+		idle.allow()
+		active := sources.get("127.0.0.4:12345")
+		active.allow()
+
+		idle.lastSeen = time.Now().Add(-2 * sourceIdleTimeout).Unix()
+		sources.lastEviction = time.Now().Add(-sourceIdleTimeout)
+		sources.get("127.0.0.4:12345")
+		So(sources.sources, ShouldHaveLength, 1)
+		So(sources.sources, ShouldContainKey, "127.0.0.4")
+
+		resumed := sources.get("127.0.0.3:54321")
+		So(resumed, ShouldNotPointTo, idle)
+		So(resumed.received.Count(), ShouldEqual, 0)
+		So(sources.get("127.0.0.4:54321").received.Count(), ShouldEqual, 1)
+	})
+}
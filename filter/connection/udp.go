@@ -0,0 +1,84 @@
+package connection
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"time"
+
+	"gopkg.in/tomb.v2"
+
+	"github.com/moira-alert/moira"
+	"github.com/moira-alert/moira/filter"
+)
+
+// maxUDPPacketSize is the largest payload that can be carried by single UDP datagram
+const maxUDPPacketSize = 65535
+
+// UDPListener reads plaintext protocol datagrams and handles every line of them
+type UDPListener struct {
+	connection      *net.UDPConn
+	patternsStorage *filter.PatternStorage
+	sources         *Sources
+	logger          moira.Logger
+	tomb            tomb.Tomb
+}
+
+// NewUDPListener creates new listener for plaintext protocol over UDP
+func NewUDPListener(port string, logger moira.Logger, patternStorage *filter.PatternStorage, sources *Sources) (*UDPListener, error) {
+	address, err := net.ResolveUDPAddr("udp", port)
+	if nil != err {
+		return nil, fmt.Errorf("Failed to resolve udp address [%s]: %s", port, err.Error())
+	}
+	connection, err := net.ListenUDP("udp", address)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to listen on [%s]: %s", port, err.Error())
+	}
+	return &UDPListener{
+		connection:      connection,
+		patternsStorage: patternStorage,
+		sources:         sources,
+		logger:          logger,
+	}, nil
+}
+
+// Listen reads datagrams, splits them by lines and sends matched metrics to metricsChan
+func (listener *UDPListener) Listen(metricsChan chan *moira.MatchedMetric) {
+	listener.tomb.Go(func() error {
+		buffer := make([]byte, maxUDPPacketSize)
+		for {
+			select {
+			case <-listener.tomb.Dying():
+				listener.connection.Close()
+				listener.logger.Infof("Moira Filter UDP Listener [%s] stopped", listener.connection.LocalAddr())
+				return nil
+			default:
+			}
+			listener.connection.SetReadDeadline(time.Now().Add(1e9))
+			n, address, err := listener.connection.ReadFromUDP(buffer)
+			if err != nil {
+				if opErr, ok := err.(*net.OpError); ok && opErr.Timeout() {
+					continue
+				}
+				listener.logger.Errorf("read failed: %s", err)
+				continue
+			}
+			src := listener.sources.get(address.String())
+			for _, lineBytes := range bytes.Split(buffer[:n], []byte{'\n'}) {
+				if len(lineBytes) == 0 {
+					continue
+				}
+				if m := src.processLine(listener.patternsStorage, lineBytes); m != nil {
+					metricsChan <- m
+				}
+			}
+		}
+	})
+	listener.logger.Infof("Moira Filter UDP Listener [%s] Started", listener.connection.LocalAddr())
+}
+
+// Stop stops reading datagrams
+func (listener *UDPListener) Stop() error {
+	listener.tomb.Kill(nil)
+	return listener.tomb.Wait()
+}
@@ -15,16 +15,19 @@ type MetricsMatcher struct {
 	metrics      *graphite.FilterMetrics
 	database     moira.Database
 	cacheStorage *filter.Storage
+	spillQueue   *SpillQueue
 	waitGroup    *sync.WaitGroup
 }
 
-// NewMetricsMatcher creates new MetricsMatcher
-func NewMetricsMatcher(metrics *graphite.FilterMetrics, logger moira.Logger, database moira.Database, cacheStorage *filter.Storage) *MetricsMatcher {
+// NewMetricsMatcher creates new MetricsMatcher.
+// Buffers failed to save are written to spillQueue and replayed later, nil spillQueue means they are discarded
+func NewMetricsMatcher(metrics *graphite.FilterMetrics, logger moira.Logger, database moira.Database, cacheStorage *filter.Storage, spillQueue *SpillQueue) *MetricsMatcher {
 	return &MetricsMatcher{
 		metrics:      metrics,
 		logger:       logger,
 		database:     database,
 		cacheStorage: cacheStorage,
+		spillQueue:   spillQueue,
 		waitGroup:    &sync.WaitGroup{},
 	}
 }
@@ -39,6 +42,9 @@ func (matcher *MetricsMatcher) Start(channel chan *moira.MatchedMetric) {
 			select {
 			case metric, ok := <-channel:
 				if !ok {
+					if len(buffer) > 0 {
+						matcher.save(buffer)
+					}
 					matcher.logger.Info("Moira Filter Metrics Matcher stopped")
 					return
 				}
@@ -49,6 +55,7 @@ func (matcher *MetricsMatcher) Start(channel chan *moira.MatchedMetric) {
 			case <-time.After(time.Second):
 			}
 			if len(buffer) == 0 {
+				matcher.replay()
 				continue
 			}
 			timer := time.Now()
@@ -68,5 +75,24 @@ func (matcher *MetricsMatcher) Wait() {
 func (matcher *MetricsMatcher) save(buffer map[string]*moira.MatchedMetric) {
 	if err := matcher.database.SaveMetrics(buffer); err != nil {
 		matcher.logger.Infof("Failed to save value in cache storage: %s", err.Error())
+		if matcher.spillQueue == nil {
+			return
+		}
+		if err := matcher.spillQueue.Push(buffer); err != nil {
+			matcher.logger.Errorf("Failed to spill metrics to disk: %s", err.Error())
+		}
+		return
+	}
+	matcher.replay()
+}
+
+// replay saves oldest spilled buffer, it is called after successful save or when no metrics are received,
+// so spill queue is drained gradually without blocking new metrics
+func (matcher *MetricsMatcher) replay() {
+	if matcher.spillQueue == nil || matcher.spillQueue.Len() == 0 {
+		return
+	}
+	if err := matcher.spillQueue.Replay(matcher.database.SaveMetrics); err != nil {
+		matcher.logger.Infof("Failed to replay spilled metrics: %s", err.Error())
 	}
 }
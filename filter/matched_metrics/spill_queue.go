@@ -0,0 +1,186 @@
+package matchedmetrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/moira-alert/moira"
+	"github.com/moira-alert/moira/metrics/graphite"
+)
+
+const (
+	segmentExtension    = ".json"
+	segmentTmpExtension = ".tmp"
+)
+
+// SpillQueue is bounded on-disk write-ahead queue of metrics buffers which failed to save.
+// Every buffer is stored in separate segment file named by its sequence number and points count.
+// Oldest segments are dropped when queue exceeds its size limit. SpillQueue is not safe for concurrent use
+type SpillQueue struct {
+	dir      string
+	maxSize  int64
+	size     int64
+	points   int64
+	nextID   int64
+	segments []spillSegment
+	metrics  *graphite.FilterMetrics
+	logger   moira.Logger
+}
+
+type spillSegment struct {
+	id     int64
+	points int64
+	size   int64
+}
+
+// NewSpillQueue creates spill queue in given directory limited by maxSize bytes.
+// Segments left by previous run are loaded to be replayed
+func NewSpillQueue(dir string, maxSize int64, metrics *graphite.FilterMetrics, logger moira.Logger) (*SpillQueue, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("Failed to create spill queue directory: %s", err.Error())
+	}
+	queue := &SpillQueue{
+		dir:      dir,
+		maxSize:  maxSize,
+		metrics:  metrics,
+		logger:   logger,
+		segments: make([]spillSegment, 0),
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read spill queue directory: %s", err.Error())
+	}
+	// ReadDir returns files sorted by name, and names start with zero padded sequence number
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), segmentTmpExtension) {
+			os.Remove(filepath.Join(dir, file.Name()))
+			continue
+		}
+		var segment spillSegment
+		if _, err := fmt.Sscanf(file.Name(), "%d-%d"+segmentExtension, &segment.id, &segment.points); err != nil {
+			logger.Warningf("Unknown file [%s] in spill queue directory is skipped", file.Name())
+			continue
+		}
+		segment.size = file.Size()
+		queue.append(segment)
+	}
+	queue.updateDepth()
+	if len(queue.segments) > 0 {
+		logger.Infof("Spill queue loaded %d segments with %d metrics to replay", len(queue.segments), queue.points)
+	}
+	return queue, nil
+}
+
+// Len returns number of metrics in queue
+func (queue *SpillQueue) Len() int64 {
+	return queue.points
+}
+
+// Push writes buffer to new segment, dropping oldest segments if queue size limit is exceeded
+func (queue *SpillQueue) Push(buffer map[string]*moira.MatchedMetric) error {
+	metrics := make([]*moira.MatchedMetric, 0, len(buffer))
+	for _, metric := range buffer {
+		metrics = append(metrics, metric)
+	}
+	data, err := json.Marshal(metrics)
+	if err != nil {
+		queue.metrics.SpillDroppedPoints.Inc(int64(len(metrics)))
+		return fmt.Errorf("Failed to marshal metrics: %s", err.Error())
+	}
+	segment := spillSegment{id: queue.nextID, points: int64(len(metrics)), size: int64(len(data))}
+	if queue.maxSize > 0 && segment.size > queue.maxSize {
+		queue.metrics.SpillDroppedPoints.Inc(segment.points)
+		return fmt.Errorf("Failed to spill %d metrics: segment size %d exceeds queue size limit %d", segment.points, segment.size, queue.maxSize)
+	}
+	for queue.maxSize > 0 && queue.size+segment.size > queue.maxSize && len(queue.segments) > 0 {
+		oldest := queue.segments[0]
+		queue.logger.Warningf("Spill queue is full, %d oldest metrics are dropped", oldest.points)
+		queue.metrics.SpillDroppedPoints.Inc(oldest.points)
+		queue.remove()
+	}
+	fileName := queue.fileName(segment)
+	if err := ioutil.WriteFile(fileName+segmentTmpExtension, data, 0644); err != nil {
+		os.Remove(fileName + segmentTmpExtension)
+		queue.metrics.SpillDroppedPoints.Inc(segment.points)
+		return fmt.Errorf("Failed to write spill queue segment: %s", err.Error())
+	}
+	// Rename is atomic, so segment is never read partially written after crash
+	if err := os.Rename(fileName+segmentTmpExtension, fileName); err != nil {
+		os.Remove(fileName + segmentTmpExtension)
+		queue.metrics.SpillDroppedPoints.Inc(segment.points)
+		return fmt.Errorf("Failed to write spill queue segment: %s", err.Error())
+	}
+	queue.append(segment)
+	queue.updateDepth()
+	return nil
+}
+
+// Replay passes oldest segment to save function and removes segment if it was saved.
+// Segment which can not be read is dropped
+func (queue *SpillQueue) Replay(save func(buffer map[string]*moira.MatchedMetric) error) error {
+	if len(queue.segments) == 0 {
+		return nil
+	}
+	segment := queue.segments[0]
+	buffer, err := queue.read(segment)
+	if err != nil {
+		queue.metrics.SpillDroppedPoints.Inc(segment.points)
+		queue.remove()
+		queue.updateDepth()
+		return fmt.Errorf("Failed to read spill queue segment, %d metrics are dropped: %s", segment.points, err.Error())
+	}
+	if err := save(buffer); err != nil {
+		return err
+	}
+	queue.remove()
+	queue.updateDepth()
+	return nil
+}
+
+func (queue *SpillQueue) read(segment spillSegment) (map[string]*moira.MatchedMetric, error) {
+	data, err := ioutil.ReadFile(queue.fileName(segment))
+	if err != nil {
+		return nil, err
+	}
+	metrics := make([]*moira.MatchedMetric, 0, segment.points)
+	if err := json.Unmarshal(data, &metrics); err != nil {
+		return nil, err
+	}
+	buffer := make(map[string]*moira.MatchedMetric, len(metrics))
+	for _, metric := range metrics {
+		buffer[metric.Metric] = metric
+	}
+	return buffer, nil
+}
+
+func (queue *SpillQueue) append(segment spillSegment) {
+	queue.segments = append(queue.segments, segment)
+	queue.size += segment.size
+	queue.points += segment.points
+	if segment.id >= queue.nextID {
+		queue.nextID = segment.id + 1
+	}
+}
+
+// remove deletes oldest segment
+func (queue *SpillQueue) remove() {
+	segment := queue.segments[0]
+	if err := os.Remove(queue.fileName(segment)); err != nil && !os.IsNotExist(err) {
+		queue.logger.Errorf("Failed to remove spill queue segment: %s", err.Error())
+	}
+	queue.segments = queue.segments[1:]
+	queue.size -= segment.size
+	queue.points -= segment.points
+}
+
+func (queue *SpillQueue) updateDepth() {
+	queue.metrics.SpillQueueDepth.Update(queue.points)
+}
+
+func (queue *SpillQueue) fileName(segment spillSegment) string {
+	return filepath.Join(queue.dir, fmt.Sprintf("%020d-%d%s", segment.id, segment.points, segmentExtension))
+}
@@ -0,0 +1,135 @@
+package matchedmetrics
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/moira-alert/moira"
+	"github.com/moira-alert/moira/metrics/graphite/go-metrics"
+	"github.com/op/go-logging"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSpillQueue(t *testing.T) {
+	logger, _ := logging.GetLogger("Filter")
+	filterMetrics := metrics.ConfigureFilterMetrics("test")
+	dir, err := ioutil.TempDir("", "spill")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	queue, err := NewSpillQueue(dir, 0, filterMetrics, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := makeBuffer("first", 2)
+	second := makeBuffer("second", 3)
+
+	Convey("Pushed buffers should be persisted and counted", t, func() {
+		So(queue.Push(first), ShouldBeNil)
+		So(queue.Push(second), ShouldBeNil)
+		So(queue.Len(), ShouldEqual, 5)
+		So(filterMetrics.SpillQueueDepth.Value(), ShouldEqual, 5)
+		files, _ := ioutil.ReadDir(dir)
+		So(files, ShouldHaveLength, 2)
+	})
+
+	Convey("Segments should be loaded by new queue in the same order", t, func() {
+		loaded, err := NewSpillQueue(dir, 0, filterMetrics, logger)
+		So(err, ShouldBeNil)
+		So(loaded.Len(), ShouldEqual, 5)
+		So(loaded.Push(makeBuffer("third", 1)), ShouldBeNil)
+		So(loaded.segments[2].id, ShouldEqual, 2)
+		queue = loaded
+	})
+
+	Convey("Failed replay should keep segment", t, func() {
+		err := queue.Replay(func(buffer map[string]*moira.MatchedMetric) error {
+			return fmt.Errorf("redis is down")
+		})
+		So(err, ShouldNotBeNil)
+		So(queue.Len(), ShouldEqual, 6)
+	})
+
+	Convey("Replay should save oldest segment and remove it", t, func() {
+		var saved map[string]*moira.MatchedMetric
+		save := func(buffer map[string]*moira.MatchedMetric) error {
+			saved = buffer
+			return nil
+		}
+		So(queue.Replay(save), ShouldBeNil)
+		So(saved, ShouldResemble, first)
+		So(queue.Replay(save), ShouldBeNil)
+		So(saved, ShouldResemble, second)
+		So(queue.Len(), ShouldEqual, 1)
+		So(filterMetrics.SpillQueueDepth.Value(), ShouldEqual, 1)
+	})
+
+	Convey("Corrupted segment should be dropped", t, func() {
+		ioutil.WriteFile(queue.fileName(queue.segments[0]), []byte("{"), 0644)
+		dropped := filterMetrics.SpillDroppedPoints.Count()
+		So(queue.Replay(func(map[string]*moira.MatchedMetric) error { return nil }), ShouldNotBeNil)
+		So(queue.Len(), ShouldEqual, 0)
+		So(filterMetrics.SpillDroppedPoints.Count(), ShouldEqual, dropped+1)
+		files, _ := filepath.Glob(filepath.Join(dir, "*"))
+		So(files, ShouldBeEmpty)
+	})
+}
+
+func TestSpillQueueLimit(t *testing.T) {
+	logger, _ := logging.GetLogger("Filter")
+	filterMetrics := metrics.ConfigureFilterMetrics("test")
+	dir, err := ioutil.TempDir("", "spill")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	first := makeBuffer("first", 2)
+	queue, _ := NewSpillQueue(dir, 0, filterMetrics, logger)
+	queue.Push(first)
+	segmentSize := queue.size
+	queue.Replay(func(map[string]*moira.MatchedMetric) error { return nil })
+	queue.maxSize = segmentSize + segmentSize/2
+
+	Convey("Oldest segments should be dropped when queue is full", t, func() {
+		dropped := filterMetrics.SpillDroppedPoints.Count()
+		So(queue.Push(first), ShouldBeNil)
+		So(queue.Push(makeBuffer("other", 2)), ShouldBeNil)
+		So(queue.Len(), ShouldEqual, 2)
+		So(filterMetrics.SpillDroppedPoints.Count(), ShouldEqual, dropped+2)
+		var saved map[string]*moira.MatchedMetric
+		queue.Replay(func(buffer map[string]*moira.MatchedMetric) error {
+			saved = buffer
+			return nil
+		})
+		So(saved, ShouldContainKey, "other.0")
+	})
+
+	Convey("Buffer larger than queue should be dropped", t, func() {
+		dropped := filterMetrics.SpillDroppedPoints.Count()
+		So(queue.Push(makeBuffer("large", 10)), ShouldNotBeNil)
+		So(queue.Len(), ShouldEqual, 0)
+		So(filterMetrics.SpillDroppedPoints.Count(), ShouldEqual, dropped+10)
+	})
+}
+
+func makeBuffer(prefix string, count int) map[string]*moira.MatchedMetric {
+	buffer := make(map[string]*moira.MatchedMetric, count)
+	for i := 0; i < count; i++ {
+		metric := fmt.Sprintf("%s.%d", prefix, i)
+		buffer[metric] = &moira.MatchedMetric{
+			Metric:             metric,
+			Patterns:           []string{prefix + ".*"},
+			Value:              float64(i),
+			Timestamp:          1234567890,
+			RetentionTimestamp: 1234567880,
+			Retention:          60,
+		}
+	}
+	return buffer
+}
@@ -0,0 +1,208 @@
+package filter
+
+import (
+	"strings"
+)
+
+// partMatcher matches single metric part against all brace-expanded alternatives of pattern part.
+// Alternatives without wildcards are looked up in the set, others are checked by compiled globs
+type partMatcher struct {
+	literals map[string]struct{}
+	globs    []glob
+}
+
+// newPartMatcher compiles pattern part with graphite glob syntax: '*', '?', '[...]' classes and '{...}' groups
+func newPartMatcher(part string) *partMatcher {
+	matcher := &partMatcher{
+		literals: make(map[string]struct{}),
+		globs:    make([]glob, 0),
+	}
+	for _, alternative := range expandBraces(part) {
+		if !strings.ContainsAny(alternative, "*?[") {
+			matcher.literals[alternative] = struct{}{}
+			continue
+		}
+		matcher.globs = append(matcher.globs, compileGlob(alternative))
+	}
+	return matcher
+}
+
+func (matcher *partMatcher) match(part []byte) bool {
+	if _, ok := matcher.literals[string(part)]; ok {
+		return true
+	}
+	for _, g := range matcher.globs {
+		if g.match(part) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandBraces expands every {a,b} group, including nested ones, into the list of alternatives.
+// Unclosed braces are kept as literal characters
+func expandBraces(part string) []string {
+	start := strings.IndexByte(part, '{')
+	if start < 0 {
+		return []string{part}
+	}
+	end, options := splitBraceGroup(part, start)
+	if end < 0 {
+		expanded := expandBraces(part[start+1:])
+		result := make([]string, 0, len(expanded))
+		for _, suffix := range expanded {
+			result = append(result, part[:start+1]+suffix)
+		}
+		return result
+	}
+	suffixes := expandBraces(part[end+1:])
+	result := make([]string, 0, len(options)*len(suffixes))
+	for _, option := range options {
+		for _, expandedOption := range expandBraces(option) {
+			for _, suffix := range suffixes {
+				result = append(result, part[:start]+expandedOption+suffix)
+			}
+		}
+	}
+	return result
+}
+
+// splitBraceGroup returns position of closing brace matching the one at start position
+// and top-level comma separated options of the group
+func splitBraceGroup(part string, start int) (int, []string) {
+	depth := 0
+	optionStart := start + 1
+	options := make([]string, 0)
+	for i := start; i < len(part); i++ {
+		switch part[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i, append(options, part[optionStart:i])
+			}
+		case ',':
+			if depth == 1 {
+				options = append(options, part[optionStart:i])
+				optionStart = i + 1
+			}
+		}
+	}
+	return -1, nil
+}
+
+const (
+	globLiteral = iota
+	globAnyChar
+	globClass
+	globStar
+)
+
+// globToken is a single element of compiled glob: one character matcher or star
+type globToken struct {
+	kind    int
+	char    byte
+	class   *[256]bool
+	negated bool
+}
+
+func (token *globToken) matchChar(c byte) bool {
+	switch token.kind {
+	case globLiteral:
+		return token.char == c
+	case globAnyChar:
+		return true
+	case globClass:
+		return token.class[c] != token.negated
+	}
+	return false
+}
+
+// glob is compiled pattern alternative without braces
+type glob []globToken
+
+func compileGlob(pattern string) glob {
+	tokens := make(glob, 0, len(pattern))
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			// Consecutive stars are equal to single one
+			if len(tokens) == 0 || tokens[len(tokens)-1].kind != globStar {
+				tokens = append(tokens, globToken{kind: globStar})
+			}
+		case '?':
+			tokens = append(tokens, globToken{kind: globAnyChar})
+		case '[':
+			token, end := compileClass(pattern, i)
+			if end < 0 {
+				tokens = append(tokens, globToken{kind: globLiteral, char: c})
+				continue
+			}
+			tokens = append(tokens, token)
+			i = end
+		default:
+			tokens = append(tokens, globToken{kind: globLiteral, char: c})
+		}
+	}
+	return tokens
+}
+
+// compileClass compiles [abc], [a-z] or negated [!a-z] and [^a-z] class
+// starting at given position and returns position of its closing bracket
+func compileClass(pattern string, start int) (globToken, int) {
+	token := globToken{kind: globClass, class: &[256]bool{}}
+	i := start + 1
+	if i < len(pattern) && (pattern[i] == '!' || pattern[i] == '^') {
+		token.negated = true
+		i++
+	}
+	first := i
+	for ; i < len(pattern); i++ {
+		c := pattern[i]
+		if c == ']' && i > first {
+			return token, i
+		}
+		if i+2 < len(pattern) && pattern[i+1] == '-' && pattern[i+2] != ']' {
+			for r := int(c); r <= int(pattern[i+2]); r++ {
+				token.class[r] = true
+			}
+			i += 2
+			continue
+		}
+		token.class[c] = true
+	}
+	return globToken{}, -1
+}
+
+// match checks whole part against glob, backtracking to the last star on mismatch
+func (g glob) match(part []byte) bool {
+	tokenIndex, partIndex := 0, 0
+	starTokenIndex, starPartIndex := -1, 0
+	for partIndex < len(part) {
+		if tokenIndex < len(g) {
+			token := &g[tokenIndex]
+			if token.kind == globStar {
+				starTokenIndex = tokenIndex
+				starPartIndex = partIndex
+				tokenIndex++
+				continue
+			}
+			if token.matchChar(part[partIndex]) {
+				tokenIndex++
+				partIndex++
+				continue
+			}
+		}
+		if starTokenIndex < 0 {
+			return false
+		}
+		starPartIndex++
+		partIndex = starPartIndex
+		tokenIndex = starTokenIndex + 1
+	}
+	for tokenIndex < len(g) && g[tokenIndex].kind == globStar {
+		tokenIndex++
+	}
+	return tokenIndex == len(g)
+}
@@ -0,0 +1,50 @@
+package filter
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestExpandBraces(t *testing.T) {
+	Convey("Brace groups should be expanded into all alternatives", t, func() {
+		So(expandBraces("plain"), ShouldResemble, []string{"plain"})
+		So(expandBraces("srv-{web,api}"), ShouldResemble, []string{"srv-web", "srv-api"})
+		So(expandBraces("srv-{web,api}-{01,02}"), ShouldResemble, []string{"srv-web-01", "srv-web-02", "srv-api-01", "srv-api-02"})
+		So(expandBraces("{a,b{c,d}}e"), ShouldResemble, []string{"ae", "bce", "bde"})
+		So(expandBraces("pr{one,}suf"), ShouldResemble, []string{"pronesuf", "prsuf"})
+		So(expandBraces("unclosed{a,b"), ShouldResemble, []string{"unclosed{a,b"})
+		So(expandBraces("{unclosed{a,b}"), ShouldResemble, []string{"{uncloseda", "{unclosedb"})
+	})
+}
+
+func TestPartMatcher(t *testing.T) {
+	cases := []struct {
+		pattern  string
+		matching []string
+		other    []string
+	}{
+		{"srv-{web,api}-{01,02}", []string{"srv-web-01", "srv-api-02"}, []string{"srv-web-03", "srv-db-01", "srv-web-01x"}},
+		{"cpu[0-9]*", []string{"cpu0", "cpu12", "cpu9_user"}, []string{"cpu", "cpux", "cpu-1"}},
+		{"cpu[!0-9]", []string{"cpux", "cpu-"}, []string{"cpu1", "cpu", "cpuxx"}},
+		{"cpu[^0-9]", []string{"cpux"}, []string{"cpu1"}},
+		{"[abc]?", []string{"a1", "cz"}, []string{"d1", "a", "a12"}},
+		{"*{one,two}suf*", []string{"prefixonesuffix", "twosuf", "xtwosufx"}, []string{"threesuf", "onesu"}},
+		{"a*b*c", []string{"abc", "aXbYc", "abbbc", "acbc"}, []string{"ab", "acb", "abcd"}},
+		{"?at_begin", []string{"1at_begin"}, []string{"at_begin", "12at_begin"}},
+		{"***", []string{"", "anything"}, []string{}},
+		{"{web{1,2},db}[0-9]", []string{"web15", "web27", "db0"}, []string{"web3", "db", "web1"}},
+		{"broken[0-9", []string{"broken[0-9"}, []string{"broken1"}},
+	}
+	Convey("Compiled matcher should follow graphite glob semantics", t, func() {
+		for _, testCase := range cases {
+			matcher := newPartMatcher(testCase.pattern)
+			for _, part := range testCase.matching {
+				So(matcher.match([]byte(part)), ShouldBeTrue)
+			}
+			for _, part := range testCase.other {
+				So(matcher.match([]byte(part)), ShouldBeFalse)
+			}
+		}
+	})
+}
@@ -12,24 +12,27 @@ import (
 
 // RefreshPatternWorker realization
 type RefreshPatternWorker struct {
-	database       moira.Database
-	logger         moira.Logger
-	metrics        *graphite.FilterMetrics
-	patternStorage *filter.PatternStorage
-	tomb           tomb.Tomb
+	database        moira.Database
+	logger          moira.Logger
+	metrics         *graphite.FilterMetrics
+	patternStorage  *filter.PatternStorage
+	refreshInterval time.Duration
+	tomb            tomb.Tomb
 }
 
 // NewRefreshPatternWorker creates new RefreshPatternWorker
-func NewRefreshPatternWorker(database moira.Database, metrics *graphite.FilterMetrics, logger moira.Logger, patternStorage *filter.PatternStorage) *RefreshPatternWorker {
+func NewRefreshPatternWorker(database moira.Database, metrics *graphite.FilterMetrics, logger moira.Logger, patternStorage *filter.PatternStorage, refreshInterval time.Duration) *RefreshPatternWorker {
 	return &RefreshPatternWorker{
-		database:       database,
-		metrics:        metrics,
-		logger:         logger,
-		patternStorage: patternStorage,
+		database:        database,
+		metrics:         metrics,
+		logger:          logger,
+		patternStorage:  patternStorage,
+		refreshInterval: refreshInterval,
 	}
 }
 
-// Start process to refresh pattern tree every second
+// Start process to apply pattern events to the pattern tree as soon as they are published
+// and to rebuild whole tree every refresh interval in case some events were lost
 func (worker *RefreshPatternWorker) Start() error {
 	err := worker.patternStorage.RefreshTree()
 	if err != nil {
@@ -37,14 +40,27 @@ func (worker *RefreshPatternWorker) Start() error {
 		return err
 	}
 
+	patternEvents, err := worker.database.SubscribePatternEvents(&worker.tomb)
+	if err != nil {
+		worker.logger.Errorf("pattern events subscription failed: %s", err.Error())
+		return err
+	}
+
 	worker.tomb.Go(func() error {
+		refreshTicker := time.NewTicker(worker.refreshInterval)
+		defer refreshTicker.Stop()
 		for {
-			checkTicker := time.NewTicker(time.Second)
 			select {
 			case <-worker.tomb.Dying():
 				worker.logger.Info("Moira Filter Pattern Updater stopped")
 				return nil
-			case <-checkTicker.C:
+			case event, ok := <-patternEvents:
+				if !ok {
+					patternEvents = nil
+					continue
+				}
+				worker.applyPatternEvent(event)
+			case <-refreshTicker.C:
 				timer := time.Now()
 				err := worker.patternStorage.RefreshTree()
 				if err != nil {
@@ -58,6 +74,14 @@ func (worker *RefreshPatternWorker) Start() error {
 	return nil
 }
 
+func (worker *RefreshPatternWorker) applyPatternEvent(event *moira.PatternEvent) {
+	if event.Removed {
+		worker.patternStorage.RemovePattern(event.Pattern)
+		return
+	}
+	worker.patternStorage.AddPattern(event.Pattern)
+}
+
 // Stop stops update pattern tree
 func (worker *RefreshPatternWorker) Stop() error {
 	worker.tomb.Kill(nil)
@@ -2,9 +2,9 @@ package filter
 
 import (
 	"fmt"
-	"path"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 	"unicode"
 
@@ -17,10 +17,14 @@ var asteriskHash = xxhash.Checksum32([]byte("*"))
 
 // PatternStorage contains pattern tree
 type PatternStorage struct {
-	database    moira.Database
-	metrics     *graphite.FilterMetrics
-	logger      moira.Logger
-	PatternTree *patternNode
+	database     moira.Database
+	metrics      *graphite.FilterMetrics
+	logger       moira.Logger
+	PatternTree  *patternNode
+	seriesByTag  *seriesByTagIndex
+	patterns     map[string]bool
+	rewriteRules *RewriteRules
+	lock         sync.RWMutex
 }
 
 // patternNode contains pattern node
@@ -30,6 +34,7 @@ type patternNode struct {
 	Hash       uint32
 	Prefix     string
 	InnerParts []string
+	matcher    *partMatcher
 }
 
 // NewPatternStorage creates new PatternStorage struct
@@ -38,6 +43,7 @@ func NewPatternStorage(database moira.Database, metrics *graphite.FilterMetrics,
 		database: database,
 		metrics:  metrics,
 		logger:   logger,
+		patterns: make(map[string]bool),
 	}
 	err := storage.RefreshTree()
 	return storage, err
@@ -52,41 +58,139 @@ func (storage *PatternStorage) RefreshTree() error {
 	return storage.buildTree(patterns)
 }
 
+// AddPattern adds pattern to the live pattern tree.
+// Nodes are never changed in place, so metrics matched concurrently see either old or new tree
+func (storage *PatternStorage) AddPattern(pattern string) {
+	storage.lock.Lock()
+	defer storage.lock.Unlock()
+	if storage.patterns[pattern] {
+		return
+	}
+	storage.patterns[pattern] = true
+	if moira.IsSeriesByTagPattern(pattern) {
+		storage.seriesByTag = storage.seriesByTag.withPattern(pattern)
+		return
+	}
+	parts := strings.Split(pattern, ".")
+	if hasEmptyParts(parts) {
+		return
+	}
+	storage.PatternTree = storage.PatternTree.withPattern(parts)
+}
+
+// RemovePattern removes pattern and all tree nodes used only by it from the live pattern tree
+func (storage *PatternStorage) RemovePattern(pattern string) {
+	storage.lock.Lock()
+	defer storage.lock.Unlock()
+	if !storage.patterns[pattern] {
+		return
+	}
+	delete(storage.patterns, pattern)
+	if moira.IsSeriesByTagPattern(pattern) {
+		storage.seriesByTag = storage.seriesByTag.withoutPattern(pattern)
+		return
+	}
+	storage.PatternTree = storage.PatternTree.withoutPattern(strings.Split(pattern, "."), storage.patterns)
+}
+
+// SetRewriteRules replaces rules applied to metric names before matching, nil disables rewriting
+func (storage *PatternStorage) SetRewriteRules(rules *RewriteRules) {
+	storage.lock.Lock()
+	storage.rewriteRules = rules
+	storage.lock.Unlock()
+}
+
 // ProcessIncomingMetric validates, parses and matches incoming raw string
 func (storage *PatternStorage) ProcessIncomingMetric(lineBytes []byte) *moira.MatchedMetric {
+	matchedMetric, _ := storage.ProcessIncomingLine(lineBytes)
+	return matchedMetric
+}
+
+// ProcessIncomingLine is the same as ProcessIncomingMetric, but also returns error if line is invalid
+func (storage *PatternStorage) ProcessIncomingLine(lineBytes []byte) (*moira.MatchedMetric, error) {
 	storage.metrics.TotalMetricsReceived.Inc(1)
-	count := storage.metrics.TotalMetricsReceived.Count()
 
 	metric, value, timestamp, err := storage.parseMetricFromString(lineBytes)
 	if err != nil {
 		storage.logger.Infof("cannot parse input: %v", err)
-		return nil
+		return nil, err
+	}
+	return storage.processMetric(metric, value, timestamp)
+}
+
+// ProcessParsedMetric matches metric received from structured protocols which need no line parsing
+func (storage *PatternStorage) ProcessParsedMetric(metric string, value float64, timestamp int64) *moira.MatchedMetric {
+	storage.metrics.TotalMetricsReceived.Inc(1)
+	matchedMetric, _ := storage.processMetric([]byte(metric), value, timestamp)
+	return matchedMetric
+}
+
+func (storage *PatternStorage) processMetric(metric []byte, value float64, timestamp int64) (*moira.MatchedMetric, error) {
+	count := storage.metrics.TotalMetricsReceived.Count()
+
+	metricName := string(metric)
+	var name string
+	var tags map[string]string
+	var err error
+	isTagged := moira.IsTaggedMetric(metricName)
+	if isTagged {
+		name, tags, err = moira.ParseTaggedMetric(metricName)
+		if err != nil {
+			storage.logger.Infof("cannot parse input: %v", err)
+			return nil, err
+		}
 	}
 
 	storage.metrics.ValidMetricsReceived.Inc(1)
 
+	storage.lock.RLock()
+	patternTree, seriesByTag, rewriteRules := storage.PatternTree, storage.seriesByTag, storage.rewriteRules
+	storage.lock.RUnlock()
+
+	if rewriteRules != nil {
+		// Rules are applied to the name of tagged series leaving its tags as is
+		var keep bool
+		if isTagged {
+			name, keep = rewriteRules.Apply(name)
+		} else {
+			metricName, keep = rewriteRules.Apply(metricName)
+			metric = []byte(metricName)
+		}
+		if !keep {
+			return nil, nil
+		}
+	}
+	if isTagged {
+		metricName = moira.TaggedMetricName(name, tags)
+	}
+
 	matchingStart := time.Now()
-	matched := storage.matchPattern(metric)
+	var matched []string
+	if isTagged {
+		matched = seriesByTag.match(name, tags)
+	} else {
+		matched = matchPattern(patternTree, metric)
+	}
 	if count%10 == 0 {
 		storage.metrics.MatchingTimer.UpdateSince(matchingStart)
 	}
 	if len(matched) > 0 {
 		storage.metrics.MatchingMetricsReceived.Inc(1)
 		return &moira.MatchedMetric{
-			Metric:             string(metric),
+			Metric:             metricName,
 			Patterns:           matched,
 			Value:              value,
 			Timestamp:          timestamp,
 			RetentionTimestamp: timestamp,
 			Retention:          60,
-		}
+		}, nil
 	}
-	return nil
+	return nil, nil
 }
 
-// matchPattern returns array of matched patterns
-func (storage *PatternStorage) matchPattern(metric []byte) []string {
-	currentLevel := []*patternNode{storage.PatternTree}
+// matchPattern returns array of patterns from given tree matched by metric
+func matchPattern(patternTree *patternNode, metric []byte) []string {
+	currentLevel := []*patternNode{patternTree}
 	var found, index int
 	for i, c := range metric {
 		if c == '.' {
@@ -123,6 +227,7 @@ func (storage *PatternStorage) matchPattern(metric []byte) []string {
 
 // parseMetricFromString parses metric from string
 // supported format: "<metricString> <valueFloat64> <timestampInt64>"
+// metricString can be graphite tagged series: "<name>;<tag1>=<value1>;<tag2>=<value2>"
 func (*PatternStorage) parseMetricFromString(line []byte) ([]byte, float64, int64, error) {
 	var parts [3][]byte
 	partIndex := 0
@@ -168,8 +273,15 @@ func (*PatternStorage) parseMetricFromString(line []byte) ([]byte, float64, int6
 
 func (storage *PatternStorage) buildTree(patterns []string) error {
 	newTree := &patternNode{}
+	newPatterns := make(map[string]bool, len(patterns))
+	seriesByTagPatterns := make([]string, 0)
 
 	for _, pattern := range patterns {
+		newPatterns[pattern] = true
+		if moira.IsSeriesByTagPattern(pattern) {
+			seriesByTagPatterns = append(seriesByTagPatterns, pattern)
+			continue
+		}
 		currentNode := newTree
 		parts := strings.Split(pattern, ".")
 		if hasEmptyParts(parts) {
@@ -185,41 +297,92 @@ func (storage *PatternStorage) buildTree(patterns []string) error {
 				}
 			}
 			if !found {
-				newNode := &patternNode{Part: part}
-
-				if currentNode.Prefix == "" {
-					newNode.Prefix = part
-				} else {
-					newNode.Prefix = fmt.Sprintf("%s.%s", currentNode.Prefix, part)
-				}
-
-				if part == "*" || !strings.ContainsAny(part, "{*?") {
-					newNode.Hash = xxhash.Checksum32([]byte(part))
-				} else {
-					if strings.Contains(part, "{") && strings.Contains(part, "}") {
-						prefix, bigSuffix := split2(part, "{")
-						inner, suffix := split2(bigSuffix, "}")
-						innerParts := strings.Split(inner, ",")
-
-						newNode.InnerParts = make([]string, 0, len(innerParts))
-						for _, innerPart := range innerParts {
-							newNode.InnerParts = append(newNode.InnerParts, fmt.Sprintf("%s%s%s", prefix, innerPart, suffix))
-						}
-					} else {
-						newNode.InnerParts = []string{part}
-					}
-
-				}
+				newNode := newPatternNode(currentNode, part)
 				currentNode.Children = append(currentNode.Children, newNode)
 				currentNode = newNode
 			}
 		}
 	}
 
+	newSeriesByTag := newSeriesByTagIndex(seriesByTagPatterns)
+
+	storage.lock.Lock()
 	storage.PatternTree = newTree
+	storage.seriesByTag = newSeriesByTag
+	storage.patterns = newPatterns
+	storage.lock.Unlock()
 	return nil
 }
 
+// newPatternNode creates node for given pattern part, which is a child of parent node
+func newPatternNode(parent *patternNode, part string) *patternNode {
+	node := &patternNode{Part: part}
+
+	if parent.Prefix == "" {
+		node.Prefix = part
+	} else {
+		node.Prefix = fmt.Sprintf("%s.%s", parent.Prefix, part)
+	}
+
+	if part == "*" || !strings.ContainsAny(part, "{*?[") {
+		node.Hash = xxhash.Checksum32([]byte(part))
+	} else {
+		node.InnerParts = expandBraces(part)
+		node.matcher = newPartMatcher(part)
+	}
+	return node
+}
+
+// copyNode returns shallow copy of node with its own children slice
+func (node *patternNode) copyNode() *patternNode {
+	newNode := *node
+	newNode.Children = make([]*patternNode, len(node.Children), len(node.Children)+1)
+	copy(newNode.Children, node.Children)
+	return &newNode
+}
+
+// withPattern returns tree with pattern parts added below the node.
+// Only nodes on the pattern path are copied, all other nodes are shared with the original tree
+func (node *patternNode) withPattern(parts []string) *patternNode {
+	if len(parts) == 0 {
+		return node
+	}
+	newNode := node.copyNode()
+	for i, child := range node.Children {
+		if child.Part == parts[0] {
+			newNode.Children[i] = child.withPattern(parts[1:])
+			return newNode
+		}
+	}
+	newNode.Children = append(newNode.Children, newPatternNode(node, parts[0]).withPattern(parts[1:]))
+	return newNode
+}
+
+// withoutPattern returns tree with pattern parts removed below the node.
+// Nodes left without children are removed too unless they are the ends of other patterns
+func (node *patternNode) withoutPattern(parts []string, patterns map[string]bool) *patternNode {
+	if len(parts) == 0 {
+		return node
+	}
+	for i, child := range node.Children {
+		if child.Part != parts[0] {
+			continue
+		}
+		newNode := node.copyNode()
+		newChild := child.withoutPattern(parts[1:], patterns)
+		if len(newChild.Children) == 0 && !patterns[newChild.Prefix] {
+			newNode.Children = append(newNode.Children[:i], newNode.Children[i+1:]...)
+			if len(newNode.Children) == 0 {
+				newNode.Children = nil
+			}
+		} else {
+			newNode.Children[i] = newChild
+		}
+		return newNode
+	}
+	return node
+}
+
 func parseTimestamp(unixTimestamp string) (int64, error) {
 	timestamp, err := strconv.ParseFloat(unixTimestamp, 64)
 	return int64(timestamp), err
@@ -241,16 +404,10 @@ func findPart(part []byte, currentLevel []*patternNode) ([]*patternNode, int) {
 		for _, child := range node.Children {
 			match := false
 
-			if child.Hash == asteriskHash || child.Hash == hash {
+			if child.matcher != nil {
+				match = child.matcher.match(part)
+			} else if child.Hash == asteriskHash || child.Hash == hash {
 				match = true
-			} else if len(child.InnerParts) > 0 {
-				for _, innerPart := range child.InnerParts {
-					innerMatch, _ := path.Match(innerPart, string(part))
-					if innerMatch {
-						match = true
-						break
-					}
-				}
 			}
 
 			if match {
@@ -260,11 +417,3 @@ func findPart(part []byte, currentLevel []*patternNode) ([]*patternNode, int) {
 	}
 	return nextLevel, len(nextLevel)
 }
-
-func split2(s, sep string) (string, string) {
-	splitResult := strings.SplitN(s, sep, 2)
-	if len(splitResult) < 2 {
-		return splitResult[0], ""
-	}
-	return splitResult[0], splitResult[1]
-}
@@ -5,8 +5,10 @@ import (
 	"testing"
 	"math/rand"
 	"strconv"
+	"strings"
 
 	"github.com/golang/mock/gomock"
+	"github.com/moira-alert/moira"
 	"github.com/moira-alert/moira/metrics/graphite/go-metrics"
 	"github.com/moira-alert/moira/mock/moira-alert"
 	"github.com/op/go-logging"
@@ -110,6 +112,8 @@ func TestProcessIncomingMetric(t *testing.T) {
 		"Complex.*{one,two,three}suf*.pattern",
 		"Question.?at_begin",
 		"Question.at_the_end?",
+		"seriesByTag('name=Tagged.metric','dc=eu')",
+		"seriesByTag('name=~Tagged\\.','host=~web','dc!=us')",
 	}
 
 	nonMatchingMetrics := []string{
@@ -118,6 +122,9 @@ func TestProcessIncomingMetric(t *testing.T) {
 		"Bracket.one.nothing",
 		"Bracket.nothing.pattern",
 		"Complex.prefixonesuffix",
+		"Simple.matching.pattern;dc=eu",
+		"Tagged.metric;dc=us",
+		"Tagged.metric",
 	}
 
 	matchingMetrics := []string{
@@ -137,6 +144,8 @@ func TestProcessIncomingMetric(t *testing.T) {
 		"Complex.anything.pattern",
 		"Question.1at_begin",
 		"Question.at_the_end2",
+		"Tagged.metric;dc=eu",
+		"Tagged.other;host=web1;dc=ru",
 	}
 
 	metrics2 := metrics.ConfigureFilterMetrics("test")
@@ -225,5 +234,125 @@ func TestProcessIncomingMetric(t *testing.T) {
 		})
 	})
 
+	Convey("When tagged metric arrives", t, func() {
+		Convey("With invalid tags, should be counted as invalid", func() {
+			patternsStorage.metrics = metrics.ConfigureFilterMetrics("test")
+			matchedMetric := patternsStorage.ProcessIncomingMetric([]byte("Tagged.metric;dc 12 1234567890"))
+			So(matchedMetric, ShouldBeNil)
+			So(patternsStorage.metrics.TotalMetricsReceived.Count(), ShouldEqual, 1)
+			So(patternsStorage.metrics.ValidMetricsReceived.Count(), ShouldEqual, 0)
+		})
+
+		Convey("Should normalize tags order and return all matched seriesByTag patterns", func() {
+			matchedMetric := patternsStorage.ProcessIncomingMetric([]byte("Tagged.metric;host=web1;dc=eu 12 1234567890"))
+			So(matchedMetric, ShouldNotBeNil)
+			So(matchedMetric.Metric, ShouldEqual, "Tagged.metric;dc=eu;host=web1")
+			So(matchedMetric.Patterns, ShouldResemble, []string{
+				"seriesByTag('name=Tagged.metric','dc=eu')",
+				"seriesByTag('name=~Tagged\\.','host=~web','dc!=us')",
+			})
+		})
+	})
+
+	Convey("When parsed metric arrives, should be matched without line parsing", t, func() {
+		patternsStorage.metrics = metrics.ConfigureFilterMetrics("test")
+		matchedMetric := patternsStorage.ProcessParsedMetric("Tagged.metric;dc", 12, 1234567890)
+		So(matchedMetric, ShouldBeNil)
+		matchedMetric = patternsStorage.ProcessParsedMetric("Simple.matching.pattern", 12, 1234567890)
+		So(matchedMetric, ShouldResemble, &moira.MatchedMetric{
+			Metric:             "Simple.matching.pattern",
+			Patterns:           []string{"Simple.matching.pattern"},
+			Value:              12,
+			Timestamp:          1234567890,
+			RetentionTimestamp: 1234567890,
+			Retention:          60,
+		})
+		So(patternsStorage.metrics.TotalMetricsReceived.Count(), ShouldEqual, 2)
+		So(patternsStorage.metrics.MatchingMetricsReceived.Count(), ShouldEqual, 1)
+	})
+
+	Convey("When rewrite rules are set, should match rewritten metric names", t, func() {
+		rules, err := ParseRewriteRules(strings.NewReader("strip-prefix prod.\ndrop ^Star\\."))
+		So(err, ShouldBeNil)
+		patternsStorage.SetRewriteRules(rules)
+		defer patternsStorage.SetRewriteRules(nil)
+
+		matchedMetric := patternsStorage.ProcessIncomingMetric([]byte("prod.Simple.matching.pattern 12 1234567890"))
+		So(matchedMetric, ShouldNotBeNil)
+		So(matchedMetric.Metric, ShouldEqual, "Simple.matching.pattern")
+		So(matchedMetric.Patterns, ShouldResemble, []string{"Simple.matching.pattern"})
+
+		matchedMetric = patternsStorage.ProcessIncomingMetric([]byte("prod.Tagged.metric;dc=eu 12 1234567890"))
+		So(matchedMetric, ShouldNotBeNil)
+		So(matchedMetric.Metric, ShouldEqual, "Tagged.metric;dc=eu")
+
+		matchedMetric = patternsStorage.ProcessIncomingMetric([]byte("prod.Star.single.anything 12 1234567890"))
+		So(matchedMetric, ShouldBeNil)
+	})
+
 	mockCtrl.Finish()
 }
+
+func TestPatternsUpdate(t *testing.T) {
+	logger, _ := logging.GetLogger("Scheduler")
+	patternsStorage := &PatternStorage{
+		metrics:  metrics.ConfigureFilterMetrics("test"),
+		logger:   logger,
+		patterns: make(map[string]bool),
+	}
+	patternsStorage.buildTree([]string{
+		"Simple.matching.pattern",
+		"Prefix.pattern",
+		"Prefix.pattern.long",
+		"seriesByTag('name=Tagged.metric','dc=eu')",
+	})
+
+	Convey("When pattern is added, should match it and keep previous tree untouched", t, func() {
+		previousTree := patternsStorage.PatternTree
+		patternsStorage.AddPattern("Added.{one,two}.pattern")
+		So(patternsStorage.ProcessParsedMetric("Added.two.pattern", 12, 1234567890).Patterns, ShouldResemble, []string{"Added.{one,two}.pattern"})
+		So(matchPattern(previousTree, []byte("Added.two.pattern")), ShouldBeEmpty)
+		So(matchPattern(previousTree, []byte("Simple.matching.pattern")), ShouldResemble, []string{"Simple.matching.pattern"})
+	})
+
+	Convey("When existing pattern is added, should not change the tree", t, func() {
+		currentTree := patternsStorage.PatternTree
+		patternsStorage.AddPattern("Added.{one,two}.pattern")
+		So(patternsStorage.PatternTree, ShouldPointTo, currentTree)
+	})
+
+	Convey("When pattern is removed, should stop matching it and remove unused nodes", t, func() {
+		patternsStorage.RemovePattern("Added.{one,two}.pattern")
+		So(patternsStorage.ProcessParsedMetric("Added.two.pattern", 12, 1234567890), ShouldBeNil)
+		So(patternsStorage.PatternTree.Children, ShouldHaveLength, 2)
+	})
+
+	Convey("When longer pattern is removed, shorter pattern with the same prefix should start matching", t, func() {
+		So(patternsStorage.ProcessParsedMetric("Prefix.pattern", 12, 1234567890), ShouldBeNil)
+		patternsStorage.RemovePattern("Prefix.pattern.long")
+		So(patternsStorage.ProcessParsedMetric("Prefix.pattern", 12, 1234567890).Patterns, ShouldResemble, []string{"Prefix.pattern"})
+	})
+
+	Convey("When seriesByTag pattern is added or removed, should update tagged series matching", t, func() {
+		patternsStorage.AddPattern("seriesByTag('name=~Tagged','host=web1')")
+		So(patternsStorage.ProcessParsedMetric("Tagged.metric;dc=eu;host=web1", 12, 1234567890).Patterns, ShouldResemble, []string{
+			"seriesByTag('name=Tagged.metric','dc=eu')",
+			"seriesByTag('name=~Tagged','host=web1')",
+		})
+		patternsStorage.RemovePattern("seriesByTag('name=Tagged.metric','dc=eu')")
+		So(patternsStorage.ProcessParsedMetric("Tagged.metric;dc=eu;host=web1", 12, 1234567890).Patterns, ShouldResemble, []string{
+			"seriesByTag('name=~Tagged','host=web1')",
+		})
+	})
+
+	Convey("Tree updated by events should be equal to the tree built from scratch", t, func() {
+		rebuiltStorage := &PatternStorage{}
+		rebuiltStorage.buildTree([]string{
+			"Simple.matching.pattern",
+			"Prefix.pattern",
+			"seriesByTag('name=~Tagged','host=web1')",
+		})
+		So(patternsStorage.PatternTree, ShouldResemble, rebuiltStorage.PatternTree)
+		So(patternsStorage.patterns, ShouldResemble, rebuiltStorage.patterns)
+	})
+}
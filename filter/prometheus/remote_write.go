@@ -0,0 +1,120 @@
+package prometheus
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+
+	"github.com/moira-alert/moira"
+)
+
+// MetricNameLabel is the label that holds Prometheus metric name
+const MetricNameLabel = "__name__"
+
+// Naming modes of converting Prometheus label set to Moira metric name
+const (
+	TaggedNaming   = "tagged"
+	GraphiteNaming = "graphite"
+)
+
+// MaxDecodedLength limits size of decompressed remote-write request
+const MaxDecodedLength = 64 * 1024 * 1024
+
+// ErrDecodedTooLarge is returned when snappy header claims decoded length above MaxDecodedLength
+var ErrDecodedTooLarge = errors.New("decoded write request is too large")
+
+// Sample is single Prometheus time series value, timestamp is in milliseconds
+type Sample struct {
+	Value     float64
+	Timestamp int64
+}
+
+// TimeSeries is Prometheus time series with its label set and samples
+type TimeSeries struct {
+	Labels  map[string]string
+	Samples []Sample
+}
+
+// DecodeWriteRequest decodes snappy-compressed protobuf remote-write WriteRequest
+func DecodeWriteRequest(body []byte) ([]TimeSeries, error) {
+	decodedLength, err := snappy.DecodedLen(body)
+	if err != nil {
+		return nil, err
+	}
+	if decodedLength > MaxDecodedLength {
+		return nil, ErrDecodedTooLarge
+	}
+	message, err := snappy.Decode(nil, body)
+	if err != nil {
+		return nil, err
+	}
+	var request prompb.WriteRequest
+	if err := request.Unmarshal(message); err != nil {
+		return nil, err
+	}
+	result := make([]TimeSeries, 0, len(request.Timeseries))
+	for _, timeSeries := range request.Timeseries {
+		series := TimeSeries{
+			Labels:  make(map[string]string, len(timeSeries.Labels)),
+			Samples: make([]Sample, 0, len(timeSeries.Samples)),
+		}
+		for _, label := range timeSeries.Labels {
+			series.Labels[label.Name] = label.Value
+		}
+		for _, sample := range timeSeries.Samples {
+			series.Samples = append(series.Samples, Sample{Value: sample.Value, Timestamp: sample.Timestamp})
+		}
+		result = append(result, series)
+	}
+	return result, nil
+}
+
+// MetricName converts label set to graphite tagged series "name;label=value" or,
+// with GraphiteNaming, to dotted path "name.value1.value2" with label values sorted by label name
+func (series *TimeSeries) MetricName(naming string) (string, error) {
+	name := sanitize(series.Labels[MetricNameLabel])
+	if name == "" {
+		return "", fmt.Errorf("time series has no %s label", MetricNameLabel)
+	}
+	labelNames := make([]string, 0, len(series.Labels))
+	for labelName, labelValue := range series.Labels {
+		if labelName != MetricNameLabel && labelValue != "" {
+			labelNames = append(labelNames, labelName)
+		}
+	}
+	sort.Strings(labelNames)
+
+	if naming == GraphiteNaming {
+		parts := make([]string, 0, len(labelNames)+1)
+		parts = append(parts, name)
+		for _, labelName := range labelNames {
+			parts = append(parts, strings.Replace(sanitize(series.Labels[labelName]), ".", "_", -1))
+		}
+		return strings.Join(parts, "."), nil
+	}
+
+	tags := make(map[string]string, len(labelNames))
+	for _, labelName := range labelNames {
+		tagName := labelName
+		if tagName == moira.MetricNameTag {
+			// The same way Prometheus renames conflicting labels on federation
+			tagName = "exported_" + tagName
+		}
+		tags[tagName] = sanitize(series.Labels[labelName])
+	}
+	return moira.TaggedMetricName(name, tags), nil
+}
+
+// sanitize replaces characters which are not allowed in graphite tagged series
+func sanitize(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' || r == ';' || r == '=' || r == '~' {
+			return '_'
+		}
+		return r
+	}, value)
+}
@@ -0,0 +1,79 @@
+package prometheus
+
+import (
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDecodeWriteRequest(t *testing.T) {
+	Convey("Valid write request should be decoded", t, func() {
+		series, err := DecodeWriteRequest(snappy.Encode(nil, encodeWriteRequest()))
+		So(err, ShouldBeNil)
+		So(series, ShouldResemble, []TimeSeries{
+			{
+				Labels:  map[string]string{"__name__": "http_requests_total", "code": "200", "job": "api"},
+				Samples: []Sample{{Value: 1.5, Timestamp: 1234567890000}, {Value: 2, Timestamp: 1234567900000}},
+			},
+		})
+	})
+
+	Convey("Truncated write request should return error", t, func() {
+		message := encodeWriteRequest()
+		_, err := DecodeWriteRequest(snappy.Encode(nil, message[:len(message)-3]))
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Write request claiming too large decoded length should return error", t, func() {
+		// snappy header is uvarint of decoded length: 1 GiB followed by a literal tag
+		body := []byte{0x80, 0x80, 0x80, 0x80, 0x04, 0x00, 'a'}
+		_, err := DecodeWriteRequest(body)
+		So(err, ShouldEqual, ErrDecodedTooLarge)
+	})
+
+	Convey("Not snappy-compressed write request should return error", t, func() {
+		_, err := DecodeWriteRequest([]byte{0x0d, 0x0c, 'a', 'b', 'c', 'd', 0x11, 0x04})
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestMetricName(t *testing.T) {
+	series := TimeSeries{Labels: map[string]string{"__name__": "http_requests_total", "job": "api", "path": "/v1/users.json", "name": "x"}}
+
+	Convey("Tagged naming should return tagged series with sorted tags", t, func() {
+		name, err := series.MetricName(TaggedNaming)
+		So(err, ShouldBeNil)
+		So(name, ShouldEqual, "http_requests_total;exported_name=x;job=api;path=/v1/users.json")
+	})
+
+	Convey("Graphite naming should return dotted path", t, func() {
+		name, err := series.MetricName(GraphiteNaming)
+		So(err, ShouldBeNil)
+		So(name, ShouldEqual, "http_requests_total.api.x./v1/users_json")
+	})
+
+	Convey("Series without name should return error", t, func() {
+		_, err := (&TimeSeries{Labels: map[string]string{"job": "api"}}).MetricName(TaggedNaming)
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func encodeWriteRequest() []byte {
+	request := prompb.WriteRequest{Timeseries: []*prompb.TimeSeries{
+		{
+			Labels: []*prompb.Label{
+				{Name: "__name__", Value: "http_requests_total"},
+				{Name: "code", Value: "200"},
+				{Name: "job", Value: "api"},
+			},
+			Samples: []*prompb.Sample{
+				{Value: 1.5, Timestamp: 1234567890000},
+				{Value: 2, Timestamp: 1234567900000},
+			},
+		},
+	}}
+	message, _ := request.Marshal()
+	return message
+}
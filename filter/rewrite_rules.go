@@ -0,0 +1,155 @@
+package filter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Rewrite rules actions
+const (
+	RewriteAction     = "rewrite"
+	StripPrefixAction = "strip-prefix"
+	AddPrefixAction   = "add-prefix"
+	DropAction        = "drop"
+	AllowAction       = "allow"
+)
+
+// RewriteRules is ordered chain of rules which rewrite or drop metric names before pattern matching
+type RewriteRules struct {
+	rules []rewriteRule
+}
+
+// rewriteRule returns new metric name and false if metric must be dropped
+type rewriteRule interface {
+	apply(metric string) (string, bool)
+}
+
+type regexRewriteRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+func (rule *regexRewriteRule) apply(metric string) (string, bool) {
+	if !rule.pattern.MatchString(metric) {
+		return metric, true
+	}
+	return rule.pattern.ReplaceAllString(metric, rule.replacement), true
+}
+
+type stripPrefixRule struct {
+	prefix string
+}
+
+func (rule *stripPrefixRule) apply(metric string) (string, bool) {
+	return strings.TrimPrefix(metric, rule.prefix), true
+}
+
+type addPrefixRule struct {
+	prefix string
+}
+
+func (rule *addPrefixRule) apply(metric string) (string, bool) {
+	return rule.prefix + metric, true
+}
+
+type dropRule struct {
+	pattern *regexp.Regexp
+}
+
+func (rule *dropRule) apply(metric string) (string, bool) {
+	return metric, !rule.pattern.MatchString(metric)
+}
+
+// allowRule keeps only metrics matching any of patterns, consecutive allow lines are joined into one rule
+type allowRule struct {
+	patterns []*regexp.Regexp
+}
+
+func (rule *allowRule) apply(metric string) (string, bool) {
+	for _, pattern := range rule.patterns {
+		if pattern.MatchString(metric) {
+			return metric, true
+		}
+	}
+	return metric, false
+}
+
+// ParseRewriteRules reads rules, one per line in form "<action> <arguments>", where action is
+// rewrite with regex and replacement, strip-prefix or add-prefix with prefix, drop or allow with regex.
+// Empty lines and lines starting with # are skipped
+func ParseRewriteRules(reader io.Reader) (*RewriteRules, error) {
+	rules := &RewriteRules{rules: make([]rewriteRule, 0)}
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule, err := parseRewriteRule(strings.Fields(line))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid rewrite rule at line %d: %s", lineNumber, err.Error())
+		}
+		if allow, ok := rule.(*allowRule); ok && len(rules.rules) > 0 {
+			if previous, ok := rules.rules[len(rules.rules)-1].(*allowRule); ok {
+				previous.patterns = append(previous.patterns, allow.patterns...)
+				continue
+			}
+		}
+		rules.rules = append(rules.rules, rule)
+	}
+	return rules, scanner.Err()
+}
+
+func parseRewriteRule(fields []string) (rewriteRule, error) {
+	action, arguments := fields[0], fields[1:]
+	switch action {
+	case RewriteAction:
+		if len(arguments) != 2 {
+			return nil, fmt.Errorf("%s requires regex and replacement", action)
+		}
+		pattern, err := regexp.Compile(arguments[0])
+		if err != nil {
+			return nil, err
+		}
+		return &regexRewriteRule{pattern: pattern, replacement: arguments[1]}, nil
+	case StripPrefixAction, AddPrefixAction:
+		if len(arguments) != 1 {
+			return nil, fmt.Errorf("%s requires prefix", action)
+		}
+		if action == StripPrefixAction {
+			return &stripPrefixRule{prefix: arguments[0]}, nil
+		}
+		return &addPrefixRule{prefix: arguments[0]}, nil
+	case DropAction, AllowAction:
+		if len(arguments) != 1 {
+			return nil, fmt.Errorf("%s requires regex", action)
+		}
+		pattern, err := regexp.Compile(arguments[0])
+		if err != nil {
+			return nil, err
+		}
+		if action == DropAction {
+			return &dropRule{pattern: pattern}, nil
+		}
+		return &allowRule{patterns: []*regexp.Regexp{pattern}}, nil
+	}
+	return nil, fmt.Errorf("unknown action '%s'", action)
+}
+
+// Apply passes metric name through all rules in order.
+// Returns rewritten metric name and false if metric was dropped
+func (rules *RewriteRules) Apply(metric string) (string, bool) {
+	var keep bool
+	for _, rule := range rules.rules {
+		metric, keep = rule.apply(metric)
+		if !keep {
+			return "", false
+		}
+	}
+	return metric, metric != ""
+}
@@ -0,0 +1,83 @@
+package filter
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseRewriteRules(t *testing.T) {
+	Convey("Given invalid rules, should return errors", t, func() {
+		invalidRules := []string{
+			"unknown ^a",
+			"rewrite ^a",
+			"rewrite ^a b c",
+			"rewrite [a b",
+			"strip-prefix",
+			"add-prefix a. b.",
+			"drop",
+			"allow [a",
+		}
+		for _, invalidRule := range invalidRules {
+			rules, err := ParseRewriteRules(strings.NewReader(invalidRule))
+			So(err, ShouldNotBeNil)
+			So(rules, ShouldBeNil)
+		}
+	})
+
+	Convey("Given rules with comments and empty lines, should skip them", t, func() {
+		rules, err := ParseRewriteRules(strings.NewReader("# comment\n\n  drop ^a\\.\n"))
+		So(err, ShouldBeNil)
+		So(rules.rules, ShouldHaveLength, 1)
+	})
+
+	Convey("Given consecutive allow rules, should join them into one allow-list", t, func() {
+		rules, err := ParseRewriteRules(strings.NewReader("allow ^a\\.\nallow ^b\\.\ndrop ^b\\.c\nallow ^b\\."))
+		So(err, ShouldBeNil)
+		So(rules.rules, ShouldHaveLength, 3)
+		So(rules.rules[0].(*allowRule).patterns, ShouldHaveLength, 2)
+	})
+}
+
+func TestApplyRewriteRules(t *testing.T) {
+	rules, err := ParseRewriteRules(strings.NewReader(`
+strip-prefix prod.
+rewrite ^(stage|test)\.(.*)$ $2
+drop ^debug\.
+allow ^servers\.
+allow ^apps\.
+add-prefix teams.
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Convey("Should rewrite metric names in order", t, func() {
+		cases := map[string]string{
+			"prod.servers.cpu":  "teams.servers.cpu",
+			"stage.apps.rps":    "teams.apps.rps",
+			"servers.prod.cpu":  "teams.servers.prod.cpu",
+			"prod.stage.apps.a": "teams.apps.a",
+		}
+		for metric, expected := range cases {
+			actual, keep := rules.Apply(metric)
+			So(keep, ShouldBeTrue)
+			So(actual, ShouldEqual, expected)
+		}
+	})
+
+	Convey("Should drop metrics matching drop rule or not matching allow-list", t, func() {
+		for _, metric := range []string{"debug.servers.cpu", "prod.debug.apps", "other.cpu", "prod.other.cpu"} {
+			actual, keep := rules.Apply(metric)
+			So(keep, ShouldBeFalse)
+			So(actual, ShouldBeEmpty)
+		}
+	})
+
+	Convey("Should drop metric rewritten to empty name", t, func() {
+		stripRules, _ := ParseRewriteRules(strings.NewReader("strip-prefix prod"))
+		_, keep := stripRules.Apply("prod")
+		So(keep, ShouldBeFalse)
+	})
+}
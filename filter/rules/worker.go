@@ -0,0 +1,94 @@
+package rules
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/tomb.v2"
+
+	"github.com/moira-alert/moira"
+	"github.com/moira-alert/moira/filter"
+)
+
+const checkInterval = 10 * time.Second
+
+// RewriteRulesWorker loads metric rewrite rules and reloads them every time rules file changes
+type RewriteRulesWorker struct {
+	logger         moira.Logger
+	patternStorage *filter.PatternStorage
+	fileName       string
+	modTime        time.Time
+	tomb           tomb.Tomb
+}
+
+// NewRewriteRulesWorker creates new RewriteRulesWorker
+func NewRewriteRulesWorker(logger moira.Logger, patternStorage *filter.PatternStorage, fileName string) *RewriteRulesWorker {
+	return &RewriteRulesWorker{
+		logger:         logger,
+		patternStorage: patternStorage,
+		fileName:       fileName,
+	}
+}
+
+// Start loads rewrite rules and starts checking rules file for changes.
+// Rules which failed to reload are logged and previous rules are kept
+func (worker *RewriteRulesWorker) Start() error {
+	fileInfo, err := os.Stat(worker.fileName)
+	if err != nil {
+		return fmt.Errorf("Failed to stat rewrite rules file: %s", err.Error())
+	}
+	if err := worker.loadRules(fileInfo); err != nil {
+		return err
+	}
+
+	worker.tomb.Go(func() error {
+		checkTicker := time.NewTicker(checkInterval)
+		defer checkTicker.Stop()
+		for {
+			select {
+			case <-worker.tomb.Dying():
+				worker.logger.Info("Moira Filter Rewrite Rules Reloader stopped")
+				return nil
+			case <-checkTicker.C:
+				fileInfo, err := os.Stat(worker.fileName)
+				if err != nil {
+					worker.logger.Errorf("Failed to stat rewrite rules file: %s", err.Error())
+					continue
+				}
+				if fileInfo.ModTime().Equal(worker.modTime) {
+					continue
+				}
+				if err := worker.loadRules(fileInfo); err != nil {
+					worker.logger.Errorf("Rewrite rules reload failed: %s", err.Error())
+					continue
+				}
+				worker.logger.Infof("Rewrite rules reloaded from [%s]", worker.fileName)
+			}
+		}
+	})
+	worker.logger.Info("Moira Filter Rewrite Rules Reloader started")
+	return nil
+}
+
+func (worker *RewriteRulesWorker) loadRules(fileInfo os.FileInfo) error {
+	// Remember modification time even if file is invalid not to report the same error every check
+	worker.modTime = fileInfo.ModTime()
+	file, err := os.Open(worker.fileName)
+	if err != nil {
+		return fmt.Errorf("Failed to open rewrite rules file: %s", err.Error())
+	}
+	defer file.Close()
+	rewriteRules, err := filter.ParseRewriteRules(file)
+	if err != nil {
+		return err
+	}
+	worker.patternStorage.SetRewriteRules(rewriteRules)
+	return nil
+}
+
+// Stop stops checking rules file for changes
+func (worker *RewriteRulesWorker) Stop() error {
+	worker.tomb.Kill(nil)
+	return worker.tomb.Wait()
+}
@@ -0,0 +1,131 @@
+package filter
+
+import (
+	"github.com/moira-alert/moira"
+)
+
+// seriesByTagPattern contains seriesByTag pattern and its compiled matcher
+type seriesByTagPattern struct {
+	pattern string
+	matcher *moira.SeriesByTagMatcher
+}
+
+// seriesByTagIndex contains seriesByTag patterns grouped by exact metric name they require
+// so tagged series is checked only against patterns which can match it
+type seriesByTagIndex struct {
+	byName map[string][]*seriesByTagPattern
+	other  []*seriesByTagPattern
+}
+
+func newSeriesByTagIndex(patterns []string) *seriesByTagIndex {
+	index := &seriesByTagIndex{
+		byName: make(map[string][]*seriesByTagPattern),
+		other:  make([]*seriesByTagPattern, 0),
+	}
+	for _, pattern := range patterns {
+		compiled, name, err := compileSeriesByTagPattern(pattern)
+		if err != nil {
+			continue
+		}
+		if name != "" {
+			index.byName[name] = append(index.byName[name], compiled)
+		} else {
+			index.other = append(index.other, compiled)
+		}
+	}
+	return index
+}
+
+// compileSeriesByTagPattern returns compiled pattern and exact metric name it requires, if any
+func compileSeriesByTagPattern(pattern string) (*seriesByTagPattern, string, error) {
+	tagSpecs, err := moira.ParseSeriesByTag(pattern)
+	if err != nil {
+		return nil, "", err
+	}
+	matcher, err := moira.NewSeriesByTagMatcher(tagSpecs)
+	if err != nil {
+		return nil, "", err
+	}
+	name, _ := getExactMetricName(tagSpecs)
+	return &seriesByTagPattern{pattern: pattern, matcher: matcher}, name, nil
+}
+
+// withPattern returns copy of index with pattern added, original index is not changed
+func (index *seriesByTagIndex) withPattern(pattern string) *seriesByTagIndex {
+	compiled, name, err := compileSeriesByTagPattern(pattern)
+	if err != nil {
+		return index
+	}
+	newIndex := index.copyIndex()
+	if name != "" {
+		newIndex.byName[name] = appendCopy(index.byName[name], compiled)
+	} else {
+		newIndex.other = appendCopy(index.other, compiled)
+	}
+	return newIndex
+}
+
+// withoutPattern returns copy of index with pattern removed, original index is not changed
+func (index *seriesByTagIndex) withoutPattern(pattern string) *seriesByTagIndex {
+	newIndex := index.copyIndex()
+	for name, compiledPatterns := range index.byName {
+		newIndex.byName[name] = removeCopy(compiledPatterns, pattern)
+		if len(newIndex.byName[name]) == 0 {
+			delete(newIndex.byName, name)
+		}
+	}
+	newIndex.other = removeCopy(index.other, pattern)
+	return newIndex
+}
+
+func (index *seriesByTagIndex) copyIndex() *seriesByTagIndex {
+	newIndex := &seriesByTagIndex{
+		byName: make(map[string][]*seriesByTagPattern, len(index.byName)),
+		other:  index.other,
+	}
+	for name, compiledPatterns := range index.byName {
+		newIndex.byName[name] = compiledPatterns
+	}
+	return newIndex
+}
+
+func appendCopy(compiledPatterns []*seriesByTagPattern, compiled *seriesByTagPattern) []*seriesByTagPattern {
+	result := make([]*seriesByTagPattern, 0, len(compiledPatterns)+1)
+	result = append(result, compiledPatterns...)
+	return append(result, compiled)
+}
+
+func removeCopy(compiledPatterns []*seriesByTagPattern, pattern string) []*seriesByTagPattern {
+	result := make([]*seriesByTagPattern, 0, len(compiledPatterns))
+	for _, compiled := range compiledPatterns {
+		if compiled.pattern != pattern {
+			result = append(result, compiled)
+		}
+	}
+	return result
+}
+
+// match returns all seriesByTag patterns matching tagged series with given name and tags
+func (index *seriesByTagIndex) match(name string, tags map[string]string) []string {
+	matched := make([]string, 0)
+	for _, compiled := range index.byName[name] {
+		if compiled.matcher.Match(name, tags) {
+			matched = append(matched, compiled.pattern)
+		}
+	}
+	for _, compiled := range index.other {
+		if compiled.matcher.Match(name, tags) {
+			matched = append(matched, compiled.pattern)
+		}
+	}
+	return matched
+}
+
+func getExactMetricName(tagSpecs []moira.TagSpec) (string, bool) {
+	for _, tagSpec := range tagSpecs {
+		if tagSpec.Name == moira.MetricNameTag && tagSpec.Operator == moira.EqualOperator {
+			return tagSpec.Value, true
+		}
+	}
+	return "", false
+}
@@ -91,13 +91,14 @@ func TestNotifier(t *testing.T) {
 		Database:  database,
 		Logger:    logger,
 		Metrics:   notifierMetrics,
-		Scheduler: notifier.NewScheduler(database, logger, notifierMetrics),
+		Scheduler: notifier.NewScheduler(database, logger, notifierMetrics, nil),
 	}
 
 	fetchNotificationsWorker := notifications.FetchNotificationsWorker{
-		Database: database,
-		Logger:   logger,
-		Notifier: notifier2,
+		Database:     database,
+		Logger:       logger,
+		Notifier:     notifier2,
+		LeaseTimeout: time.Minute,
 	}
 
 	fetchEventsWorker.Start()
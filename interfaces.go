@@ -24,9 +24,13 @@ type Database interface {
 	RemoveTriggerLastCheck(triggerID string) error
 	GetTriggerCheckIDs(tags []string, onlyErrors bool) ([]string, error)
 	SetTriggerCheckMetricsMaintenance(triggerID string, metrics map[string]int64) error
+	SetTriggerCheckAcknowledgement(triggerID, metric string, acknowledgement *Acknowledgement) error
 
 	// Trigger storing
 	GetTriggerIDs() ([]string, error)
+	GetRemoteTriggerIDs() ([]string, error)
+	GetPrometheusTriggerIDs() ([]string, error)
+	GetScheduledTriggerIDs() ([]string, error)
 	GetTrigger(triggerID string) (Trigger, error)
 	GetTriggers(triggerIDs []string) ([]*Trigger, error)
 	GetTriggerChecks(triggerIDs []string) ([]*TriggerCheck, error)
@@ -37,8 +41,12 @@ type Database interface {
 
 	// Throttling
 	GetTriggerThrottling(triggerID string) (time.Time, time.Time)
-	SetTriggerThrottling(triggerID string, next time.Time) error
+	SetTriggerThrottling(triggerID string, next time.Time, reason string) error
+	GetTriggerThrottlingReason(triggerID string) (string, error)
 	DeleteTriggerThrottling(triggerID string) error
+	GetSubscriptionThrottling(triggerID, subscriptionID string) time.Time
+	GetSubscriptionsThrottling(triggerID string) (map[string]time.Time, error)
+	SetSubscriptionThrottling(triggerID, subscriptionID string, next time.Time) error
 
 	// NotificationEvent storing
 	GetNotificationEvents(triggerID string, start, size int64) ([]*NotificationEvent, error)
@@ -68,22 +76,28 @@ type Database interface {
 	GetNotifications(start, end int64) ([]*ScheduledNotification, int64, error)
 	RemoveNotification(notificationKey string) (int64, error)
 	RemoveAllNotifications() error
-	FetchNotifications(to int64) ([]*ScheduledNotification, error)
+	FetchNotifications(to, leaseDeadline int64) ([]*ScheduledNotification, error)
+	AckNotifications(notifications []*ScheduledNotification) error
 	AddNotification(notification *ScheduledNotification) error
 	AddNotifications(notification []*ScheduledNotification, timestamp int64) error
+	RemoveEscalationNotifications(triggerID, metric string) (int64, error)
 
 	// Patterns and metrics storing
 	GetPatterns() ([]string, error)
 	AddPatternMetric(pattern, metric string) error
 	GetPatternMetrics(pattern string) ([]string, error)
+	GetTaggedMetrics(tagSpecs []TagSpec) ([]string, error)
 	RemovePattern(pattern string) error
 	RemovePatternsMetrics(pattern []string) error
 	RemovePatternWithMetrics(pattern string) error
 
 	SubscribeMetricEvents(tomb *tomb.Tomb) (<-chan *MetricEvent, error)
+	SubscribePatternEvents(tomb *tomb.Tomb) (<-chan *PatternEvent, error)
 	SaveMetrics(buffer map[string]*MatchedMetric) error
 	GetMetricRetention(metric string) (int64, error)
 	GetMetricsValues(metrics []string, from int64, until int64) (map[string][]*MetricValue, error)
+	GetMetricArchives(metric string) ([]MetricArchive, error)
+	GetMetricsArchiveValues(metrics []string, precision int64, from int64, until int64) (map[string][]*MetricValue, error)
 	RemoveMetricValues(metric string, toTime int64) error
 	RemoveMetricsValues(metrics []string, toTime int64) error
 
@@ -92,6 +106,11 @@ type Database interface {
 	DeleteTriggerCheckLock(triggerID string) error
 	SetTriggerCheckLock(triggerID string) (bool, error)
 
+	// Checker members storing
+	UpdateCheckerMember(memberID string, timestamp int64) error
+	GetCheckerMembers(aliveSince int64) ([]string, error)
+	RemoveCheckerMember(memberID string) error
+
 	// Bot data storing
 	GetIDByUsername(messenger, username string) (string, error)
 	SetUsernameID(messenger, username, id string) error
@@ -121,3 +140,9 @@ type Sender interface {
 	SendEvents(events NotificationEvents, contact ContactData, trigger TriggerData, throttled bool) error
 	Init(senderSettings map[string]string, logger Logger, location *time.Location, dateTimeFormat string) error
 }
+
+// DigestSender is implemented by senders able to send events of several triggers in one message,
+// throttledTriggers contains IDs of triggers which notifications are throttled
+type DigestSender interface {
+	SendDigest(events NotificationEvents, contact ContactData, triggers []TriggerData, throttledTriggers map[string]bool) error
+}
@@ -9,4 +9,12 @@ type FilterMetrics struct {
 	SavingTimer             Timer
 	BuildTreeTimer          Timer
 	MetricChannelLen        Histogram
+	ConnectionsActive       Counter
+	ConnectionsRejected     Meter
+	SourceLinesReceived     MeterMap
+	SourceLinesInvalid      MeterMap
+	SourceLinesMatching     MeterMap
+	SourceLinesDropped      MeterMap
+	SpillQueueDepth         Gauge
+	SpillDroppedPoints      Counter
 }
@@ -16,6 +16,14 @@ func ConfigureFilterMetrics(prefix string) *graphite.FilterMetrics {
 		SavingTimer:             registerTimer(metricNameWithPrefix(prefix, "time.save")),
 		BuildTreeTimer:          registerTimer(metricNameWithPrefix(prefix, "time.buildtree")),
 		MetricChannelLen:        registerHistogram(metricNameWithPrefix(prefix, "metricsToSave")),
+		ConnectionsActive:       registerCounter(metricNameWithPrefix(prefix, "connections.active")),
+		ConnectionsRejected:     registerMeter(metricNameWithPrefix(prefix, "connections.rejected")),
+		SourceLinesReceived:     newPrefixedMeterMap(metricNameWithPrefix(prefix, "sources.received")),
+		SourceLinesInvalid:      newPrefixedMeterMap(metricNameWithPrefix(prefix, "sources.invalid")),
+		SourceLinesMatching:     newPrefixedMeterMap(metricNameWithPrefix(prefix, "sources.matching")),
+		SourceLinesDropped:      newPrefixedMeterMap(metricNameWithPrefix(prefix, "sources.dropped")),
+		SpillQueueDepth:         registerGauge(metricNameWithPrefix(prefix, "spill.depth")),
+		SpillDroppedPoints:      registerCounter(metricNameWithPrefix(prefix, "spill.dropped")),
 	}
 }
 
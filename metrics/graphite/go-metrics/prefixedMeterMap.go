@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"strings"
+	"sync"
+
+	goMetrics "github.com/rcrowley/go-metrics"
+
+	"github.com/moira-alert/moira/metrics/graphite"
+)
+
+// PrefixedMeterMap is realization of metrics map of type Meter, which registers meters under common prefix on first use
+type PrefixedMeterMap struct {
+	metrics map[string]Meter
+	paths   map[string]string
+	addLock sync.Mutex
+	prefix  string
+}
+
+// newPrefixedMeterMap create empty Meter map
+func newPrefixedMeterMap(prefix string) *PrefixedMeterMap {
+	return &PrefixedMeterMap{
+		metrics: make(map[string]Meter),
+		paths:   make(map[string]string),
+		prefix:  prefix,
+	}
+}
+
+// GetOrAdd gets meter and, if it does not exists, add it do map
+func (meterMap *PrefixedMeterMap) GetOrAdd(name, graphitePath string) graphite.Meter {
+	if _, ok := meterMap.metrics[name]; !ok {
+		meterMap.addLock.Lock()
+		defer meterMap.addLock.Unlock()
+		if _, ok := meterMap.metrics[name]; !ok {
+			newMetricsMap := make(map[string]Meter, len(meterMap.metrics)+1)
+			for k, v := range meterMap.metrics {
+				newMetricsMap[k] = v
+			}
+			path := metricNameWithPrefix(meterMap.prefix, strings.Replace(graphitePath, "-", "_", -1))
+			newMetricsMap[name] = *registerMeter(path)
+			meterMap.paths[name] = path
+			meterMap.metrics = newMetricsMap
+		}
+	}
+	value := meterMap.metrics[name]
+	return &value
+}
+
+// Remove removes meter from map and unregisters it, so it is not reported anymore
+func (meterMap *PrefixedMeterMap) Remove(name string) {
+	meterMap.addLock.Lock()
+	defer meterMap.addLock.Unlock()
+	if _, ok := meterMap.metrics[name]; !ok {
+		return
+	}
+	newMetricsMap := make(map[string]Meter, len(meterMap.metrics))
+	for k, v := range meterMap.metrics {
+		if k != name {
+			newMetricsMap[k] = v
+		}
+	}
+	goMetrics.DefaultRegistry.Unregister(meterMap.paths[name])
+	delete(meterMap.paths, name)
+	meterMap.metrics = newMetricsMap
+}
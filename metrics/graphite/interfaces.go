@@ -8,6 +8,12 @@ type MetricsMap interface {
 	GetMetric(name string) (Meter, bool)
 }
 
+// MeterMap implements meter collection abstraction which registers meters on first use
+type MeterMap interface {
+	GetOrAdd(name, graphitePath string) Meter
+	Remove(name string)
+}
+
 // TimerMap implements timer collection abstraction
 type TimerMap interface {
 	GetOrAdd(name, graphitePath string) Timer
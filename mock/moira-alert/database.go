@@ -36,6 +36,18 @@ func (m *MockDatabase) EXPECT() *MockDatabaseMockRecorder {
 	return m.recorder
 }
 
+// AckNotifications mocks base method
+func (m *MockDatabase) AckNotifications(arg0 []*moira.ScheduledNotification) error {
+	ret := m.ctrl.Call(m, "AckNotifications", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AckNotifications indicates an expected call of AckNotifications
+func (mr *MockDatabaseMockRecorder) AckNotifications(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AckNotifications", reflect.TypeOf((*MockDatabase)(nil).AckNotifications), arg0)
+}
+
 // AcquireTriggerCheckLock mocks base method
 func (m *MockDatabase) AcquireTriggerCheckLock(arg0 string, arg1 int) error {
 	ret := m.ctrl.Call(m, "AcquireTriggerCheckLock", arg0, arg1)
@@ -144,16 +156,16 @@ func (mr *MockDatabaseMockRecorder) FetchNotificationEvent() *gomock.Call {
 }
 
 // FetchNotifications mocks base method
-func (m *MockDatabase) FetchNotifications(arg0 int64) ([]*moira.ScheduledNotification, error) {
-	ret := m.ctrl.Call(m, "FetchNotifications", arg0)
+func (m *MockDatabase) FetchNotifications(arg0, arg1 int64) ([]*moira.ScheduledNotification, error) {
+	ret := m.ctrl.Call(m, "FetchNotifications", arg0, arg1)
 	ret0, _ := ret[0].([]*moira.ScheduledNotification)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // FetchNotifications indicates an expected call of FetchNotifications
-func (mr *MockDatabaseMockRecorder) FetchNotifications(arg0 interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchNotifications", reflect.TypeOf((*MockDatabase)(nil).FetchNotifications), arg0)
+func (mr *MockDatabaseMockRecorder) FetchNotifications(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchNotifications", reflect.TypeOf((*MockDatabase)(nil).FetchNotifications), arg0, arg1)
 }
 
 // GetAllContacts mocks base method
@@ -169,6 +181,19 @@ func (mr *MockDatabaseMockRecorder) GetAllContacts() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllContacts", reflect.TypeOf((*MockDatabase)(nil).GetAllContacts))
 }
 
+// GetCheckerMembers mocks base method
+func (m *MockDatabase) GetCheckerMembers(arg0 int64) ([]string, error) {
+	ret := m.ctrl.Call(m, "GetCheckerMembers", arg0)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCheckerMembers indicates an expected call of GetCheckerMembers
+func (mr *MockDatabaseMockRecorder) GetCheckerMembers(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCheckerMembers", reflect.TypeOf((*MockDatabase)(nil).GetCheckerMembers), arg0)
+}
+
 // GetChecksUpdatesCount mocks base method
 func (m *MockDatabase) GetChecksUpdatesCount() (int64, error) {
 	ret := m.ctrl.Call(m, "GetChecksUpdatesCount")
@@ -221,6 +246,19 @@ func (mr *MockDatabaseMockRecorder) GetIDByUsername(arg0, arg1 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIDByUsername", reflect.TypeOf((*MockDatabase)(nil).GetIDByUsername), arg0, arg1)
 }
 
+// GetMetricArchives mocks base method
+func (m *MockDatabase) GetMetricArchives(arg0 string) ([]moira.MetricArchive, error) {
+	ret := m.ctrl.Call(m, "GetMetricArchives", arg0)
+	ret0, _ := ret[0].([]moira.MetricArchive)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMetricArchives indicates an expected call of GetMetricArchives
+func (mr *MockDatabaseMockRecorder) GetMetricArchives(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMetricArchives", reflect.TypeOf((*MockDatabase)(nil).GetMetricArchives), arg0)
+}
+
 // GetMetricRetention mocks base method
 func (m *MockDatabase) GetMetricRetention(arg0 string) (int64, error) {
 	ret := m.ctrl.Call(m, "GetMetricRetention", arg0)
@@ -234,6 +272,19 @@ func (mr *MockDatabaseMockRecorder) GetMetricRetention(arg0 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMetricRetention", reflect.TypeOf((*MockDatabase)(nil).GetMetricRetention), arg0)
 }
 
+// GetMetricsArchiveValues mocks base method
+func (m *MockDatabase) GetMetricsArchiveValues(arg0 []string, arg1 int64, arg2 int64, arg3 int64) (map[string][]*moira.MetricValue, error) {
+	ret := m.ctrl.Call(m, "GetMetricsArchiveValues", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(map[string][]*moira.MetricValue)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMetricsArchiveValues indicates an expected call of GetMetricsArchiveValues
+func (mr *MockDatabaseMockRecorder) GetMetricsArchiveValues(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMetricsArchiveValues", reflect.TypeOf((*MockDatabase)(nil).GetMetricsArchiveValues), arg0, arg1, arg2, arg3)
+}
+
 // GetMetricsUpdatesCount mocks base method
 func (m *MockDatabase) GetMetricsUpdatesCount() (int64, error) {
 	ret := m.ctrl.Call(m, "GetMetricsUpdatesCount")
@@ -338,6 +389,45 @@ func (mr *MockDatabaseMockRecorder) GetPatterns() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPatterns", reflect.TypeOf((*MockDatabase)(nil).GetPatterns))
 }
 
+// GetPrometheusTriggerIDs mocks base method
+func (m *MockDatabase) GetPrometheusTriggerIDs() ([]string, error) {
+	ret := m.ctrl.Call(m, "GetPrometheusTriggerIDs")
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPrometheusTriggerIDs indicates an expected call of GetPrometheusTriggerIDs
+func (mr *MockDatabaseMockRecorder) GetPrometheusTriggerIDs() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPrometheusTriggerIDs", reflect.TypeOf((*MockDatabase)(nil).GetPrometheusTriggerIDs))
+}
+
+// GetRemoteTriggerIDs mocks base method
+func (m *MockDatabase) GetRemoteTriggerIDs() ([]string, error) {
+	ret := m.ctrl.Call(m, "GetRemoteTriggerIDs")
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRemoteTriggerIDs indicates an expected call of GetRemoteTriggerIDs
+func (mr *MockDatabaseMockRecorder) GetRemoteTriggerIDs() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRemoteTriggerIDs", reflect.TypeOf((*MockDatabase)(nil).GetRemoteTriggerIDs))
+}
+
+// GetScheduledTriggerIDs mocks base method
+func (m *MockDatabase) GetScheduledTriggerIDs() ([]string, error) {
+	ret := m.ctrl.Call(m, "GetScheduledTriggerIDs")
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScheduledTriggerIDs indicates an expected call of GetScheduledTriggerIDs
+func (mr *MockDatabaseMockRecorder) GetScheduledTriggerIDs() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScheduledTriggerIDs", reflect.TypeOf((*MockDatabase)(nil).GetScheduledTriggerIDs))
+}
+
 // GetSubscription mocks base method
 func (m *MockDatabase) GetSubscription(arg0 string) (moira.SubscriptionData, error) {
 	ret := m.ctrl.Call(m, "GetSubscription", arg0)
@@ -364,6 +454,31 @@ func (mr *MockDatabaseMockRecorder) GetSubscriptions(arg0 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubscriptions", reflect.TypeOf((*MockDatabase)(nil).GetSubscriptions), arg0)
 }
 
+// GetSubscriptionThrottling mocks base method
+func (m *MockDatabase) GetSubscriptionThrottling(arg0, arg1 string) time.Time {
+	ret := m.ctrl.Call(m, "GetSubscriptionThrottling", arg0, arg1)
+	ret0, _ := ret[0].(time.Time)
+	return ret0
+}
+
+// GetSubscriptionThrottling indicates an expected call of GetSubscriptionThrottling
+func (mr *MockDatabaseMockRecorder) GetSubscriptionThrottling(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubscriptionThrottling", reflect.TypeOf((*MockDatabase)(nil).GetSubscriptionThrottling), arg0, arg1)
+}
+
+// GetSubscriptionsThrottling mocks base method
+func (m *MockDatabase) GetSubscriptionsThrottling(arg0 string) (map[string]time.Time, error) {
+	ret := m.ctrl.Call(m, "GetSubscriptionsThrottling", arg0)
+	ret0, _ := ret[0].(map[string]time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSubscriptionsThrottling indicates an expected call of GetSubscriptionsThrottling
+func (mr *MockDatabaseMockRecorder) GetSubscriptionsThrottling(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubscriptionsThrottling", reflect.TypeOf((*MockDatabase)(nil).GetSubscriptionsThrottling), arg0)
+}
+
 // GetTagNames mocks base method
 func (m *MockDatabase) GetTagNames() ([]string, error) {
 	ret := m.ctrl.Call(m, "GetTagNames")
@@ -390,6 +505,19 @@ func (mr *MockDatabaseMockRecorder) GetTagTriggerIDs(arg0 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTagTriggerIDs", reflect.TypeOf((*MockDatabase)(nil).GetTagTriggerIDs), arg0)
 }
 
+// GetTaggedMetrics mocks base method
+func (m *MockDatabase) GetTaggedMetrics(arg0 []moira.TagSpec) ([]string, error) {
+	ret := m.ctrl.Call(m, "GetTaggedMetrics", arg0)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTaggedMetrics indicates an expected call of GetTaggedMetrics
+func (mr *MockDatabaseMockRecorder) GetTaggedMetrics(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTaggedMetrics", reflect.TypeOf((*MockDatabase)(nil).GetTaggedMetrics), arg0)
+}
+
 // GetTagsSubscriptions mocks base method
 func (m *MockDatabase) GetTagsSubscriptions(arg0 []string) ([]*moira.SubscriptionData, error) {
 	ret := m.ctrl.Call(m, "GetTagsSubscriptions", arg0)
@@ -481,6 +609,19 @@ func (mr *MockDatabaseMockRecorder) GetTriggerThrottling(arg0 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTriggerThrottling", reflect.TypeOf((*MockDatabase)(nil).GetTriggerThrottling), arg0)
 }
 
+// GetTriggerThrottlingReason mocks base method
+func (m *MockDatabase) GetTriggerThrottlingReason(arg0 string) (string, error) {
+	ret := m.ctrl.Call(m, "GetTriggerThrottlingReason", arg0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTriggerThrottlingReason indicates an expected call of GetTriggerThrottlingReason
+func (mr *MockDatabaseMockRecorder) GetTriggerThrottlingReason(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTriggerThrottlingReason", reflect.TypeOf((*MockDatabase)(nil).GetTriggerThrottlingReason), arg0)
+}
+
 // GetTriggers mocks base method
 func (m *MockDatabase) GetTriggers(arg0 []string) ([]*moira.Trigger, error) {
 	ret := m.ctrl.Call(m, "GetTriggers", arg0)
@@ -568,6 +709,18 @@ func (mr *MockDatabaseMockRecorder) RemoveAllNotifications() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveAllNotifications", reflect.TypeOf((*MockDatabase)(nil).RemoveAllNotifications))
 }
 
+// RemoveCheckerMember mocks base method
+func (m *MockDatabase) RemoveCheckerMember(arg0 string) error {
+	ret := m.ctrl.Call(m, "RemoveCheckerMember", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveCheckerMember indicates an expected call of RemoveCheckerMember
+func (mr *MockDatabaseMockRecorder) RemoveCheckerMember(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveCheckerMember", reflect.TypeOf((*MockDatabase)(nil).RemoveCheckerMember), arg0)
+}
+
 // RemoveContact mocks base method
 func (m *MockDatabase) RemoveContact(arg0 string) error {
 	ret := m.ctrl.Call(m, "RemoveContact", arg0)
@@ -580,6 +733,19 @@ func (mr *MockDatabaseMockRecorder) RemoveContact(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveContact", reflect.TypeOf((*MockDatabase)(nil).RemoveContact), arg0)
 }
 
+// RemoveEscalationNotifications mocks base method
+func (m *MockDatabase) RemoveEscalationNotifications(arg0, arg1 string) (int64, error) {
+	ret := m.ctrl.Call(m, "RemoveEscalationNotifications", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveEscalationNotifications indicates an expected call of RemoveEscalationNotifications
+func (mr *MockDatabaseMockRecorder) RemoveEscalationNotifications(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveEscalationNotifications", reflect.TypeOf((*MockDatabase)(nil).RemoveEscalationNotifications), arg0, arg1)
+}
+
 // RemoveMetricValues mocks base method
 func (m *MockDatabase) RemoveMetricValues(arg0 string, arg1 int64) error {
 	ret := m.ctrl.Call(m, "RemoveMetricValues", arg0, arg1)
@@ -797,6 +963,30 @@ func (mr *MockDatabaseMockRecorder) SaveTrigger(arg0, arg1 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveTrigger", reflect.TypeOf((*MockDatabase)(nil).SaveTrigger), arg0, arg1)
 }
 
+// SetSubscriptionThrottling mocks base method
+func (m *MockDatabase) SetSubscriptionThrottling(arg0, arg1 string, arg2 time.Time) error {
+	ret := m.ctrl.Call(m, "SetSubscriptionThrottling", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSubscriptionThrottling indicates an expected call of SetSubscriptionThrottling
+func (mr *MockDatabaseMockRecorder) SetSubscriptionThrottling(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSubscriptionThrottling", reflect.TypeOf((*MockDatabase)(nil).SetSubscriptionThrottling), arg0, arg1, arg2)
+}
+
+// SetTriggerCheckAcknowledgement mocks base method
+func (m *MockDatabase) SetTriggerCheckAcknowledgement(arg0, arg1 string, arg2 *moira.Acknowledgement) error {
+	ret := m.ctrl.Call(m, "SetTriggerCheckAcknowledgement", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetTriggerCheckAcknowledgement indicates an expected call of SetTriggerCheckAcknowledgement
+func (mr *MockDatabaseMockRecorder) SetTriggerCheckAcknowledgement(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTriggerCheckAcknowledgement", reflect.TypeOf((*MockDatabase)(nil).SetTriggerCheckAcknowledgement), arg0, arg1, arg2)
+}
+
 // SetTriggerCheckLock mocks base method
 func (m *MockDatabase) SetTriggerCheckLock(arg0 string) (bool, error) {
 	ret := m.ctrl.Call(m, "SetTriggerCheckLock", arg0)
@@ -835,15 +1025,15 @@ func (mr *MockDatabaseMockRecorder) SetTriggerLastCheck(arg0, arg1 interface{})
 }
 
 // SetTriggerThrottling mocks base method
-func (m *MockDatabase) SetTriggerThrottling(arg0 string, arg1 time.Time) error {
-	ret := m.ctrl.Call(m, "SetTriggerThrottling", arg0, arg1)
+func (m *MockDatabase) SetTriggerThrottling(arg0 string, arg1 time.Time, arg2 string) error {
+	ret := m.ctrl.Call(m, "SetTriggerThrottling", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // SetTriggerThrottling indicates an expected call of SetTriggerThrottling
-func (mr *MockDatabaseMockRecorder) SetTriggerThrottling(arg0, arg1 interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTriggerThrottling", reflect.TypeOf((*MockDatabase)(nil).SetTriggerThrottling), arg0, arg1)
+func (mr *MockDatabaseMockRecorder) SetTriggerThrottling(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTriggerThrottling", reflect.TypeOf((*MockDatabase)(nil).SetTriggerThrottling), arg0, arg1, arg2)
 }
 
 // SetUsernameID mocks base method
@@ -871,6 +1061,31 @@ func (mr *MockDatabaseMockRecorder) SubscribeMetricEvents(arg0 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubscribeMetricEvents", reflect.TypeOf((*MockDatabase)(nil).SubscribeMetricEvents), arg0)
 }
 
+// SubscribePatternEvents mocks base method
+func (m *MockDatabase) SubscribePatternEvents(arg0 *tomb_v2.Tomb) (<-chan *moira.PatternEvent, error) {
+	ret := m.ctrl.Call(m, "SubscribePatternEvents", arg0)
+	ret0, _ := ret[0].(<-chan *moira.PatternEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SubscribePatternEvents indicates an expected call of SubscribePatternEvents
+func (mr *MockDatabaseMockRecorder) SubscribePatternEvents(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubscribePatternEvents", reflect.TypeOf((*MockDatabase)(nil).SubscribePatternEvents), arg0)
+}
+
+// UpdateCheckerMember mocks base method
+func (m *MockDatabase) UpdateCheckerMember(arg0 string, arg1 int64) error {
+	ret := m.ctrl.Call(m, "UpdateCheckerMember", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCheckerMember indicates an expected call of UpdateCheckerMember
+func (mr *MockDatabaseMockRecorder) UpdateCheckerMember(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCheckerMember", reflect.TypeOf((*MockDatabase)(nil).UpdateCheckerMember), arg0, arg1)
+}
+
 // UpdateMetricsHeartbeat mocks base method
 func (m *MockDatabase) UpdateMetricsHeartbeat() error {
 	ret := m.ctrl.Call(m, "UpdateMetricsHeartbeat")
@@ -1,16 +1,22 @@
 package notifier
 
-import "time"
+import (
+	"time"
+
+	"github.com/moira-alert/moira"
+)
 
 // Config is sending settings including log settings
 type Config struct {
 	Enabled          bool
 	SendingTimeout   time.Duration
 	ResendingTimeout time.Duration
+	LeaseTimeout     time.Duration
 	Senders          []map[string]string
 	LogFile          string
 	LogLevel         string
 	FrontURL         string
 	Location         *time.Location
 	DateTimeFormat   string
+	ThrottlingLevels []moira.ThrottlingLevel
 }
@@ -102,40 +102,108 @@ func (worker *FetchEventsWorker) processEvent(event moira.NotificationEvent) err
 		subscriptions = []*moira.SubscriptionData{sub}
 	}
 
+	escalate := event.State != "TEST" && !event.IsReminder() && hasEscalations(subscriptions)
+	if escalate {
+		worker.cancelEscalations(event)
+	}
+	escalate = escalate && event.State != "OK" && !worker.isAcknowledged(event)
+
 	duplications := make(map[string]bool)
 	for _, subscription := range subscriptions {
-		if subscription != nil && (event.State == "TEST" || (subscription.Enabled && subset(subscription.Tags, tags))) {
+		if subscription != nil && (event.State == "TEST" || (subscription.Enabled && subset(subscription.Tags, tags) && subscription.NeedsReminder(&event))) {
 			worker.Logger.Debugf("Processing contact ids %v for subscription %s", subscription.Contacts, subscription.ID)
-			for _, contactID := range subscription.Contacts {
-				contact, err := worker.Database.GetContact(contactID)
-				if err != nil {
-					worker.Logger.Warningf("Failed to get contact: %s, skip handling it, error: %v", contactID, err)
-					continue
-				}
-				event.SubscriptionID = &subscription.ID
-				notification := worker.Scheduler.ScheduleNotification(time.Now(), event, triggerData, contact, false, 0)
-				key := notification.GetKey()
-				if _, exist := duplications[key]; !exist {
-					if err := worker.Database.AddNotification(notification); err != nil {
-						worker.Logger.Errorf("Failed to save scheduled notification: %s", err)
-					}
-					duplications[key] = true
-				} else {
-					worker.Logger.Debugf("Skip duplicated notification for contact %s", notification.Contact)
-				}
+			event.SubscriptionID = &subscription.ID
+			now := time.Now()
+			worker.scheduleNotifications(now, event, triggerData, subscription.Contacts, 0, subscription.DigestInterval, duplications)
+			if !escalate {
+				continue
+			}
+			for i, escalation := range subscription.Escalations {
+				worker.Logger.Debugf("Processing escalation contact ids %v for subscription %s", escalation.Contacts, subscription.ID)
+				escalationTime := now.Add(time.Duration(escalation.OffsetInSeconds) * time.Second)
+				worker.scheduleNotifications(escalationTime, event, triggerData, escalation.Contacts, i+1, subscription.DigestInterval, duplications)
 			}
-
 		} else if subscription == nil {
 			worker.Logger.Debugf("Subscription is nil")
 		} else if !subscription.Enabled {
 			worker.Logger.Debugf("Subscription %s is disabled", subscription.ID)
-		} else {
+		} else if !subset(subscription.Tags, tags) {
 			worker.Logger.Debugf("Subscription %s has extra tags", subscription.ID)
+		} else {
+			worker.Logger.Debugf("Subscription %s skips reminder for %s", subscription.ID, event.Metric)
 		}
 	}
 	return nil
 }
 
+func (worker *FetchEventsWorker) scheduleNotifications(now time.Time, event moira.NotificationEvent, triggerData moira.TriggerData, contactIDs []string, escalationLevel int, digestInterval int64, duplications map[string]bool) {
+	for _, contactID := range contactIDs {
+		contact, err := worker.Database.GetContact(contactID)
+		if err != nil {
+			worker.Logger.Warningf("Failed to get contact: %s, skip handling it, error: %v", contactID, err)
+			continue
+		}
+		notification := worker.Scheduler.ScheduleNotification(now, event, triggerData, contact, false, 0)
+		notification.EscalationLevel = escalationLevel
+		if digestInterval > 0 {
+			notification.Digest = true
+			notification.Timestamp = getDigestTimestamp(notification.Timestamp, digestInterval)
+		}
+		key := notification.GetKey()
+		if _, exist := duplications[key]; !exist {
+			if err := worker.Database.AddNotification(notification); err != nil {
+				worker.Logger.Errorf("Failed to save scheduled notification: %s", err)
+			}
+			duplications[key] = true
+		} else {
+			worker.Logger.Debugf("Skip duplicated notification for contact %s", notification.Contact)
+		}
+	}
+}
+
+// cancelEscalations removes pending escalations of metric, they are restarted by new bad state and stopped by recovery
+func (worker *FetchEventsWorker) cancelEscalations(event moira.NotificationEvent) {
+	removed, err := worker.Database.RemoveEscalationNotifications(event.TriggerID, event.Metric)
+	if err != nil {
+		worker.Logger.Errorf("Failed to cancel escalations of trigger %s metric %s: %s", event.TriggerID, event.Metric, err.Error())
+		return
+	}
+	if removed > 0 {
+		worker.Logger.Debugf("Cancelled %d escalation notifications of trigger %s metric %s", removed, event.TriggerID, event.Metric)
+	}
+}
+
+// isAcknowledged checks if event trigger or metric is acknowledged, acknowledged events are not escalated
+func (worker *FetchEventsWorker) isAcknowledged(event moira.NotificationEvent) bool {
+	lastCheck, err := worker.Database.GetTriggerLastCheck(event.TriggerID)
+	if err != nil {
+		if err != database.ErrNil {
+			worker.Logger.Warningf("Failed to get last check of trigger %s: %s", event.TriggerID, err.Error())
+		}
+		return false
+	}
+	if lastCheck.IsAcknowledged(event.Metric, time.Now().Unix()) {
+		worker.Logger.Debugf("Skip escalations of acknowledged trigger %s metric %s", event.TriggerID, event.Metric)
+		return true
+	}
+	return false
+}
+
+// getDigestTimestamp returns end of digest interval containing given timestamp,
+// so digest notifications of all triggers in the same interval are fetched and sent together
+func getDigestTimestamp(timestamp, digestInterval int64) int64 {
+	return (timestamp + digestInterval - 1) / digestInterval * digestInterval
+}
+
+func hasEscalations(subscriptions []*moira.SubscriptionData) bool {
+	for _, subscription := range subscriptions {
+		if subscription != nil && len(subscription.Escalations) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func (worker *FetchEventsWorker) getNotificationSubscriptions(event moira.NotificationEvent) (*moira.SubscriptionData, error) {
 	if event.SubscriptionID != nil {
 		worker.Logger.Debugf("Getting subscriptionID %s for test message", *event.SubscriptionID)
@@ -31,7 +31,7 @@ func TestEvent(t *testing.T) {
 			Database:  dataBase,
 			Logger:    logger,
 			Metrics:   metrics2,
-			Scheduler: notifier.NewScheduler(dataBase, logger, metrics2),
+			Scheduler: notifier.NewScheduler(dataBase, logger, metrics2, nil),
 		}
 		event := moira.NotificationEvent{
 			State:          "TEST",
@@ -111,7 +111,7 @@ func TestNoSubscription(t *testing.T) {
 			Database:  dataBase,
 			Logger:    logger,
 			Metrics:   metrics2,
-			Scheduler: notifier.NewScheduler(dataBase, logger, metrics2),
+			Scheduler: notifier.NewScheduler(dataBase, logger, metrics2, nil),
 		}
 
 		event := moira.NotificationEvent{
@@ -140,7 +140,7 @@ func TestDisabledNotification(t *testing.T) {
 			Database:  dataBase,
 			Logger:    logger,
 			Metrics:   metrics2,
-			Scheduler: notifier.NewScheduler(dataBase, logger, metrics2),
+			Scheduler: notifier.NewScheduler(dataBase, logger, metrics2, nil),
 		}
 
 		event := moira.NotificationEvent{
@@ -174,7 +174,7 @@ func TestExtraTags(t *testing.T) {
 			Database:  dataBase,
 			Logger:    logger,
 			Metrics:   metrics2,
-			Scheduler: notifier.NewScheduler(dataBase, logger, metrics2),
+			Scheduler: notifier.NewScheduler(dataBase, logger, metrics2, nil),
 		}
 
 		event := moira.NotificationEvent{
@@ -197,6 +197,43 @@ func TestExtraTags(t *testing.T) {
 	})
 }
 
+func TestSkipReminder(t *testing.T) {
+	Convey("When reminder doesn't cross subscription reminder interval, should not call AddNotification", t, func() {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+		dataBase := mock_moira_alert.NewMockDatabase(mockCtrl)
+		logger := mock_moira_alert.NewMockLogger(mockCtrl)
+
+		worker := FetchEventsWorker{
+			Database:  dataBase,
+			Logger:    logger,
+			Metrics:   metrics2,
+			Scheduler: notifier.NewScheduler(dataBase, logger, metrics2, nil),
+		}
+
+		event := moira.NotificationEvent{
+			Metric:             "generate.event.1",
+			State:              "ERROR",
+			OldState:           "ERROR",
+			TriggerID:          triggerData.ID,
+			Timestamp:          1502809200,
+			StateTimestamp:     1502809200 - 2*3600,
+			LastEventTimestamp: 1502809200 - 3600,
+		}
+
+		dataBase.EXPECT().GetTrigger(event.TriggerID).Return(trigger, nil)
+		tags := append(triggerData.Tags, event.GetEventTags()...)
+		dataBase.EXPECT().GetTagsSubscriptions(tags).Times(1).Return([]*moira.SubscriptionData{&dailyReminderSubscription}, nil)
+
+		logger.EXPECT().Debugf("Processing trigger id %s for metric %s == %f, %s -> %s", event.TriggerID, event.Metric, moira.UseFloat64(event.Value), event.OldState, event.State)
+		logger.EXPECT().Debugf("Getting subscriptions for tags %v", tags)
+		logger.EXPECT().Debugf("Subscription %s skips reminder for %s", dailyReminderSubscription.ID, event.Metric)
+
+		err := worker.processEvent(event)
+		So(err, ShouldBeEmpty)
+	})
+}
+
 func TestAddNotification(t *testing.T) {
 	Convey("When good subscription, should add new notification", t, func() {
 		mockCtrl := gomock.NewController(t)
@@ -232,6 +269,98 @@ func TestAddNotification(t *testing.T) {
 	})
 }
 
+func TestEscalation(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	dataBase := mock_moira_alert.NewMockDatabase(mockCtrl)
+	logger, _ := logging.GetLogger("Events")
+	scheduler := mock_scheduler.NewMockScheduler(mockCtrl)
+	worker := FetchEventsWorker{
+		Database:  dataBase,
+		Logger:    logger,
+		Metrics:   metrics2,
+		Scheduler: scheduler,
+	}
+
+	Convey("When bad event, should schedule escalation notifications", t, func() {
+		event := moira.NotificationEvent{
+			Metric:    "generate.event.1",
+			State:     "ERROR",
+			OldState:  "OK",
+			TriggerID: triggerData.ID,
+		}
+		scheduledEvent := event
+		scheduledEvent.SubscriptionID = &escalationSubscription.ID
+		notification := moira.ScheduledNotification{Contact: contact, Timestamp: 1}
+		escalationNotification := moira.ScheduledNotification{Contact: escalationContact, Timestamp: 2}
+
+		dataBase.EXPECT().GetTrigger(event.TriggerID).Return(trigger, nil)
+		tags := append(triggerData.Tags, event.GetEventTags()...)
+		dataBase.EXPECT().GetTagsSubscriptions(tags).Return([]*moira.SubscriptionData{&escalationSubscription}, nil)
+		dataBase.EXPECT().RemoveEscalationNotifications(event.TriggerID, event.Metric).Return(int64(0), nil)
+		dataBase.EXPECT().GetTriggerLastCheck(event.TriggerID).Return(moira.CheckData{}, nil)
+		dataBase.EXPECT().GetContact(contact.ID).Return(contact, nil)
+		dataBase.EXPECT().GetContact(escalationContact.ID).Return(escalationContact, nil)
+		scheduler.EXPECT().ScheduleNotification(gomock.Any(), scheduledEvent, triggerData, contact, false, 0).Return(&notification)
+		scheduler.EXPECT().ScheduleNotification(gomock.Any(), scheduledEvent, triggerData, escalationContact, false, 0).Return(&escalationNotification)
+		dataBase.EXPECT().AddNotification(&notification).Return(nil)
+		dataBase.EXPECT().AddNotification(&moira.ScheduledNotification{Contact: escalationContact, Timestamp: 2, EscalationLevel: 1}).Return(nil)
+
+		err := worker.processEvent(event)
+		So(err, ShouldBeEmpty)
+	})
+
+	Convey("When bad event is acknowledged, should not schedule escalation notifications", t, func() {
+		event := moira.NotificationEvent{
+			Metric:    "generate.event.1",
+			State:     "ERROR",
+			OldState:  "WARN",
+			TriggerID: triggerData.ID,
+		}
+		scheduledEvent := event
+		scheduledEvent.SubscriptionID = &escalationSubscription.ID
+		notification := moira.ScheduledNotification{Contact: contact}
+		lastCheck := moira.CheckData{
+			Acknowledgement: &moira.Acknowledgement{User: "user", Until: time.Now().Unix() + 3600},
+		}
+
+		dataBase.EXPECT().GetTrigger(event.TriggerID).Return(trigger, nil)
+		tags := append(triggerData.Tags, event.GetEventTags()...)
+		dataBase.EXPECT().GetTagsSubscriptions(tags).Return([]*moira.SubscriptionData{&escalationSubscription}, nil)
+		dataBase.EXPECT().RemoveEscalationNotifications(event.TriggerID, event.Metric).Return(int64(0), nil)
+		dataBase.EXPECT().GetTriggerLastCheck(event.TriggerID).Return(lastCheck, nil)
+		dataBase.EXPECT().GetContact(contact.ID).Return(contact, nil)
+		scheduler.EXPECT().ScheduleNotification(gomock.Any(), scheduledEvent, triggerData, contact, false, 0).Return(&notification)
+		dataBase.EXPECT().AddNotification(&notification).Return(nil)
+
+		err := worker.processEvent(event)
+		So(err, ShouldBeEmpty)
+	})
+
+	Convey("When OK event, should cancel escalation notifications", t, func() {
+		event := moira.NotificationEvent{
+			Metric:    "generate.event.1",
+			State:     "OK",
+			OldState:  "ERROR",
+			TriggerID: triggerData.ID,
+		}
+		scheduledEvent := event
+		scheduledEvent.SubscriptionID = &escalationSubscription.ID
+		notification := moira.ScheduledNotification{Contact: contact}
+
+		dataBase.EXPECT().GetTrigger(event.TriggerID).Return(trigger, nil)
+		tags := append(triggerData.Tags, event.GetEventTags()...)
+		dataBase.EXPECT().GetTagsSubscriptions(tags).Return([]*moira.SubscriptionData{&escalationSubscription}, nil)
+		dataBase.EXPECT().RemoveEscalationNotifications(event.TriggerID, event.Metric).Return(int64(1), nil)
+		dataBase.EXPECT().GetContact(contact.ID).Return(contact, nil)
+		scheduler.EXPECT().ScheduleNotification(gomock.Any(), scheduledEvent, triggerData, contact, false, 0).Return(&notification)
+		dataBase.EXPECT().AddNotification(&notification).Return(nil)
+
+		err := worker.processEvent(event)
+		So(err, ShouldBeEmpty)
+	})
+}
+
 func TestAddOneNotificationByTwoSubscriptionsWithSame(t *testing.T) {
 	Convey("When good subscription and create 2 same scheduled notifications, should add one new notification", t, func() {
 		mockCtrl := gomock.NewController(t)
@@ -283,7 +412,7 @@ func TestFailReadContact(t *testing.T) {
 			Database:  dataBase,
 			Logger:    logger,
 			Metrics:   metrics2,
-			Scheduler: notifier.NewScheduler(dataBase, logger, metrics2),
+			Scheduler: notifier.NewScheduler(dataBase, logger, metrics2, nil),
 		}
 
 		event := moira.NotificationEvent{
@@ -319,7 +448,7 @@ func TestEmptySubscriptions(t *testing.T) {
 			Database:  dataBase,
 			Logger:    logger,
 			Metrics:   metrics2,
-			Scheduler: notifier.NewScheduler(dataBase, logger, metrics2),
+			Scheduler: notifier.NewScheduler(dataBase, logger, metrics2, nil),
 		}
 
 		event := moira.NotificationEvent{
@@ -350,7 +479,7 @@ func TestEmptySubscriptions(t *testing.T) {
 			Database:  dataBase,
 			Logger:    logger,
 			Metrics:   metrics2,
-			Scheduler: notifier.NewScheduler(dataBase, logger, metrics2),
+			Scheduler: notifier.NewScheduler(dataBase, logger, metrics2, nil),
 		}
 
 		event := moira.NotificationEvent{
@@ -382,7 +511,7 @@ func TestGetNotificationSubscriptions(t *testing.T) {
 		Database:  dataBase,
 		Logger:    logger,
 		Metrics:   metrics2,
-		Scheduler: notifier.NewScheduler(dataBase, logger, metrics2),
+		Scheduler: notifier.NewScheduler(dataBase, logger, metrics2, nil),
 	}
 
 	Convey("Error GetSubscription", t, func() {
@@ -515,6 +644,34 @@ var disabledSubscription = moira.SubscriptionData{
 	ThrottlingEnabled: true,
 }
 
+var dailyReminderInterval int64 = 86400
+
+var dailyReminderSubscription = moira.SubscriptionData{
+	ID:                "subscriptionID-00000000000005",
+	Enabled:           true,
+	Tags:              []string{"test-tag"},
+	Contacts:          []string{contact.ID},
+	ThrottlingEnabled: true,
+	ReminderInterval:  &dailyReminderInterval,
+}
+
+var escalationContact = moira.ContactData{
+	ID:    "ContactID-000000000000002",
+	Type:  "email",
+	Value: "mail2@example.com",
+}
+
+var escalationSubscription = moira.SubscriptionData{
+	ID:                "subscriptionID-00000000000006",
+	Enabled:           true,
+	Tags:              []string{"test-tag"},
+	Contacts:          []string{contact.ID},
+	ThrottlingEnabled: true,
+	Escalations: []moira.EscalationData{
+		{Contacts: []string{escalationContact.ID}, OffsetInSeconds: 900},
+	},
+}
+
 var multipleTagsSubscription = moira.SubscriptionData{
 	ID:                "subscriptionID-00000000000003",
 	Enabled:           true,
@@ -522,3 +679,11 @@ var multipleTagsSubscription = moira.SubscriptionData{
 	Contacts:          []string{contact.ID},
 	ThrottlingEnabled: true,
 }
+
+func TestGetDigestTimestamp(t *testing.T) {
+	Convey("Timestamp should be aligned to the end of digest interval", t, func() {
+		So(getDigestTimestamp(1441188915, 300), ShouldEqual, 1441189200)
+		So(getDigestTimestamp(1441189200, 300), ShouldEqual, 1441189200)
+		So(getDigestTimestamp(1441189201, 300), ShouldEqual, 1441189500)
+	})
+}
@@ -13,10 +13,11 @@ import (
 
 // FetchNotificationsWorker - check for new notifications and send it using notifier
 type FetchNotificationsWorker struct {
-	Logger   moira.Logger
-	Database moira.Database
-	Notifier notifier.Notifier
-	tomb     tomb.Tomb
+	Logger       moira.Logger
+	Database     moira.Database
+	Notifier     notifier.Notifier
+	LeaseTimeout time.Duration
+	tomb         tomb.Tomb
 }
 
 // Start is a cycle that fetches scheduled notifications from database
@@ -46,24 +47,39 @@ func (worker *FetchNotificationsWorker) Stop() error {
 }
 
 func (worker *FetchNotificationsWorker) processScheduledNotifications() error {
-	notifications, err := worker.Database.FetchNotifications(time.Now().Unix())
+	now := time.Now()
+	notifications, err := worker.Database.FetchNotifications(now.Unix(), now.Add(worker.LeaseTimeout).Unix())
 	if err != nil {
 		return err
 	}
 	notificationPackages := make(map[string]*notifier.NotificationPackage)
 	for _, notification := range notifications {
 		packageKey := fmt.Sprintf("%s:%s:%s", notification.Contact.Type, notification.Contact.Value, notification.Event.TriggerID)
+		if notification.Digest {
+			packageKey = fmt.Sprintf("%s:%s:digest", notification.Contact.Type, notification.Contact.Value)
+		}
 		p, found := notificationPackages[packageKey]
 		if !found {
 			p = &notifier.NotificationPackage{
 				Events:    make([]moira.NotificationEvent, 0, len(notifications)),
 				Trigger:   notification.Trigger,
 				Contact:   notification.Contact,
-				Throttled: notification.Throttled,
+				Throttled: notification.Throttled && !notification.Digest,
 				FailCount: notification.SendFail,
+				Digest:    notification.Digest,
+			}
+		}
+		if p.Digest {
+			p.AddTrigger(notification.Trigger)
+			if notification.Throttled {
+				p.AddThrottledTrigger(notification.Event.TriggerID)
+			}
+			if notification.SendFail > p.FailCount {
+				p.FailCount = notification.SendFail
 			}
 		}
 		p.Events = append(p.Events, notification.Event)
+		p.Notifications = append(p.Notifications, notification)
 		notificationPackages[packageKey] = p
 	}
 	var sendingWG sync.WaitGroup
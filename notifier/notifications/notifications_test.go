@@ -62,7 +62,7 @@ func TestProcessScheduledEvent(t *testing.T) {
 	}
 
 	Convey("Two different notifications, should send two packages", t, func() {
-		dataBase.EXPECT().FetchNotifications(gomock.Any()).Return([]*moira.ScheduledNotification{
+		dataBase.EXPECT().FetchNotifications(gomock.Any(), gomock.Any()).Return([]*moira.ScheduledNotification{
 			&notification1,
 			&notification2,
 		}, nil)
@@ -76,6 +76,9 @@ func TestProcessScheduledEvent(t *testing.T) {
 			Events: []moira.NotificationEvent{
 				notification1.Event,
 			},
+			Notifications: []*moira.ScheduledNotification{
+				&notification1,
+			},
 		}
 		pkg2 := notifier2.NotificationPackage{
 			Trigger:    notification2.Trigger,
@@ -86,6 +89,9 @@ func TestProcessScheduledEvent(t *testing.T) {
 			Events: []moira.NotificationEvent{
 				notification2.Event,
 			},
+			Notifications: []*moira.ScheduledNotification{
+				&notification2,
+			},
 		}
 		notifier.EXPECT().Send(&pkg1, gomock.Any())
 		notifier.EXPECT().Send(&pkg2, gomock.Any())
@@ -95,7 +101,7 @@ func TestProcessScheduledEvent(t *testing.T) {
 	})
 
 	Convey("Two same notifications, should send one package", t, func() {
-		dataBase.EXPECT().FetchNotifications(gomock.Any()).Return([]*moira.ScheduledNotification{
+		dataBase.EXPECT().FetchNotifications(gomock.Any(), gomock.Any()).Return([]*moira.ScheduledNotification{
 			&notification2,
 			&notification3,
 		}, nil)
@@ -110,6 +116,63 @@ func TestProcessScheduledEvent(t *testing.T) {
 				notification2.Event,
 				notification3.Event,
 			},
+			Notifications: []*moira.ScheduledNotification{
+				&notification2,
+				&notification3,
+			},
+		}
+
+		notifier.EXPECT().Send(&pkg, gomock.Any())
+		err := worker.processScheduledNotifications()
+		So(err, ShouldBeEmpty)
+		mockCtrl.Finish()
+	})
+
+	Convey("Digest notifications of different triggers, should send one digest package", t, func() {
+		digestNotification1 := moira.ScheduledNotification{
+			Event: moira.NotificationEvent{
+				SubscriptionID: &subID7,
+				State:          "ERROR",
+				TriggerID:      "triggerID-00000000000001",
+			},
+			Trigger:   moira.TriggerData{ID: "triggerID-00000000000001"},
+			Contact:   contact2,
+			Timestamp: 1441189200,
+			Digest:    true,
+		}
+		digestNotification2 := moira.ScheduledNotification{
+			Event: moira.NotificationEvent{
+				SubscriptionID: &subID7,
+				State:          "WARN",
+				TriggerID:      "triggerID-00000000000002",
+			},
+			Trigger:   moira.TriggerData{ID: "triggerID-00000000000002"},
+			Contact:   contact2,
+			Throttled: true,
+			SendFail:  1,
+			Timestamp: 1441189200,
+			Digest:    true,
+		}
+		dataBase.EXPECT().FetchNotifications(gomock.Any(), gomock.Any()).Return([]*moira.ScheduledNotification{
+			&digestNotification1,
+			&digestNotification2,
+		}, nil)
+
+		pkg := notifier2.NotificationPackage{
+			Trigger:   digestNotification1.Trigger,
+			Contact:   contact2,
+			FailCount: 1,
+			Events: []moira.NotificationEvent{
+				digestNotification1.Event,
+				digestNotification2.Event,
+			},
+			Notifications: []*moira.ScheduledNotification{
+				&digestNotification1,
+				&digestNotification2,
+			},
+			Digest:            true,
+			Triggers:          []moira.TriggerData{digestNotification1.Trigger, digestNotification2.Trigger},
+			ThrottledTriggers: map[string]bool{digestNotification2.Event.TriggerID: true},
 		}
 
 		notifier.EXPECT().Send(&pkg, gomock.Any())
@@ -141,6 +204,9 @@ func TestGoRoutine(t *testing.T) {
 		Events: []moira.NotificationEvent{
 			notification1.Event,
 		},
+		Notifications: []*moira.ScheduledNotification{
+			&notification1,
+		},
 	}
 
 	mockCtrl := gomock.NewController(t)
@@ -155,7 +221,7 @@ func TestGoRoutine(t *testing.T) {
 	}
 
 	shutdown := make(chan bool)
-	dataBase.EXPECT().FetchNotifications(gomock.Any()).Return([]*moira.ScheduledNotification{&notification1}, nil)
+	dataBase.EXPECT().FetchNotifications(gomock.Any(), gomock.Any()).Return([]*moira.ScheduledNotification{&notification1}, nil)
 	notifier.EXPECT().Send(&pkg, gomock.Any()).Do(func(f ...interface{}) { close(shutdown) })
 	notifier.EXPECT().StopSenders()
 
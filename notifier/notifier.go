@@ -17,12 +17,87 @@ type NotificationPackage struct {
 	FailCount  int
 	Throttled  bool
 	DontResend bool
+	// Digest package contains events of all Triggers, throttling of them is kept in ThrottledTriggers
+	Digest            bool
+	Triggers          []moira.TriggerData
+	ThrottledTriggers map[string]bool
+	// Notifications are leased from database and acknowledged after package is sent or rescheduled
+	Notifications []*moira.ScheduledNotification
 }
 
 func (pkg NotificationPackage) String() string {
 	return fmt.Sprintf("package of %d notifications to %s", len(pkg.Events), pkg.Contact.Value)
 }
 
+// AddTrigger adds trigger to digest package triggers if it is not added yet
+func (pkg *NotificationPackage) AddTrigger(trigger moira.TriggerData) {
+	for _, packageTrigger := range pkg.Triggers {
+		if packageTrigger.ID == trigger.ID {
+			return
+		}
+	}
+	pkg.Triggers = append(pkg.Triggers, trigger)
+}
+
+// AddThrottledTrigger marks notifications of given trigger in digest package as throttled
+func (pkg *NotificationPackage) AddThrottledTrigger(triggerID string) {
+	if pkg.ThrottledTriggers == nil {
+		pkg.ThrottledTriggers = make(map[string]bool)
+	}
+	pkg.ThrottledTriggers[triggerID] = true
+}
+
+func (pkg *NotificationPackage) isThrottled(triggerID string) bool {
+	return pkg.Throttled || pkg.ThrottledTriggers[triggerID]
+}
+
+func (pkg *NotificationPackage) getTrigger(triggerID string) moira.TriggerData {
+	for _, trigger := range pkg.Triggers {
+		if trigger.ID == triggerID {
+			return trigger
+		}
+	}
+	return pkg.Trigger
+}
+
+// getEscalationLevel returns escalation level of notification of package event with given index,
+// events of package not fetched from scheduled notifications are not escalations
+func (pkg *NotificationPackage) getEscalationLevel(eventIndex int) int {
+	if len(pkg.Notifications) != len(pkg.Events) {
+		return 0
+	}
+	return pkg.Notifications[eventIndex].EscalationLevel
+}
+
+// splitByTrigger splits digest package to packages of single triggers for senders not supporting digests,
+// they stay digest packages to be rescheduled as digest notifications on sending failure
+func (pkg *NotificationPackage) splitByTrigger() []NotificationPackage {
+	packages := make([]NotificationPackage, 0, len(pkg.Triggers))
+	for _, trigger := range pkg.Triggers {
+		triggerPackage := NotificationPackage{
+			Trigger:    trigger,
+			Contact:    pkg.Contact,
+			FailCount:  pkg.FailCount,
+			Throttled:  pkg.isThrottled(trigger.ID),
+			DontResend: pkg.DontResend,
+			Digest:     pkg.Digest,
+			Triggers:   []moira.TriggerData{trigger},
+		}
+		for _, event := range pkg.Events {
+			if event.TriggerID == trigger.ID {
+				triggerPackage.Events = append(triggerPackage.Events, event)
+			}
+		}
+		for _, notification := range pkg.Notifications {
+			if notification.Event.TriggerID == trigger.ID {
+				triggerPackage.Notifications = append(triggerPackage.Notifications, notification)
+			}
+		}
+		packages = append(packages, triggerPackage)
+	}
+	return packages
+}
+
 // Notifier implements notification functionality
 type Notifier interface {
 	Send(pkg *NotificationPackage, waitGroup *sync.WaitGroup)
@@ -48,7 +123,7 @@ func NewNotifier(database moira.Database, logger moira.Logger, config Config, me
 		senders:   make(map[string]chan NotificationPackage),
 		logger:    logger,
 		database:  database,
-		scheduler: NewScheduler(database, logger, metrics),
+		scheduler: NewScheduler(database, logger, metrics, config.ThrottlingLevels),
 		config:    config,
 		metrics:   metrics,
 	}
@@ -96,25 +171,53 @@ func (notifier *StandardNotifier) resend(pkg *NotificationPackage, reason string
 	if time.Duration(pkg.FailCount)*time.Minute > notifier.config.ResendingTimeout {
 		notifier.logger.Error("Stop resending. Notification interval is timed out")
 	} else {
-		for _, event := range pkg.Events {
-			notification := notifier.scheduler.ScheduleNotification(time.Now(), event, pkg.Trigger, pkg.Contact, pkg.Throttled, pkg.FailCount+1)
+		for i, event := range pkg.Events {
+			notification := notifier.scheduler.ScheduleNotification(time.Now(), event, pkg.getTrigger(event.TriggerID), pkg.Contact, pkg.isThrottled(event.TriggerID), pkg.FailCount+1)
+			notification.Digest = pkg.Digest
+			notification.EscalationLevel = pkg.getEscalationLevel(i)
 			if err := notifier.database.AddNotification(notification); err != nil {
+				// Leased notifications are not acknowledged, so they are fetched again after lease expiration
 				notifier.logger.Errorf("Failed to save scheduled notification: %s", err)
+				return
 			}
 		}
 	}
+	notifier.ackNotifications(pkg)
+}
+
+func (notifier *StandardNotifier) ackNotifications(pkg *NotificationPackage) {
+	if len(pkg.Notifications) == 0 {
+		return
+	}
+	if err := notifier.database.AckNotifications(pkg.Notifications); err != nil {
+		notifier.logger.Errorf("Failed to acknowledge sent notifications: %s", err)
+	}
 }
 
 func (notifier *StandardNotifier) run(sender moira.Sender, ch chan NotificationPackage) {
 	defer notifier.waitGroup.Done()
 	for pkg := range ch {
-		err := sender.SendEvents(pkg.Events, pkg.Contact, pkg.Trigger, pkg.Throttled)
-		if err == nil {
-			if metric, found := notifier.metrics.SendersOkMetrics.GetMetric(pkg.Contact.Type); found {
-				metric.Mark(1)
-			}
-		} else {
-			notifier.resend(&pkg, err.Error())
+		if !pkg.Digest {
+			notifier.handleSendingResult(&pkg, sender.SendEvents(pkg.Events, pkg.Contact, pkg.Trigger, pkg.Throttled))
+			continue
+		}
+		if digestSender, ok := sender.(moira.DigestSender); ok {
+			notifier.handleSendingResult(&pkg, digestSender.SendDigest(pkg.Events, pkg.Contact, pkg.Triggers, pkg.ThrottledTriggers))
+			continue
 		}
+		for _, triggerPackage := range pkg.splitByTrigger() {
+			notifier.handleSendingResult(&triggerPackage, sender.SendEvents(triggerPackage.Events, triggerPackage.Contact, triggerPackage.Trigger, triggerPackage.Throttled))
+		}
+	}
+}
+
+func (notifier *StandardNotifier) handleSendingResult(pkg *NotificationPackage, err error) {
+	if err == nil {
+		if metric, found := notifier.metrics.SendersOkMetrics.GetMetric(pkg.Contact.Type); found {
+			metric.Mark(1)
+		}
+		notifier.ackNotifications(pkg)
+	} else {
+		notifier.resend(pkg, err.Error())
 	}
 }
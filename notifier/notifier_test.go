@@ -69,6 +69,73 @@ func TestFailSendEvent(t *testing.T) {
 	time.Sleep(time.Second * 2)
 }
 
+func TestAckSentNotifications(t *testing.T) {
+	configureNotifier(t)
+	defer afterTest()
+
+	var eventsData moira.NotificationEvents = []moira.NotificationEvent{event}
+
+	pkg := NotificationPackage{
+		Events: eventsData,
+		Contact: moira.ContactData{
+			Type: "test",
+		},
+		Notifications: []*moira.ScheduledNotification{{Event: event}},
+	}
+	sender.EXPECT().SendEvents(eventsData, pkg.Contact, pkg.Trigger, pkg.Throttled).Return(nil)
+	dataBase.EXPECT().AckNotifications(pkg.Notifications).Return(nil)
+
+	var wg sync.WaitGroup
+	notif.Send(&pkg, &wg)
+	wg.Wait()
+	time.Sleep(time.Second * 2)
+}
+
+func TestNotAckNotSavedNotifications(t *testing.T) {
+	configureNotifier(t)
+	defer afterTest()
+
+	var eventsData moira.NotificationEvents = []moira.NotificationEvent{event}
+
+	pkg := NotificationPackage{
+		Events: eventsData,
+		Contact: moira.ContactData{
+			Type: "unknown contact",
+		},
+		Notifications: []*moira.ScheduledNotification{{Event: event}},
+	}
+	notification := moira.ScheduledNotification{}
+	scheduler.EXPECT().ScheduleNotification(gomock.Any(), event, pkg.Trigger, pkg.Contact, pkg.Throttled, pkg.FailCount+1).Return(&notification)
+	dataBase.EXPECT().AddNotification(&notification).Return(fmt.Errorf("redis is down"))
+
+	var wg sync.WaitGroup
+	notif.Send(&pkg, &wg)
+	wg.Wait()
+}
+
+func TestResendKeepsEscalationLevel(t *testing.T) {
+	configureNotifier(t)
+	defer afterTest()
+
+	var eventsData moira.NotificationEvents = []moira.NotificationEvent{event}
+
+	pkg := NotificationPackage{
+		Events: eventsData,
+		Contact: moira.ContactData{
+			Type: "unknown contact",
+		},
+		Notifications: []*moira.ScheduledNotification{{Event: event, EscalationLevel: 2}},
+	}
+	notification := moira.ScheduledNotification{}
+	scheduler.EXPECT().ScheduleNotification(gomock.Any(), event, pkg.Trigger, pkg.Contact, pkg.Throttled, pkg.FailCount+1).Return(&notification)
+	dataBase.EXPECT().AddNotification(&moira.ScheduledNotification{EscalationLevel: 2}).Return(nil)
+	dataBase.EXPECT().AckNotifications(pkg.Notifications).Return(nil)
+
+	var wg sync.WaitGroup
+	notif.Send(&pkg, &wg)
+	wg.Wait()
+}
+
 func TestTimeout(t *testing.T) {
 	configureNotifier(t)
 	defer afterTest()
@@ -105,6 +172,68 @@ func TestTimeout(t *testing.T) {
 	waitTestEnd()
 }
 
+func TestSendDigest(t *testing.T) {
+	configureNotifier(t)
+	defer afterTest()
+	digestSender := registerDigestSender()
+
+	event2 := event
+	event2.TriggerID = "triggerID-0000000000002"
+	pkg := NotificationPackage{
+		Events: []moira.NotificationEvent{event, event2},
+		Contact: moira.ContactData{
+			Type: "digest",
+		},
+		Digest:            true,
+		Triggers:          []moira.TriggerData{{ID: event.TriggerID}, {ID: event2.TriggerID}},
+		ThrottledTriggers: map[string]bool{event2.TriggerID: true},
+		Notifications:     []*moira.ScheduledNotification{{Event: event}, {Event: event2}},
+	}
+	digestSender.EXPECT().SendDigest(moira.NotificationEvents(pkg.Events), pkg.Contact, pkg.Triggers, pkg.ThrottledTriggers).Return(nil)
+	dataBase.EXPECT().AckNotifications(pkg.Notifications).Return(nil)
+
+	var wg sync.WaitGroup
+	notif.Send(&pkg, &wg)
+	wg.Wait()
+	time.Sleep(time.Second * 2)
+}
+
+func TestSendDigestBySingleTriggers(t *testing.T) {
+	configureNotifier(t)
+	defer afterTest()
+
+	event2 := event
+	event2.TriggerID = "triggerID-0000000000002"
+	trigger1 := moira.TriggerData{ID: event.TriggerID}
+	trigger2 := moira.TriggerData{ID: event2.TriggerID}
+	notification1 := &moira.ScheduledNotification{Event: event}
+	notification2 := &moira.ScheduledNotification{Event: event2}
+	pkg := NotificationPackage{
+		Events: []moira.NotificationEvent{event, event2},
+		Contact: moira.ContactData{
+			Type: "test",
+		},
+		Digest:            true,
+		Triggers:          []moira.TriggerData{trigger1, trigger2},
+		ThrottledTriggers: map[string]bool{event2.TriggerID: true},
+		Notifications:     []*moira.ScheduledNotification{notification1, notification2},
+	}
+	sender.EXPECT().SendEvents(moira.NotificationEvents{event}, pkg.Contact, trigger1, false).Return(nil)
+	dataBase.EXPECT().AckNotifications([]*moira.ScheduledNotification{notification1}).Return(nil)
+
+	// failed package of single trigger keeps its throttling and is rescheduled as digest notification
+	notification := moira.ScheduledNotification{}
+	sender.EXPECT().SendEvents(moira.NotificationEvents{event2}, pkg.Contact, trigger2, true).Return(fmt.Errorf("Cant't send"))
+	scheduler.EXPECT().ScheduleNotification(gomock.Any(), event2, trigger2, pkg.Contact, true, 1).Return(&notification)
+	dataBase.EXPECT().AddNotification(&moira.ScheduledNotification{Digest: true}).Return(nil)
+	dataBase.EXPECT().AckNotifications([]*moira.ScheduledNotification{notification2}).Return(nil)
+
+	var wg sync.WaitGroup
+	notif.Send(&pkg, &wg)
+	wg.Wait()
+	time.Sleep(time.Second * 2)
+}
+
 func waitTestEnd() {
 	select {
 	case <-shutdown:
@@ -147,6 +276,22 @@ func configureNotifier(t *testing.T) {
 	})
 }
 
+type digestSender struct {
+	*mock_moira_alert.MockSender
+	*mock_moira_alert.MockDigestSender
+}
+
+func registerDigestSender() *mock_moira_alert.MockDigestSender {
+	senderSettings := map[string]string{
+		"type": "digest",
+	}
+	plainSender := mock_moira_alert.NewMockSender(mockCtrl)
+	plainSender.EXPECT().Init(senderSettings, logger, gomock.Any(), gomock.Any()).Return(nil)
+	mockDigestSender := mock_moira_alert.NewMockDigestSender(mockCtrl)
+	notif.RegisterSender(senderSettings, digestSender{plainSender, mockDigestSender})
+	return mockDigestSender
+}
+
 func afterTest() {
 	mockCtrl.Finish()
 	notif.StopSenders()
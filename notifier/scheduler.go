@@ -14,9 +14,10 @@ type Scheduler interface {
 
 // StandardScheduler represents standard event scheduling
 type StandardScheduler struct {
-	logger   moira.Logger
-	database moira.Database
-	metrics  *graphite.NotifierMetrics
+	logger           moira.Logger
+	database         moira.Database
+	metrics          *graphite.NotifierMetrics
+	throttlingLevels []throttlingLevel
 }
 
 type throttlingLevel struct {
@@ -25,15 +26,39 @@ type throttlingLevel struct {
 	count    int64
 }
 
-// NewScheduler is initializer for StandardScheduler
-func NewScheduler(database moira.Database, logger moira.Logger, metrics *graphite.NotifierMetrics) *StandardScheduler {
+// DefaultThrottlingLevels are used if no throttling levels are configured:
+// if trigger switches 20 times in 3 hours, delay next delivery for 1 hour,
+// if trigger switches 10 times in 1 hour, delay next delivery for 30 minutes
+var DefaultThrottlingLevels = []moira.ThrottlingLevel{
+	{Duration: 3 * 3600, Delay: 3600, Count: 20},
+	{Duration: 3600, Delay: 1800, Count: 10},
+}
+
+// NewScheduler is initializer for StandardScheduler, DefaultThrottlingLevels are used if throttlingLevels are empty
+func NewScheduler(database moira.Database, logger moira.Logger, metrics *graphite.NotifierMetrics, throttlingLevels []moira.ThrottlingLevel) *StandardScheduler {
+	if len(throttlingLevels) == 0 {
+		throttlingLevels = DefaultThrottlingLevels
+	}
 	return &StandardScheduler{
-		database: database,
-		logger:   logger,
-		metrics:  metrics,
+		database:         database,
+		logger:           logger,
+		metrics:          metrics,
+		throttlingLevels: toThrottlingLevels(throttlingLevels),
 	}
 }
 
+func toThrottlingLevels(levels []moira.ThrottlingLevel) []throttlingLevel {
+	throttlingLevels := make([]throttlingLevel, 0, len(levels))
+	for _, level := range levels {
+		throttlingLevels = append(throttlingLevels, throttlingLevel{
+			duration: time.Duration(level.Duration) * time.Second,
+			delay:    time.Duration(level.Delay) * time.Second,
+			count:    level.Count,
+		})
+	}
+	return throttlingLevels
+}
+
 // ScheduleNotification is realization of scheduling event, based on trigger and subscription time intervals and triggers settings
 func (scheduler *StandardScheduler) ScheduleNotification(now time.Time, event moira.NotificationEvent, trigger moira.TriggerData, contact moira.ContactData, throttledOld bool, sendfail int) *moira.ScheduledNotification {
 	var (
@@ -68,13 +93,6 @@ func (scheduler *StandardScheduler) ScheduleNotification(now time.Time, event mo
 }
 
 func (scheduler *StandardScheduler) calculateNextDelivery(now time.Time, event *moira.NotificationEvent) (time.Time, bool) {
-	// if trigger switches more than .count times in .length seconds, delay next delivery for .delay seconds
-	// processing stops after first condition matches
-	throttlingLevels := []throttlingLevel{
-		{3 * time.Hour, time.Hour, 20},
-		{time.Hour, time.Hour / 2, 10},
-	}
-
 	alarmFatigue := false
 
 	next, beginning := scheduler.database.GetTriggerThrottling(event.TriggerID)
@@ -85,46 +103,68 @@ func (scheduler *StandardScheduler) calculateNextDelivery(now time.Time, event *
 		next = now
 	}
 
-	subscription, err := scheduler.database.GetSubscription(moira.UseString(event.SubscriptionID))
+	subscriptionID := moira.UseString(event.SubscriptionID)
+	subscription, err := scheduler.database.GetSubscription(subscriptionID)
 	if err != nil {
 		scheduler.metrics.SubsMalformed.Mark(1)
-		scheduler.logger.Debugf("Failed get subscription by id: %s. %s", moira.UseString(event.SubscriptionID), err.Error())
+		scheduler.logger.Debugf("Failed get subscription by id: %s. %s", subscriptionID, err.Error())
 		return next, alarmFatigue
 	}
 
 	if subscription.ThrottlingEnabled {
-		if next.After(now) {
-			scheduler.logger.Debugf("Using existing throttling for trigger %s: %s", event.TriggerID, next)
+		if len(subscription.ThrottlingLevels) > 0 {
+			// own throttling levels of subscription must not delay other subscriptions of the trigger,
+			// so its throttling is kept apart from the trigger one
+			next = scheduler.database.GetSubscriptionThrottling(event.TriggerID, subscriptionID)
+			next, alarmFatigue = scheduler.throttle(now, next, beginning, event.TriggerID, toThrottlingLevels(subscription.ThrottlingLevels),
+				func(next time.Time, reason string) error {
+					return scheduler.database.SetSubscriptionThrottling(event.TriggerID, subscriptionID, next)
+				})
 		} else {
-			for _, level := range throttlingLevels {
-				from := now.Add(-level.duration)
-				if from.Before(beginning) {
-					from = beginning
-				}
-				count := scheduler.database.GetNotificationEventCount(event.TriggerID, from.Unix())
-				if count >= level.count {
-					next = now.Add(level.delay)
-					scheduler.logger.Debugf("Trigger %s switched %d times in last %s, delaying next notification for %s", event.TriggerID, count, level.duration, level.delay)
-					if err = scheduler.database.SetTriggerThrottling(event.TriggerID, next); err != nil {
-						scheduler.logger.Errorf("Failed to set trigger throttling timestamp: %s", err)
-					}
-					alarmFatigue = true
-					break
-				} else if count == level.count-1 {
-					alarmFatigue = true
-				}
-			}
+			next, alarmFatigue = scheduler.throttle(now, next, beginning, event.TriggerID, scheduler.throttlingLevels,
+				func(next time.Time, reason string) error {
+					return scheduler.database.SetTriggerThrottling(event.TriggerID, next, reason)
+				})
 		}
 	} else {
 		next = now
 	}
 	next, err = calculateNextDelivery(&subscription.Schedule, next)
 	if err != nil {
-		scheduler.logger.Errorf("Failed to apply schedule for subscriptionID: %s. %s.", moira.UseString(event.SubscriptionID), err)
+		scheduler.logger.Errorf("Failed to apply schedule for subscriptionID: %s. %s.", subscriptionID, err)
 	}
 	return next, alarmFatigue
 }
 
+// throttle keeps existing throttling until next, otherwise if trigger switches more than .count times in .duration,
+// it delays next delivery for .delay and stores it with setThrottling, processing stops after first condition matches
+func (scheduler *StandardScheduler) throttle(now, next, beginning time.Time, triggerID string, throttlingLevels []throttlingLevel, setThrottling func(next time.Time, reason string) error) (time.Time, bool) {
+	if next.After(now) {
+		scheduler.logger.Debugf("Using existing throttling for trigger %s: %s", triggerID, next)
+		return next, true
+	}
+	alarmFatigue := false
+	for _, level := range throttlingLevels {
+		from := now.Add(-level.duration)
+		if from.Before(beginning) {
+			from = beginning
+		}
+		count := scheduler.database.GetNotificationEventCount(triggerID, from.Unix())
+		if count >= level.count {
+			next = now.Add(level.delay)
+			reason := fmt.Sprintf("Trigger switched %d times in last %s, delaying next notification for %s", count, level.duration, level.delay)
+			scheduler.logger.Debugf("Trigger %s: %s", triggerID, reason)
+			if err := setThrottling(next, reason); err != nil {
+				scheduler.logger.Errorf("Failed to set trigger throttling timestamp: %s", err)
+			}
+			return next, true
+		} else if count == level.count-1 {
+			alarmFatigue = true
+		}
+	}
+	return now, alarmFatigue
+}
+
 func calculateNextDelivery(schedule *moira.ScheduleData, nextTime time.Time) (time.Time, error) {
 
 	if len(schedule.Days) != 0 && len(schedule.Days) != 7 {
@@ -42,7 +42,7 @@ func TestThrottling(t *testing.T) {
 	dataBase := mock_moira_alert.NewMockDatabase(mockCtrl)
 	logger, _ := logging.GetLogger("Scheduler")
 	metrics2 := metrics.ConfigureNotifierMetrics("notifier")
-	scheduler := NewScheduler(dataBase, logger, metrics2)
+	scheduler := NewScheduler(dataBase, logger, metrics2, nil)
 
 	now := time.Now()
 
@@ -126,7 +126,7 @@ func TestSubscriptionSchedule(t *testing.T) {
 	dataBase := mock_moira_alert.NewMockDatabase(mockCtrl)
 	logger, _ := logging.GetLogger("Scheduler")
 	metrics2 := metrics.ConfigureNotifierMetrics("notifier")
-	scheduler := NewScheduler(dataBase, logger, metrics2)
+	scheduler := NewScheduler(dataBase, logger, metrics2, nil)
 
 	Convey("Throttling disabled", t, func() {
 		now := time.Unix(1441187115, 0)
@@ -198,7 +198,7 @@ func TestSubscriptionSchedule(t *testing.T) {
 			dataBase.EXPECT().GetSubscription(*event.SubscriptionID).Return(subscription, nil)
 			dataBase.EXPECT().GetNotificationEventCount(event.TriggerID, now.Add(-time.Hour*3).Unix()).Return(int64(10))
 			dataBase.EXPECT().GetNotificationEventCount(event.TriggerID, now.Add(-time.Hour).Unix()).Return(int64(10))
-			dataBase.EXPECT().SetTriggerThrottling(event.TriggerID, now.Add(time.Hour/2)).Return(nil)
+			dataBase.EXPECT().SetTriggerThrottling(event.TriggerID, now.Add(time.Hour/2), "Trigger switched 10 times in last 1h0m0s, delaying next notification for 30m0s").Return(nil)
 
 			next, throttled := scheduler.calculateNextDelivery(now, &event)
 			So(next, ShouldResemble, time.Unix(1441135800, 0))
@@ -210,7 +210,7 @@ func TestSubscriptionSchedule(t *testing.T) {
 			dataBase.EXPECT().GetTriggerThrottling(event.TriggerID).Return(time.Unix(0, 0), time.Unix(0, 0))
 			dataBase.EXPECT().GetSubscription(*event.SubscriptionID).Return(subscription, nil)
 			dataBase.EXPECT().GetNotificationEventCount(event.TriggerID, now.Add(-time.Hour*3).Unix()).Return(int64(20))
-			dataBase.EXPECT().SetTriggerThrottling(event.TriggerID, now.Add(time.Hour)).Return(nil)
+			dataBase.EXPECT().SetTriggerThrottling(event.TriggerID, now.Add(time.Hour), "Trigger switched 20 times in last 3h0m0s, delaying next notification for 1h0m0s").Return(nil)
 
 			next, throttled := scheduler.calculateNextDelivery(now, &event)
 			So(next, ShouldResemble, now.Add(time.Hour))
@@ -218,6 +218,63 @@ func TestSubscriptionSchedule(t *testing.T) {
 			mockCtrl.Finish()
 		})
 
+		Convey("Subscription throttling levels override default, should next timestamp in 5 minutes", func() {
+			subscription := subscription
+			subscription.ThrottlingLevels = []moira.ThrottlingLevel{{Duration: 600, Delay: 300, Count: 5}}
+			dataBase.EXPECT().GetTriggerThrottling(event.TriggerID).Return(time.Unix(0, 0), time.Unix(0, 0))
+			dataBase.EXPECT().GetSubscription(*event.SubscriptionID).Return(subscription, nil)
+			dataBase.EXPECT().GetSubscriptionThrottling(event.TriggerID, *event.SubscriptionID).Return(time.Unix(0, 0))
+			dataBase.EXPECT().GetNotificationEventCount(event.TriggerID, now.Add(-time.Minute*10).Unix()).Return(int64(5))
+			dataBase.EXPECT().SetSubscriptionThrottling(event.TriggerID, *event.SubscriptionID, now.Add(time.Minute*5)).Return(nil)
+
+			next, throttled := scheduler.calculateNextDelivery(now, &event)
+			So(next, ShouldResemble, now.Add(time.Minute*5))
+			So(throttled, ShouldBeTrue)
+			mockCtrl.Finish()
+		})
+
+		Convey("Subscriptions with different throttling levels are throttled separately", func() {
+			otherSubID := "SubscriptionID-000000000000002"
+			otherEvent := event
+			otherEvent.SubscriptionID = &otherSubID
+			ownSubscription := subscription
+			ownSubscription.ThrottlingLevels = []moira.ThrottlingLevel{{Duration: 600, Delay: 300, Count: 5}}
+			otherSubscription := subscription
+			otherSubscription.ThrottlingLevels = []moira.ThrottlingLevel{{Duration: 600, Delay: 1800, Count: 5}}
+
+			dataBase.EXPECT().GetTriggerThrottling(event.TriggerID).Return(time.Unix(0, 0), time.Unix(0, 0)).Times(2)
+			dataBase.EXPECT().GetSubscription(*event.SubscriptionID).Return(ownSubscription, nil)
+			dataBase.EXPECT().GetSubscription(otherSubID).Return(otherSubscription, nil)
+			dataBase.EXPECT().GetSubscriptionThrottling(event.TriggerID, *event.SubscriptionID).Return(time.Unix(0, 0))
+			dataBase.EXPECT().GetSubscriptionThrottling(event.TriggerID, otherSubID).Return(time.Unix(0, 0))
+			dataBase.EXPECT().GetNotificationEventCount(event.TriggerID, now.Add(-time.Minute*10).Unix()).Return(int64(5)).Times(2)
+			dataBase.EXPECT().SetSubscriptionThrottling(event.TriggerID, *event.SubscriptionID, now.Add(time.Minute*5)).Return(nil)
+			dataBase.EXPECT().SetSubscriptionThrottling(event.TriggerID, otherSubID, now.Add(time.Minute*30)).Return(nil)
+
+			next, throttled := scheduler.calculateNextDelivery(now, &event)
+			So(next, ShouldResemble, now.Add(time.Minute*5))
+			So(throttled, ShouldBeTrue)
+
+			next, throttled = scheduler.calculateNextDelivery(now, &otherEvent)
+			So(next, ShouldResemble, now.Add(time.Minute*30))
+			So(throttled, ShouldBeTrue)
+			mockCtrl.Finish()
+		})
+
+		Convey("Subscription with own throttling levels ignores trigger throttling", func() {
+			subscription := subscription
+			subscription.ThrottlingLevels = []moira.ThrottlingLevel{{Duration: 600, Delay: 300, Count: 5}}
+			dataBase.EXPECT().GetTriggerThrottling(event.TriggerID).Return(time.Unix(1441148000, 0), time.Unix(0, 0))
+			dataBase.EXPECT().GetSubscription(*event.SubscriptionID).Return(subscription, nil)
+			dataBase.EXPECT().GetSubscriptionThrottling(event.TriggerID, *event.SubscriptionID).Return(time.Unix(0, 0))
+			dataBase.EXPECT().GetNotificationEventCount(event.TriggerID, now.Add(-time.Minute*10).Unix()).Return(int64(1))
+
+			next, throttled := scheduler.calculateNextDelivery(now, &event)
+			So(next, ShouldResemble, now)
+			So(throttled, ShouldBeFalse)
+			mockCtrl.Finish()
+		})
+
 		Convey("Trigger already alarm fatigue, should has old throttled value", func() {
 			dataBase.EXPECT().GetTriggerThrottling(event.TriggerID).Return(time.Unix(1441148000, 0), time.Unix(0, 0))
 			dataBase.EXPECT().GetSubscription(*event.SubscriptionID).Return(subscription, nil)
@@ -0,0 +1,85 @@
+package filter
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/moira-alert/moira/filter"
+	"github.com/moira-alert/moira/metrics/graphite/go-metrics"
+	"github.com/moira-alert/moira/mock/moira-alert"
+	"github.com/op/go-logging"
+)
+
+var globPatternTemplates = []string{
+	"Glob%d.srv-{web,api,db}-{01,02,03}.cpu[0-9]*.{user,system}",
+	"Glob%d.app?.*.requests.{2xx,4xx,5xx}",
+	"Glob%d.dc[a-c].host-[0-9][0-9].disk.*",
+	"Glob%d.{front{1,2},back}.queue-[!0-9]*.size",
+}
+
+var globMetricTemplates = []string{
+	"Glob%d.srv-api-02.cpu7_total.system",
+	"Glob%d.app1.instance.requests.5xx",
+	"Glob%d.dcb.host-42.disk.sda",
+	"Glob%d.front2.queue-mail.size",
+	"Glob%d.srv-cache-02.cpu7_total.system",
+	"Glob%d.dcd.host-4.disk.sda",
+}
+
+func BenchmarkProcessIncomingMetricGlobs(b *testing.B) {
+	patterns := make([]string, 0, len(globPatternTemplates)*500)
+	for i := 0; i < 500; i++ {
+		for _, template := range globPatternTemplates {
+			patterns = append(patterns, fmt.Sprintf(template, i))
+		}
+	}
+
+	mockCtrl := gomock.NewController(b)
+	database := mock_moira_alert.NewMockDatabase(mockCtrl)
+	logger, _ := logging.GetLogger("Benchmark")
+
+	database.EXPECT().GetPatterns().Return(patterns, nil)
+	patternsStorage, err := filter.NewPatternStorage(database, metrics.ConfigureFilterMetrics("test"), logger)
+	if err != nil {
+		b.Errorf("Can not create new cache storage %s", err)
+	}
+
+	timestamp := time.Now().Unix()
+	testMetricsLines := make([][]byte, 0, b.N)
+	for i := 0; i < b.N; i++ {
+		metric := fmt.Sprintf(globMetricTemplates[rand.Intn(len(globMetricTemplates))], rand.Intn(500))
+		testMetricsLines = append(testMetricsLines, []byte(fmt.Sprintf("%s %f %d", metric, rand.Float32(), timestamp)))
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		patternsStorage.ProcessIncomingMetric(testMetricsLines[i])
+	}
+}
+
+func BenchmarkBuildTreeGlobs(b *testing.B) {
+	patterns := make([]string, 0, len(globPatternTemplates)*500)
+	for i := 0; i < 500; i++ {
+		for _, template := range globPatternTemplates {
+			patterns = append(patterns, fmt.Sprintf(template, i))
+		}
+	}
+
+	mockCtrl := gomock.NewController(b)
+	database := mock_moira_alert.NewMockDatabase(mockCtrl)
+	logger, _ := logging.GetLogger("Benchmark")
+
+	database.EXPECT().GetPatterns().Return(patterns, nil).Times(b.N + 1)
+	patternsStorage, err := filter.NewPatternStorage(database, metrics.ConfigureFilterMetrics("test"), logger)
+	if err != nil {
+		b.Errorf("Can not create new cache storage %s", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		patternsStorage.RefreshTree()
+	}
+}
@@ -0,0 +1,18 @@
+package prometheus
+
+import "time"
+
+// Config represents Prometheus compatible HTTP API settings
+type Config struct {
+	URL           string
+	CheckInterval time.Duration
+	Timeout       time.Duration
+	Step          time.Duration
+	User          string
+	Password      string
+}
+
+// IsEnabled returns true if Prometheus is configured
+func (config *Config) IsEnabled() bool {
+	return config != nil && config.URL != "" && config.CheckInterval > 0 && config.Step >= time.Second
+}
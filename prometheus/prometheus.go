@@ -0,0 +1,83 @@
+package prometheus
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-graphite/carbonapi/expr/types"
+)
+
+const queryRangePath = "/api/v1/query_range"
+
+// ErrPrometheusNotConfigured used if prometheus trigger is checked while Prometheus is not configured
+var ErrPrometheusNotConfigured = fmt.Errorf("prometheus is not configured")
+
+// Fetch evaluates PromQL query by Prometheus query_range API and returns its time series.
+// Query is evaluated every config.Step seconds starting from from rounded down to the step,
+// so values of all series share the same timestamps
+func Fetch(config *Config, query string, from int64, until int64) ([]*types.MetricData, error) {
+	if !config.IsEnabled() {
+		return nil, ErrPrometheusNotConfigured
+	}
+	step := int64(config.Step.Seconds())
+	from -= from % step
+	request, err := prepareRequest(config, query, from, until, step)
+	if err != nil {
+		return nil, err
+	}
+	body, statusCode, err := makeRequest(config, request)
+	if err != nil {
+		return nil, err
+	}
+	metricsData, err := decodeBody(body, from, until, step)
+	if err != nil {
+		if statusCode != http.StatusOK {
+			return nil, fmt.Errorf("Prometheus %s responded with status %d: %s", hideQuery(request.URL), statusCode, string(body))
+		}
+		return nil, fmt.Errorf("Failed to decode prometheus response for %s: %s", query, err.Error())
+	}
+	return metricsData, nil
+}
+
+func prepareRequest(config *Config, query string, from int64, until int64, step int64) (*http.Request, error) {
+	request, err := http.NewRequest("GET", strings.TrimSuffix(config.URL, "/")+queryRangePath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create prometheus request: %s", err.Error())
+	}
+	values := request.URL.Query()
+	values.Add("query", query)
+	values.Add("start", strconv.FormatInt(from, 10))
+	values.Add("end", strconv.FormatInt(until, 10))
+	values.Add("step", strconv.FormatInt(step, 10))
+	request.URL.RawQuery = values.Encode()
+	if config.User != "" && config.Password != "" {
+		request.SetBasicAuth(config.User, config.Password)
+	}
+	return request, nil
+}
+
+func makeRequest(config *Config, request *http.Request) ([]byte, int, error) {
+	client := &http.Client{Timeout: config.Timeout}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, 0, fmt.Errorf("Failed to request prometheus %s: %s", hideQuery(request.URL), err.Error())
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("Failed to read prometheus response: %s", err.Error())
+	}
+	return body, response.StatusCode, nil
+}
+
+// hideQuery returns request url without query not to log queries and credentials
+func hideQuery(requestURL *url.URL) string {
+	withoutQuery := *requestURL
+	withoutQuery.RawQuery = ""
+	withoutQuery.User = nil
+	return withoutQuery.String()
+}
@@ -0,0 +1,101 @@
+package prometheus
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFetch(t *testing.T) {
+	var path string
+	var query map[string][]string
+	var user, password string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		path = request.URL.Path
+		query = request.URL.Query()
+		user, password, _ = request.BasicAuth()
+		if request.URL.Query().Get("query") == "bad(" {
+			writer.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(writer, `{"status": "error", "errorType": "bad_data", "error": "parse error"}`)
+			return
+		}
+		fmt.Fprint(writer, `{"status": "success", "data": {"resultType": "matrix", "result": [
+			{"metric": {"__name__": "up", "job": "node", "instance": "host:9100"}, "values": [[60, "1"], [180, "0"], [240, "NaN"]]},
+			{"metric": {}, "values": [[120, "+Inf"]]}
+		]}}`)
+	}))
+	defer server.Close()
+	config := &Config{URL: server.URL + "/", CheckInterval: time.Minute, Timeout: time.Second, Step: time.Minute, User: "user", Password: "pass"}
+
+	Convey("Should request query_range API and decode series", t, func() {
+		metricsData, err := Fetch(config, "up", 70, 240)
+		So(err, ShouldBeNil)
+		So(path, ShouldEqual, "/api/v1/query_range")
+		So(query["query"], ShouldResemble, []string{"up"})
+		So(query["start"], ShouldResemble, []string{"60"})
+		So(query["end"], ShouldResemble, []string{"240"})
+		So(query["step"], ShouldResemble, []string{"60"})
+		So(user, ShouldEqual, "user")
+		So(password, ShouldEqual, "pass")
+		So(metricsData, ShouldHaveLength, 2)
+
+		So(metricsData[0].Name, ShouldEqual, `up{instance="host:9100", job="node"}`)
+		So(metricsData[0].StartTime, ShouldEqual, 60)
+		So(metricsData[0].StepTime, ShouldEqual, 60)
+		So(metricsData[0].StopTime, ShouldEqual, 300)
+		So(metricsData[0].Values[0], ShouldEqual, 1)
+		So(metricsData[0].Values[2], ShouldEqual, 0)
+		So(metricsData[0].IsAbsent, ShouldResemble, []bool{false, true, false, true})
+
+		So(metricsData[1].Name, ShouldEqual, "{}")
+		So(math.IsInf(metricsData[1].Values[1], 1), ShouldBeTrue)
+		So(metricsData[1].IsAbsent, ShouldResemble, []bool{true, false, true, true})
+	})
+
+	Convey("Should return error on bad query", t, func() {
+		_, err := Fetch(config, "bad(", 60, 240)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Should return error if prometheus is not configured", t, func() {
+		_, err := Fetch(&Config{}, "up", 60, 240)
+		So(err, ShouldEqual, ErrPrometheusNotConfigured)
+	})
+}
+
+func TestValidateQuery(t *testing.T) {
+	Convey("Valid queries", t, func() {
+		queries := []string{
+			"up",
+			`sum by (job) (rate(http_requests_total{code=~"5.."}[5m]))`,
+			`count(node_uname_info{release="4.4(x)"})`,
+			"label_replace(up, \"a\", `$1`, \"job\", \"(.*)\")",
+		}
+		for _, query := range queries {
+			So(ValidateQuery(query), ShouldBeNil)
+		}
+	})
+
+	Convey("Invalid queries", t, func() {
+		queries := []string{
+			"",
+			"  ",
+			"sum(rate(x[5m])",
+			"rate(x[5m)]",
+			`up{job="node}`,
+			"up)",
+			"rate(foo[5])",
+			"sum by foo",
+			"rate(foo)",
+			"foo +",
+		}
+		for _, query := range queries {
+			So(ValidateQuery(query), ShouldNotBeNil)
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package prometheus
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-graphite/carbonapi/expr/types"
+	pb "github.com/go-graphite/carbonzipper/carbonzipperpb3"
+)
+
+const statusSuccess = "success"
+
+// queryRangeResponse is Prometheus query_range API json response
+type queryRangeResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string        `json:"resultType"`
+		Result     []rangeSeries `json:"result"`
+	} `json:"data"`
+}
+
+// rangeSeries is single series of matrix result with [timestamp, "value"] pairs
+type rangeSeries struct {
+	Metric map[string]string `json:"metric"`
+	Values [][2]interface{}  `json:"values"`
+}
+
+// decodeBody converts Prometheus matrix result to metric data with values placed on from..until grid of step,
+// timestamps without samples are marked as absent
+func decodeBody(body []byte, from int64, until int64, step int64) ([]*types.MetricData, error) {
+	var response queryRangeResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+	if response.Status != statusSuccess {
+		return nil, fmt.Errorf("%s: %s", response.ErrorType, response.Error)
+	}
+	if response.Data.ResultType != "matrix" {
+		return nil, fmt.Errorf("unexpected result type '%s'", response.Data.ResultType)
+	}
+	pointsCount := (until-from)/step + 1
+	metricsData := make([]*types.MetricData, 0, len(response.Data.Result))
+	for _, series := range response.Data.Result {
+		fetchResponse := pb.FetchResponse{
+			Name:      seriesName(series.Metric),
+			StartTime: int32(from),
+			StopTime:  int32(from + pointsCount*step),
+			StepTime:  int32(step),
+			Values:    make([]float64, pointsCount),
+			IsAbsent:  make([]bool, pointsCount),
+		}
+		for i := range fetchResponse.Values {
+			fetchResponse.Values[i] = math.NaN()
+			fetchResponse.IsAbsent[i] = true
+		}
+		for _, point := range series.Values {
+			timestamp, value, err := parsePoint(point)
+			if err != nil {
+				return nil, err
+			}
+			index := (timestamp - from) / step
+			if timestamp < from || index >= pointsCount {
+				continue
+			}
+			fetchResponse.Values[index] = value
+			fetchResponse.IsAbsent[index] = math.IsNaN(value)
+		}
+		metricsData = append(metricsData, &types.MetricData{FetchResponse: fetchResponse})
+	}
+	return metricsData, nil
+}
+
+func parsePoint(point [2]interface{}) (int64, float64, error) {
+	timestamp, ok := point[0].(float64)
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid sample timestamp %v", point[0])
+	}
+	rawValue, ok := point[1].(string)
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid sample value %v", point[1])
+	}
+	value, err := strconv.ParseFloat(rawValue, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid sample value %s", rawValue)
+	}
+	return int64(timestamp), value, nil
+}
+
+// seriesName formats series labels as in Prometheus: name{label1="value1", label2="value2"}
+func seriesName(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for label := range labels {
+		if label != "__name__" {
+			names = append(names, label)
+		}
+	}
+	if len(names) == 0 {
+		if name, ok := labels["__name__"]; ok {
+			return name
+		}
+		return "{}"
+	}
+	sort.Strings(names)
+	pairs := make([]string, 0, len(names))
+	for _, label := range names {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", label, labels[label]))
+	}
+	return labels["__name__"] + "{" + strings.Join(pairs, ", ") + "}"
+}
@@ -0,0 +1,20 @@
+package prometheus
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/prometheus/promql"
+)
+
+// ValidateQuery parses PromQL query, so syntax errors and wrong argument types
+// are reported on trigger save instead of trigger check
+func ValidateQuery(query string) error {
+	if strings.TrimSpace(query) == "" {
+		return fmt.Errorf("query is empty")
+	}
+	if _, err := promql.ParseExpr(query); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package remote
+
+import "time"
+
+// Config represents remote Graphite render API settings
+type Config struct {
+	URL           string
+	CheckInterval time.Duration
+	Timeout       time.Duration
+	User          string
+	Password      string
+}
+
+// IsEnabled returns true if remote Graphite is configured
+func (config *Config) IsEnabled() bool {
+	return config != nil && config.URL != "" && config.CheckInterval > 0
+}
@@ -0,0 +1,82 @@
+package remote
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/go-graphite/carbonapi/expr/types"
+)
+
+// ErrRemoteNotConfigured used if remote trigger is checked while remote Graphite is not configured
+var ErrRemoteNotConfigured = fmt.Errorf("remote graphite is not configured")
+
+// Fetch evaluates target by remote Graphite render API and returns its time series.
+// Values of the last incomplete interval are dropped unless allowRealTimeAlerting is set
+func Fetch(config *Config, target string, from int64, until int64, allowRealTimeAlerting bool) ([]*types.MetricData, error) {
+	if !config.IsEnabled() {
+		return nil, ErrRemoteNotConfigured
+	}
+	request, err := prepareRequest(config, target, from, until)
+	if err != nil {
+		return nil, err
+	}
+	body, err := makeRequest(config, request)
+	if err != nil {
+		return nil, err
+	}
+	metricsData, err := decodeBody(body, from)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode remote graphite response for %s: %s", target, err.Error())
+	}
+	if !allowRealTimeAlerting {
+		for _, metricData := range metricsData {
+			dropIncompleteValues(metricData, until)
+		}
+	}
+	return metricsData, nil
+}
+
+func prepareRequest(config *Config, target string, from int64, until int64) (*http.Request, error) {
+	request, err := http.NewRequest("GET", config.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create remote graphite request: %s", err.Error())
+	}
+	query := request.URL.Query()
+	query.Add("format", "json")
+	query.Add("target", target)
+	query.Add("from", strconv.FormatInt(from, 10))
+	query.Add("until", strconv.FormatInt(until, 10))
+	request.URL.RawQuery = query.Encode()
+	if config.User != "" && config.Password != "" {
+		request.SetBasicAuth(config.User, config.Password)
+	}
+	return request, nil
+}
+
+func makeRequest(config *Config, request *http.Request) ([]byte, error) {
+	client := &http.Client{Timeout: config.Timeout}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to request remote graphite %s: %s", hideQuery(request.URL), err.Error())
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read remote graphite response: %s", err.Error())
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Remote graphite %s responded with status %d: %s", hideQuery(request.URL), response.StatusCode, string(body))
+	}
+	return body, nil
+}
+
+// hideQuery returns request url without query not to log targets and credentials
+func hideQuery(requestURL *url.URL) string {
+	withoutQuery := *requestURL
+	withoutQuery.RawQuery = ""
+	withoutQuery.User = nil
+	return withoutQuery.String()
+}
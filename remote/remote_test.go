@@ -0,0 +1,79 @@
+package remote
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/moira-alert/moira/target"
+)
+
+func TestFetch(t *testing.T) {
+	var query map[string][]string
+	var user, password string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		query = request.URL.Query()
+		user, password, _ = request.BasicAuth()
+		if request.URL.Query().Get("target") == "bad" {
+			writer.WriteHeader(http.StatusBadRequest)
+			writer.Write([]byte("bad target"))
+			return
+		}
+		fmt.Fprint(writer, `[{"target": "one.two", "datapoints": [[1, 60], [null, 120], [3, 180]]}, {"target": "empty", "datapoints": []}]`)
+	}))
+	defer server.Close()
+	config := &Config{URL: server.URL + "/render", CheckInterval: time.Minute, Timeout: time.Second, User: "user", Password: "pass"}
+
+	Convey("Should request render API and decode series", t, func() {
+		metricsData, err := Fetch(config, "sumSeries(one.*)", 60, 240, true)
+		So(err, ShouldBeNil)
+		So(query["target"], ShouldResemble, []string{"sumSeries(one.*)"})
+		So(query["from"], ShouldResemble, []string{"60"})
+		So(query["until"], ShouldResemble, []string{"240"})
+		So(query["format"], ShouldResemble, []string{"json"})
+		So(user, ShouldEqual, "user")
+		So(password, ShouldEqual, "pass")
+		So(metricsData, ShouldHaveLength, 2)
+		So(metricsData[0].Name, ShouldEqual, "one.two")
+		So(metricsData[0].StartTime, ShouldEqual, 60)
+		So(metricsData[0].StepTime, ShouldEqual, 60)
+		So(metricsData[0].StopTime, ShouldEqual, 240)
+		So(metricsData[0].Values[0], ShouldEqual, 1)
+		So(math.IsNaN(metricsData[0].Values[1]), ShouldBeTrue)
+		So(metricsData[0].IsAbsent, ShouldResemble, []bool{false, true, false})
+		So(metricsData[1].Values, ShouldBeEmpty)
+	})
+
+	Convey("Should decode series without datapoints with non-zero step", t, func() {
+		metricsData, err := Fetch(config, "empty", 60, 240, false)
+		So(err, ShouldBeNil)
+		empty := metricsData[1]
+		So(empty.StartTime, ShouldEqual, 60)
+		So(empty.StopTime, ShouldEqual, 60)
+		So(empty.StepTime, ShouldEqual, defaultStepTime)
+		timeSeries := target.TimeSeries{MetricData: *empty}
+		So(math.IsNaN(timeSeries.GetTimestampValue(180)), ShouldBeTrue)
+	})
+
+	Convey("Should drop incomplete values without real time alerting", t, func() {
+		metricsData, err := Fetch(config, "one.two", 60, 200, false)
+		So(err, ShouldBeNil)
+		So(metricsData[0].Values, ShouldHaveLength, 2)
+		So(metricsData[0].StopTime, ShouldEqual, 180)
+	})
+
+	Convey("Should return error on bad response", t, func() {
+		_, err := Fetch(config, "bad", 60, 240, true)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Should return error if remote is not configured", t, func() {
+		_, err := Fetch(&Config{}, "one.two", 60, 240, true)
+		So(err, ShouldEqual, ErrRemoteNotConfigured)
+	})
+}
@@ -0,0 +1,74 @@
+package remote
+
+import (
+	"encoding/json"
+	"math"
+
+	"github.com/go-graphite/carbonapi/expr/types"
+	pb "github.com/go-graphite/carbonzipper/carbonzipperpb3"
+)
+
+// graphiteMetric is single series of Graphite render API json response
+type graphiteMetric struct {
+	Target     string        `json:"target"`
+	DataPoints [][2]*float64 `json:"datapoints"`
+}
+
+// defaultStepTime is used for series which have less than two datapoints to take step from
+const defaultStepTime = 60
+
+// decodeBody converts Graphite json series with [value, timestamp] datapoints to metric data.
+// Step is taken from the first two datapoints, null values are marked as absent.
+// Series without datapoints start at from and never have zero step
+func decodeBody(body []byte, from int64) ([]*types.MetricData, error) {
+	var graphiteMetrics []graphiteMetric
+	if err := json.Unmarshal(body, &graphiteMetrics); err != nil {
+		return nil, err
+	}
+	metricsData := make([]*types.MetricData, 0, len(graphiteMetrics))
+	for _, metric := range graphiteMetrics {
+		fetchResponse := pb.FetchResponse{
+			Name:      metric.Target,
+			Values:    make([]float64, 0, len(metric.DataPoints)),
+			IsAbsent:  make([]bool, 0, len(metric.DataPoints)),
+			StartTime: int32(from),
+			StepTime:  defaultStepTime,
+		}
+		if len(metric.DataPoints) > 0 {
+			fetchResponse.StartTime = int32(getTimestamp(metric.DataPoints[0]))
+		}
+		if len(metric.DataPoints) > 1 {
+			if stepTime := int32(getTimestamp(metric.DataPoints[1])) - fetchResponse.StartTime; stepTime > 0 {
+				fetchResponse.StepTime = stepTime
+			}
+		}
+		for _, point := range metric.DataPoints {
+			if point[0] == nil {
+				fetchResponse.Values = append(fetchResponse.Values, math.NaN())
+				fetchResponse.IsAbsent = append(fetchResponse.IsAbsent, true)
+				continue
+			}
+			fetchResponse.Values = append(fetchResponse.Values, *point[0])
+			fetchResponse.IsAbsent = append(fetchResponse.IsAbsent, false)
+		}
+		fetchResponse.StopTime = fetchResponse.StartTime + int32(len(fetchResponse.Values))*fetchResponse.StepTime
+		metricsData = append(metricsData, &types.MetricData{FetchResponse: fetchResponse})
+	}
+	return metricsData, nil
+}
+
+func getTimestamp(point [2]*float64) int64 {
+	if point[1] == nil {
+		return 0
+	}
+	return int64(*point[1])
+}
+
+// dropIncompleteValues removes values of intervals which end after until
+func dropIncompleteValues(metricData *types.MetricData, until int64) {
+	for len(metricData.Values) > 0 && int64(metricData.StopTime) > until {
+		metricData.Values = metricData.Values[:len(metricData.Values)-1]
+		metricData.IsAbsent = metricData.IsAbsent[:len(metricData.IsAbsent)-1]
+		metricData.StopTime -= metricData.StepTime
+	}
+}
@@ -28,6 +28,7 @@ type Sender struct {
 	TemplateName   string
 	log            moira.Logger
 	Template       *template.Template
+	DigestTemplate *template.Template
 	location       *time.Location
 	DateTimeFormat string
 }
@@ -53,6 +54,10 @@ type triggerData struct {
 	Items        []*templateRow
 }
 
+type digestData struct {
+	Triggers []triggerData
+}
+
 // Init read yaml config
 func (sender *Sender) Init(senderSettings map[string]string, logger moira.Logger, location *time.Location, dateTimeFormat string) error {
 	sender.setLogger(logger)
@@ -66,6 +71,7 @@ func (sender *Sender) Init(senderSettings map[string]string, logger moira.Logger
 	sender.TemplateFile = senderSettings["template_file"]
 	sender.location = location
 	sender.DateTimeFormat = dateTimeFormat
+	sender.DigestTemplate = template.Must(template.New("digest").Parse(defaultDigestTemplate))
 
 	if sender.Username == "" {
 		sender.Username = sender.From
@@ -113,9 +119,15 @@ func (sender *Sender) Init(senderSettings map[string]string, logger moira.Logger
 
 // SendEvents implements Sender interface Send
 func (sender *Sender) SendEvents(events moira.NotificationEvents, contact moira.ContactData, trigger moira.TriggerData, throttled bool) error {
+	return sender.dialAndSend(sender.makeMessage(events, contact, trigger, throttled))
+}
 
-	m := sender.makeMessage(events, contact, trigger, throttled)
+// SendDigest implements DigestSender interface SendDigest
+func (sender *Sender) SendDigest(events moira.NotificationEvents, contact moira.ContactData, triggers []moira.TriggerData, throttledTriggers map[string]bool) error {
+	return sender.dialAndSend(sender.makeDigestMessage(events, contact, triggers, throttledTriggers))
+}
 
+func (sender *Sender) dialAndSend(m *gomail.Message) error {
 	d := gomail.Dialer{
 		Host: sender.SMTPhost,
 		Port: int(sender.SMTPport),
@@ -140,14 +152,57 @@ func (sender *Sender) makeMessage(events moira.NotificationEvents, contact moira
 	tags := trigger.GetTags()
 
 	subject := fmt.Sprintf("%s %s %s (%d)", state, trigger.Name, tags, len(events))
+	templateData := sender.makeTriggerData(events, trigger, throttled)
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", sender.From)
+	m.SetHeader("To", contact.Value)
+	m.SetHeader("Subject", subject)
+	m.AddAlternativeWriter("text/html", func(w io.Writer) error {
+		return sender.Template.ExecuteTemplate(w, sender.TemplateName, templateData)
+	})
+
+	return m
+}
+
+func (sender *Sender) makeDigestMessage(events moira.NotificationEvents, contact moira.ContactData, triggers []moira.TriggerData, throttledTriggers map[string]bool) *gomail.Message {
+	state := events.GetSubjectState()
+	subject := fmt.Sprintf("%s Digest: %d events of %d triggers", state, len(events), len(triggers))
+
+	templateData := digestData{
+		Triggers: make([]triggerData, 0, len(triggers)),
+	}
+	for _, trigger := range triggers {
+		triggerEvents := make(moira.NotificationEvents, 0)
+		for _, event := range events {
+			if event.TriggerID == trigger.ID {
+				triggerEvents = append(triggerEvents, event)
+			}
+		}
+		if len(triggerEvents) > 0 {
+			templateData.Triggers = append(templateData.Triggers, sender.makeTriggerData(triggerEvents, trigger, throttledTriggers[trigger.ID]))
+		}
+	}
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", sender.From)
+	m.SetHeader("To", contact.Value)
+	m.SetHeader("Subject", subject)
+	m.AddAlternativeWriter("text/html", func(w io.Writer) error {
+		return sender.DigestTemplate.Execute(w, templateData)
+	})
+
+	return m
+}
 
+func (sender *Sender) makeTriggerData(events moira.NotificationEvents, trigger moira.TriggerData, throttled bool) triggerData {
 	templateData := triggerData{
 		Link:         fmt.Sprintf("%s/trigger/%s", sender.FrontURI, events[0].TriggerID),
 		Description:  formatDescription(trigger.Desc),
 		Throttled:    throttled,
 		TriggerName:  trigger.Name,
-		Tags:         tags,
-		TriggerState: state,
+		Tags:         trigger.GetTags(),
+		TriggerState: events.GetSubjectState(),
 		Items:        make([]*templateRow, 0, len(events)),
 	}
 
@@ -163,16 +218,7 @@ func (sender *Sender) makeMessage(events moira.NotificationEvents, contact moira
 			Message:    moira.UseString(event.Message),
 		})
 	}
-
-	m := gomail.NewMessage()
-	m.SetHeader("From", sender.From)
-	m.SetHeader("To", contact.Value)
-	m.SetHeader("Subject", subject)
-	m.AddAlternativeWriter("text/html", func(w io.Writer) error {
-		return sender.Template.ExecuteTemplate(w, sender.TemplateName, templateData)
-	})
-
-	return m
+	return templateData
 }
 
 func (sender *Sender) setLogger(logger moira.Logger) {
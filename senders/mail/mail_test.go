@@ -53,6 +53,19 @@ func TestMail(t *testing.T) {
 		So(message.GetHeader("To")[0], ShouldEqual, contact.Value)
 		message.WriteTo(os.Stdout)
 	})
+
+	Convey("Make digest message", t, func() {
+		sender.DigestTemplate = template.Must(template.New("digest").Parse(defaultDigestTemplate))
+		trigger2 := moira.TriggerData{
+			ID:   "triggerID-0000000000002",
+			Name: "test trigger 2",
+		}
+		digestEvents := append(events, moira.NotificationEvent{Metric: "Metric of trigger 2", TriggerID: trigger2.ID, State: "ERROR"})
+		message := sender.makeDigestMessage(digestEvents, contact, []moira.TriggerData{trigger, trigger2}, map[string]bool{trigger2.ID: true})
+		So(message.GetHeader("To")[0], ShouldEqual, contact.Value)
+		So(message.GetHeader("Subject")[0], ShouldEqual, "TEST Digest: 11 events of 2 triggers")
+		message.WriteTo(os.Stdout)
+	})
 }
 
 func generateTestEvents(n int, subscriptionID string) chan *moira.NotificationEvent {
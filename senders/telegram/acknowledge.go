@@ -0,0 +1,128 @@
+package telegram
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tucnak/telebot"
+
+	"github.com/moira-alert/moira"
+	"github.com/moira-alert/moira/database"
+)
+
+const acknowledgeCommand = "/ack"
+
+var (
+	defaultAcknowledgementDuration = time.Hour
+	triggerLinkRegexp              = regexp.MustCompile(`/trigger/([\w-]+)`)
+)
+
+// handleAcknowledgement acknowledges trigger given by ID or by link in replied alert message:
+// "/ack [trigger_id] [duration]", duration is one hour by default
+func (sender *Sender) handleAcknowledgement(message *telebot.Message) error {
+	registered, err := sender.isRegisteredContact(message.Chat)
+	if err != nil {
+		return fmt.Errorf("failed to check chat %d is registered contact: %s", message.Chat.ID, err.Error())
+	}
+	if !registered {
+		sender.bot.Send(message.Chat, "Only chats registered as Moira contacts can acknowledge triggers")
+		return nil
+	}
+
+	triggerID, duration, err := parseAcknowledgement(message)
+	if err != nil {
+		sender.bot.Send(message.Chat, fmt.Sprintf("%s. Usage: reply to alert with %s [duration] or send %s <trigger_id> [duration]", err.Error(), acknowledgeCommand, acknowledgeCommand))
+		return nil
+	}
+
+	user := strings.Trim(fmt.Sprintf("%s %s", message.Sender.FirstName, message.Sender.LastName), " ")
+	if message.Sender.Username != "" {
+		user = "@" + message.Sender.Username
+	}
+	now := time.Now()
+	acknowledgement := &moira.Acknowledgement{
+		User:      user,
+		Timestamp: now.Unix(),
+		Until:     now.Add(duration).Unix(),
+	}
+	if err := sender.setTriggerCheckAcknowledgement(triggerID, acknowledgement); err != nil {
+		if err == database.ErrNil {
+			sender.bot.Send(message.Chat, fmt.Sprintf("Trigger %s is not found", triggerID))
+			return nil
+		}
+		return fmt.Errorf("failed to acknowledge trigger %s: %s", triggerID, err.Error())
+	}
+	if _, err := sender.DataBase.RemoveEscalationNotifications(triggerID, ""); err != nil {
+		return fmt.Errorf("failed to cancel escalations of trigger %s: %s", triggerID, err.Error())
+	}
+	sender.bot.Send(message.Chat, fmt.Sprintf("Okay, %s, trigger %s is acknowledged until %s", user, triggerID, now.Add(duration).In(sender.location).Format("2006/01/02 15:04")))
+	return nil
+}
+
+// setTriggerCheckAcknowledgement sets acknowledgement under trigger check lock, so it is not overwritten by check in progress
+func (sender *Sender) setTriggerCheckAcknowledgement(triggerID string, acknowledgement *moira.Acknowledgement) error {
+	if err := sender.DataBase.AcquireTriggerCheckLock(triggerID, 10); err != nil {
+		return err
+	}
+	defer sender.DataBase.DeleteTriggerCheckLock(triggerID)
+	return sender.DataBase.SetTriggerCheckAcknowledgement(triggerID, "", acknowledgement)
+}
+
+// isRegisteredContact checks that chat is the one the bot sends alerts of some Moira contact to
+func (sender *Sender) isRegisteredContact(chat *telebot.Chat) (bool, error) {
+	name := chat.Title
+	if chat.Type == "private" {
+		if chat.Username == "" {
+			return false, nil
+		}
+		name = "@" + chat.Username
+	}
+	id, err := sender.DataBase.GetIDByUsername(messenger, name)
+	if err == database.ErrNil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if id != strconv.FormatInt(chat.ID, 10) {
+		return false, nil
+	}
+	contacts, err := sender.DataBase.GetAllContacts()
+	if err != nil {
+		return false, err
+	}
+	for _, contact := range contacts {
+		if contact != nil && contact.Type == sender.contactType && contact.Value == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func parseAcknowledgement(message *telebot.Message) (string, time.Duration, error) {
+	_, args := parseCommand(message.Text)
+	triggerID := ""
+	if message.ReplyTo != nil {
+		if match := triggerLinkRegexp.FindStringSubmatch(message.ReplyTo.Text); match != nil {
+			triggerID = match[1]
+		}
+	}
+	if triggerID == "" {
+		if len(args) == 0 {
+			return "", 0, fmt.Errorf("Trigger is not specified")
+		}
+		triggerID, args = args[0], args[1:]
+	}
+
+	duration := defaultAcknowledgementDuration
+	if len(args) > 0 {
+		var err error
+		if duration, err = time.ParseDuration(args[0]); err != nil || duration <= 0 {
+			return "", 0, fmt.Errorf("Invalid duration %s", args[0])
+		}
+	}
+	return triggerID, duration, nil
+}
@@ -0,0 +1,122 @@
+package telegram
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/tucnak/telebot"
+
+	"github.com/moira-alert/moira"
+	"github.com/moira-alert/moira/database"
+	"github.com/moira-alert/moira/mock/moira-alert"
+)
+
+func TestParseAcknowledgement(t *testing.T) {
+	Convey("Trigger ID in command", t, func() {
+		triggerID, duration, err := parseAcknowledgement(&telebot.Message{Text: "/ack trigger-1 30m"})
+		So(err, ShouldBeNil)
+		So(triggerID, ShouldEqual, "trigger-1")
+		So(duration, ShouldEqual, 30*time.Minute)
+	})
+
+	Convey("Reply to alert message", t, func() {
+		message := &telebot.Message{
+			Text:    "/ack",
+			ReplyTo: &telebot.Message{Text: "ERROR Trigger (1)\n\nhttp://moira.example.com/trigger/4b0f6a7e-1cde-4c2a-9a3e-1d1b9f0c2e11\n"},
+		}
+		triggerID, duration, err := parseAcknowledgement(message)
+		So(err, ShouldBeNil)
+		So(triggerID, ShouldEqual, "4b0f6a7e-1cde-4c2a-9a3e-1d1b9f0c2e11")
+		So(duration, ShouldEqual, time.Hour)
+	})
+
+	Convey("Command addressed to bot", t, func() {
+		triggerID, duration, err := parseAcknowledgement(&telebot.Message{Text: "/ack@MoiraBot trigger-1 30m"})
+		So(err, ShouldBeNil)
+		So(triggerID, ShouldEqual, "trigger-1")
+		So(duration, ShouldEqual, 30*time.Minute)
+	})
+
+	Convey("No trigger", t, func() {
+		_, _, err := parseAcknowledgement(&telebot.Message{Text: "/ack"})
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Invalid duration", t, func() {
+		_, _, err := parseAcknowledgement(&telebot.Message{Text: "/ack trigger-1 forever"})
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestParseCommand(t *testing.T) {
+	Convey("Command with arguments", t, func() {
+		command, args := parseCommand("/ack trigger-1 30m")
+		So(command, ShouldEqual, acknowledgeCommand)
+		So(args, ShouldResemble, []string{"trigger-1", "30m"})
+	})
+
+	Convey("Command addressed to bot", t, func() {
+		command, args := parseCommand("/ack@MoiraBot")
+		So(command, ShouldEqual, acknowledgeCommand)
+		So(args, ShouldBeEmpty)
+	})
+
+	Convey("Other command with same prefix", t, func() {
+		command, _ := parseCommand("/ackfoo trigger-1")
+		So(command, ShouldNotEqual, acknowledgeCommand)
+	})
+
+	Convey("Not a command", t, func() {
+		command, _ := parseCommand("ack trigger-1")
+		So(command, ShouldBeEmpty)
+	})
+}
+
+func TestIsRegisteredContact(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	dataBase := mock_moira_alert.NewMockDatabase(mockCtrl)
+	sender := Sender{DataBase: dataBase, contactType: "telegram"}
+	chat := &telebot.Chat{ID: 123, Type: "private", Username: "user"}
+
+	Convey("Registered contact", t, func() {
+		dataBase.EXPECT().GetIDByUsername(messenger, "@user").Return("123", nil)
+		dataBase.EXPECT().GetAllContacts().Return([]*moira.ContactData{{Type: "telegram", Value: "@user"}}, nil)
+		registered, err := sender.isRegisteredContact(chat)
+		So(err, ShouldBeNil)
+		So(registered, ShouldBeTrue)
+	})
+
+	Convey("Chat has never talked to bot", t, func() {
+		dataBase.EXPECT().GetIDByUsername(messenger, "@user").Return("", database.ErrNil)
+		registered, err := sender.isRegisteredContact(chat)
+		So(err, ShouldBeNil)
+		So(registered, ShouldBeFalse)
+	})
+
+	Convey("Username belongs to another chat", t, func() {
+		dataBase.EXPECT().GetIDByUsername(messenger, "@user").Return("456", nil)
+		registered, err := sender.isRegisteredContact(chat)
+		So(err, ShouldBeNil)
+		So(registered, ShouldBeFalse)
+	})
+
+	Convey("No contact with chat username", t, func() {
+		dataBase.EXPECT().GetIDByUsername(messenger, "@user").Return("123", nil)
+		dataBase.EXPECT().GetAllContacts().Return([]*moira.ContactData{{Type: "telegram", Value: "@other"}, {Type: "mail", Value: "@user"}}, nil)
+		registered, err := sender.isRegisteredContact(chat)
+		So(err, ShouldBeNil)
+		So(registered, ShouldBeFalse)
+	})
+
+	Convey("Group chat", t, func() {
+		group := &telebot.Chat{ID: -100, Type: "group", Title: "Admins"}
+		dataBase.EXPECT().GetIDByUsername(messenger, "Admins").Return("-100", nil)
+		dataBase.EXPECT().GetAllContacts().Return([]*moira.ContactData{{Type: "telegram", Value: "Admins"}}, nil)
+		registered, err := sender.isRegisteredContact(group)
+		So(err, ShouldBeNil)
+		So(registered, ShouldBeTrue)
+	})
+}
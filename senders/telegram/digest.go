@@ -0,0 +1,73 @@
+package telegram
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/moira-alert/moira"
+)
+
+const digestThrottledNote = "\nPlease, fix your system or tune this trigger to generate less events."
+
+// SendDigest implements DigestSender interface SendDigest
+func (sender *Sender) SendDigest(events moira.NotificationEvents, contact moira.ContactData, triggers []moira.TriggerData, throttledTriggers map[string]bool) error {
+	message := sender.buildDigestMessage(events, triggers, throttledTriggers)
+
+	sender.logger.Debugf("Calling telegram api with chat_id %s and message body %s", contact.Value, message)
+
+	if err := sender.talk(contact.Value, message); err != nil {
+		return fmt.Errorf("Failed to send digest to telegram contact %s: %s. ", contact.Value, err)
+	}
+	return nil
+}
+
+// buildDigestMessage lists events grouped by trigger, events which do not fit into telegram message limit are skipped
+func (sender *Sender) buildDigestMessage(events moira.NotificationEvents, triggers []moira.TriggerData, throttledTriggers map[string]bool) string {
+	var message bytes.Buffer
+	state := events.GetSubjectState()
+	message.WriteString(fmt.Sprintf("%s%s Digest: %d events of %d triggers\n", emojiStates[state], state, len(events), len(triggers)))
+
+	skippedCount := 0
+	for _, trigger := range triggers {
+		triggerEvents := make(moira.NotificationEvents, 0)
+		for _, event := range events {
+			if event.TriggerID == trigger.ID {
+				triggerEvents = append(triggerEvents, event)
+			}
+		}
+		if len(triggerEvents) == 0 {
+			continue
+		}
+		triggerState := triggerEvents.GetSubjectState()
+		header := fmt.Sprintf("\n%s%s %s %s (%d)\n%s/trigger/%s", emojiStates[triggerState], triggerState, trigger.Name, trigger.GetTags(), len(triggerEvents), sender.FrontURI, trigger.ID)
+		if message.Len()+len(header) > telegramMessageLimit-400 {
+			skippedCount += len(triggerEvents)
+			continue
+		}
+		message.WriteString(header)
+		for i, event := range triggerEvents {
+			value := strconv.FormatFloat(moira.UseFloat64(event.Value), 'f', -1, 64)
+			eventTime := time.Unix(event.Timestamp, 0).In(sender.location)
+			line := fmt.Sprintf("\n%s: %s = %s (%s to %s)", eventTime.Format("15:04"), event.Metric, value, event.OldState, event.State)
+			if len(moira.UseString(event.Message)) > 0 {
+				line += fmt.Sprintf(". %s", moira.UseString(event.Message))
+			}
+			if message.Len()+len(line) > telegramMessageLimit-400 {
+				skippedCount += len(triggerEvents) - i
+				break
+			}
+			message.WriteString(line)
+		}
+		if throttledTriggers[trigger.ID] {
+			message.WriteString(digestThrottledNote)
+		}
+		message.WriteString("\n")
+	}
+
+	if skippedCount > 0 {
+		message.WriteString(fmt.Sprintf("\n...and %d more events.\n", skippedCount))
+	}
+	return message.String()
+}
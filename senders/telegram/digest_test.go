@@ -0,0 +1,52 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/moira-alert/moira"
+)
+
+func TestBuildDigestMessage(t *testing.T) {
+	sender := Sender{FrontURI: "http://moira.example.com", location: time.UTC}
+	value := float64(97.4)
+	triggers := []moira.TriggerData{
+		{ID: "trigger-1", Name: "Trigger 1"},
+		{ID: "trigger-2", Name: "Trigger 2"},
+	}
+
+	Convey("Throttled note is added to throttled trigger only", t, func() {
+		events := moira.NotificationEvents{
+			{TriggerID: "trigger-1", Metric: "metric.1", Value: &value, OldState: "OK", State: "ERROR", Timestamp: 150000000},
+			{TriggerID: "trigger-2", Metric: "metric.2", Value: &value, OldState: "OK", State: "WARN", Timestamp: 150000000},
+		}
+		message := sender.buildDigestMessage(events, triggers, map[string]bool{"trigger-2": true})
+		So(strings.Count(message, digestThrottledNote), ShouldEqual, 1)
+		So(message, ShouldEndWith, "metric.2 = 97.4 (OK to WARN)"+digestThrottledNote+"\n")
+		So(message, ShouldNotContainSubstring, "more events")
+	})
+
+	Convey("Events exceeding message limit are skipped", t, func() {
+		events := make(moira.NotificationEvents, 0, 400)
+		for i := 0; i < 400; i++ {
+			events = append(events, moira.NotificationEvent{
+				TriggerID: triggers[i%2].ID,
+				Metric:    fmt.Sprintf("some.long.metric.name.%d", i),
+				Value:     &value,
+				OldState:  "OK",
+				State:     "ERROR",
+				Timestamp: 150000000,
+			})
+		}
+		message := sender.buildDigestMessage(events, triggers, map[string]bool{"trigger-1": true, "trigger-2": true})
+		So(len(message), ShouldBeLessThanOrEqualTo, telegramMessageLimit)
+
+		sent := strings.Count(message, " = 97.4 ")
+		So(sent, ShouldBeGreaterThan, 0)
+		So(message, ShouldEndWith, fmt.Sprintf("\n...and %d more events.\n", len(events)-sent))
+	})
+}
@@ -8,7 +8,7 @@ import (
 	"github.com/tucnak/telebot"
 )
 
-// handleMessage handles incoming messages to start sending events to subscribers chats
+// handleMessage handles incoming messages to start sending events to subscribers chats and to acknowledge alerts
 func (sender *Sender) handleMessage(message *telebot.Message) error {
 	var err error
 	id := strconv.FormatInt(message.Chat.ID, 10)
@@ -16,7 +16,10 @@ func (sender *Sender) handleMessage(message *telebot.Message) error {
 	userTitle := strings.Trim(fmt.Sprintf("%s %s", message.Sender.FirstName, message.Sender.LastName), " ")
 	username := message.Chat.Username
 	chatType := message.Chat.Type
+	command, _ := parseCommand(message.Text)
 	switch {
+	case command == acknowledgeCommand:
+		err = sender.handleAcknowledgement(message)
 	case chatType == "private" && message.Text == "/start":
 		if username == "" {
 			sender.bot.Send(message.Chat, "Username is empty. Please add username in Telegram.")
@@ -42,3 +45,17 @@ func (sender *Sender) handleMessage(message *telebot.Message) error {
 	}
 	return err
 }
+
+// parseCommand splits message text into command and its arguments,
+// bot name of "/command@BotName" form is stripped from the command
+func parseCommand(text string) (string, []string) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return "", fields
+	}
+	command := fields[0]
+	if i := strings.Index(command, "@"); i >= 0 {
+		command = command[:i]
+	}
+	return command, fields[1:]
+}
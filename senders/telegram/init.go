@@ -27,12 +27,13 @@ var (
 
 // Sender implements moira sender interface via telegram
 type Sender struct {
-	DataBase moira.Database
-	APIToken string
-	FrontURI string
-	logger   moira.Logger
-	bot      *telebot.Bot
-	location *time.Location
+	DataBase    moira.Database
+	APIToken    string
+	FrontURI    string
+	contactType string
+	logger      moira.Logger
+	bot         *telebot.Bot
+	location    *time.Location
 }
 
 // Init loads yaml config, configures and starts telegram bot
@@ -43,6 +44,7 @@ func (sender *Sender) Init(senderSettings map[string]string, logger moira.Logger
 		return fmt.Errorf("can not read telegram api_token from config")
 	}
 	sender.FrontURI = senderSettings["front_uri"]
+	sender.contactType = senderSettings["type"]
 	sender.logger = logger
 	sender.location = location
 
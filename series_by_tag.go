@@ -0,0 +1,233 @@
+package moira
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const seriesByTagFunction = "seriesByTag"
+
+// MetricNameTag is reserved tag that matches path part of graphite tagged series
+const MetricNameTag = "name"
+
+// TagSpecOperator is an operator of seriesByTag tag expression
+type TagSpecOperator string
+
+// Supported seriesByTag tag expression operators
+const (
+	EqualOperator    TagSpecOperator = "="
+	NotEqualOperator TagSpecOperator = "!="
+	MatchOperator    TagSpecOperator = "=~"
+	NotMatchOperator TagSpecOperator = "!=~"
+)
+
+// TagSpec represents single tag expression of seriesByTag pattern, like 'dc=eu' or 'host=~web.*'
+type TagSpec struct {
+	Name     string
+	Operator TagSpecOperator
+	Value    string
+}
+
+func (spec TagSpec) String() string {
+	return fmt.Sprintf("%s%s%s", spec.Name, spec.Operator, spec.Value)
+}
+
+// IsSeriesByTagPattern checks that pattern is graphite seriesByTag('tag=value',...) call
+func IsSeriesByTagPattern(pattern string) bool {
+	return strings.HasPrefix(pattern, seriesByTagFunction+"(")
+}
+
+// IsTaggedMetric checks that metric is graphite tagged series like "name;tag=value"
+func IsTaggedMetric(metric string) bool {
+	return strings.Contains(metric, ";")
+}
+
+// ParseSeriesByTag parses seriesByTag('tag=value',...) pattern into the list of tag expressions
+func ParseSeriesByTag(pattern string) ([]TagSpec, error) {
+	if !IsSeriesByTagPattern(pattern) || !strings.HasSuffix(pattern, ")") {
+		return nil, fmt.Errorf("'%s' is not a seriesByTag pattern", pattern)
+	}
+	arguments, err := parseQuotedArguments(pattern[len(seriesByTagFunction)+1 : len(pattern)-1])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse '%s': %s", pattern, err.Error())
+	}
+	if len(arguments) == 0 {
+		return nil, fmt.Errorf("failed to parse '%s': no tag expressions", pattern)
+	}
+	tagSpecs := make([]TagSpec, 0, len(arguments))
+	hasPositive := false
+	for _, argument := range arguments {
+		tagSpec, err := parseTagSpec(argument)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse '%s': %s", pattern, err.Error())
+		}
+		if (tagSpec.Operator == EqualOperator || tagSpec.Operator == MatchOperator) && tagSpec.Value != "" {
+			hasPositive = true
+		}
+		tagSpecs = append(tagSpecs, tagSpec)
+	}
+	if !hasPositive {
+		return nil, fmt.Errorf("failed to parse '%s': at least one tag expression must match non-empty value", pattern)
+	}
+	return tagSpecs, nil
+}
+
+// FormatSeriesByTag builds canonical seriesByTag pattern from given tag expressions
+func FormatSeriesByTag(tagSpecs []TagSpec) string {
+	arguments := make([]string, 0, len(tagSpecs))
+	for _, tagSpec := range tagSpecs {
+		arguments = append(arguments, fmt.Sprintf("'%s'", tagSpec))
+	}
+	return fmt.Sprintf("%s(%s)", seriesByTagFunction, strings.Join(arguments, ","))
+}
+
+// ParseTaggedMetric splits tagged series "name;tag1=value1;tag2=value2" into name and tags
+func ParseTaggedMetric(metric string) (string, map[string]string, error) {
+	parts := strings.Split(metric, ";")
+	name := parts[0]
+	if name == "" {
+		return "", nil, fmt.Errorf("tagged series name is empty: '%s'", metric)
+	}
+	tags := make(map[string]string, len(parts)-1)
+	for _, part := range parts[1:] {
+		index := strings.Index(part, "=")
+		if index < 1 || index == len(part)-1 {
+			return "", nil, fmt.Errorf("invalid tag '%s' in tagged series '%s'", part, metric)
+		}
+		tagName := part[:index]
+		if tagName == MetricNameTag || strings.ContainsAny(tagName, "!~") {
+			return "", nil, fmt.Errorf("invalid tag name '%s' in tagged series '%s'", tagName, metric)
+		}
+		tags[tagName] = part[index+1:]
+	}
+	return name, tags, nil
+}
+
+// TaggedMetricName builds normalized tagged series with tags sorted by name
+func TaggedMetricName(name string, tags map[string]string) string {
+	tagNames := make([]string, 0, len(tags))
+	for tagName := range tags {
+		tagNames = append(tagNames, tagName)
+	}
+	sort.Strings(tagNames)
+	parts := make([]string, 0, len(tags)+1)
+	parts = append(parts, name)
+	for _, tagName := range tagNames {
+		parts = append(parts, fmt.Sprintf("%s=%s", tagName, tags[tagName]))
+	}
+	return strings.Join(parts, ";")
+}
+
+// CompileTagSpec creates function that checks tag value against given tag expression.
+// Absent tag is checked as empty value, so 'tag=' matches series without that tag
+func CompileTagSpec(tagSpec TagSpec) (func(value string) bool, error) {
+	switch tagSpec.Operator {
+	case EqualOperator:
+		return func(value string) bool { return value == tagSpec.Value }, nil
+	case NotEqualOperator:
+		return func(value string) bool { return value != tagSpec.Value }, nil
+	case MatchOperator, NotMatchOperator:
+		// Graphite matches regular expressions from the beginning of tag value
+		expression, err := regexp.Compile("^(?:" + tagSpec.Value + ")")
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression in '%s': %s", tagSpec, err.Error())
+		}
+		if tagSpec.Operator == MatchOperator {
+			return expression.MatchString, nil
+		}
+		return func(value string) bool { return !expression.MatchString(value) }, nil
+	}
+	return nil, fmt.Errorf("unknown operator in '%s'", tagSpec)
+}
+
+// SeriesByTagMatcher checks tagged series against all tag expressions of seriesByTag pattern
+type SeriesByTagMatcher struct {
+	tagNames []string
+	matchers []func(value string) bool
+}
+
+// NewSeriesByTagMatcher compiles given tag expressions into SeriesByTagMatcher
+func NewSeriesByTagMatcher(tagSpecs []TagSpec) (*SeriesByTagMatcher, error) {
+	matcher := &SeriesByTagMatcher{
+		tagNames: make([]string, 0, len(tagSpecs)),
+		matchers: make([]func(value string) bool, 0, len(tagSpecs)),
+	}
+	for _, tagSpec := range tagSpecs {
+		tagMatcher, err := CompileTagSpec(tagSpec)
+		if err != nil {
+			return nil, err
+		}
+		matcher.tagNames = append(matcher.tagNames, tagSpec.Name)
+		matcher.matchers = append(matcher.matchers, tagMatcher)
+	}
+	return matcher, nil
+}
+
+// Match returns true if series with given name and tags satisfies every tag expression
+func (matcher *SeriesByTagMatcher) Match(name string, tags map[string]string) bool {
+	for i, tagName := range matcher.tagNames {
+		value := name
+		if tagName != MetricNameTag {
+			value = tags[tagName]
+		}
+		if !matcher.matchers[i](value) {
+			return false
+		}
+	}
+	return true
+}
+
+func parseTagSpec(expression string) (TagSpec, error) {
+	index := strings.Index(expression, "=")
+	if index < 0 {
+		return TagSpec{}, fmt.Errorf("no operator in tag expression '%s'", expression)
+	}
+	tagSpec := TagSpec{
+		Name:     expression[:index],
+		Operator: EqualOperator,
+		Value:    expression[index+1:],
+	}
+	if strings.HasSuffix(tagSpec.Name, "!") {
+		tagSpec.Name = tagSpec.Name[:len(tagSpec.Name)-1]
+		tagSpec.Operator = NotEqualOperator
+	}
+	if strings.HasPrefix(tagSpec.Value, "~") {
+		tagSpec.Value = tagSpec.Value[1:]
+		tagSpec.Operator += "~"
+	}
+	if tagSpec.Name == "" {
+		return TagSpec{}, fmt.Errorf("empty tag name in tag expression '%s'", expression)
+	}
+	return tagSpec, nil
+}
+
+// parseQuotedArguments parses comma separated list of single or double quoted strings
+func parseQuotedArguments(arguments string) ([]string, error) {
+	result := make([]string, 0)
+	rest := strings.TrimSpace(arguments)
+	for rest != "" {
+		quote := rest[0]
+		if quote != '\'' && quote != '"' {
+			return nil, fmt.Errorf("argument must be quoted string: %s", rest)
+		}
+		end := strings.IndexByte(rest[1:], quote)
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated string: %s", rest)
+		}
+		result = append(result, rest[1:end+1])
+		rest = strings.TrimSpace(rest[end+2:])
+		if rest == "" {
+			break
+		}
+		if rest[0] != ',' {
+			return nil, fmt.Errorf("comma expected: %s", rest)
+		}
+		rest = strings.TrimSpace(rest[1:])
+		if rest == "" {
+			return nil, fmt.Errorf("argument expected after comma")
+		}
+	}
+	return result, nil
+}
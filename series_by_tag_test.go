@@ -0,0 +1,99 @@
+package moira
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseSeriesByTag(t *testing.T) {
+	Convey("Given valid seriesByTag patterns, should parse tag expressions", t, func() {
+		tagSpecs, err := ParseSeriesByTag(`seriesByTag('name=cpu.load', "dc!=eu",'host=~web.*' , 'rack!=~r1.*')`)
+		So(err, ShouldBeNil)
+		So(tagSpecs, ShouldResemble, []TagSpec{
+			{Name: "name", Operator: EqualOperator, Value: "cpu.load"},
+			{Name: "dc", Operator: NotEqualOperator, Value: "eu"},
+			{Name: "host", Operator: MatchOperator, Value: "web.*"},
+			{Name: "rack", Operator: NotMatchOperator, Value: "r1.*"},
+		})
+		So(FormatSeriesByTag(tagSpecs), ShouldEqual, "seriesByTag('name=cpu.load','dc!=eu','host=~web.*','rack!=~r1.*')")
+	})
+
+	Convey("Given invalid seriesByTag patterns, should return errors", t, func() {
+		invalidPatterns := []string{
+			"cpu.load",
+			"seriesByTag()",
+			"seriesByTag('name=cpu.load'",
+			"seriesByTag(name=cpu.load)",
+			"seriesByTag('name=cpu.load',)",
+			"seriesByTag('name=cpu.load' 'dc=eu')",
+			"seriesByTag('namecpu.load')",
+			"seriesByTag('=cpu.load')",
+			"seriesByTag('dc!=eu')",
+			"seriesByTag('dc=')",
+		}
+		for _, pattern := range invalidPatterns {
+			_, err := ParseSeriesByTag(pattern)
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
+
+func TestParseTaggedMetric(t *testing.T) {
+	Convey("Given valid tagged series, should parse and normalize it", t, func() {
+		name, tags, err := ParseTaggedMetric("cpu.load;host=web1;dc=eu")
+		So(err, ShouldBeNil)
+		So(name, ShouldEqual, "cpu.load")
+		So(tags, ShouldResemble, map[string]string{"host": "web1", "dc": "eu"})
+		So(TaggedMetricName(name, tags), ShouldEqual, "cpu.load;dc=eu;host=web1")
+	})
+
+	Convey("Given invalid tagged series, should return errors", t, func() {
+		invalidMetrics := []string{
+			";dc=eu",
+			"cpu.load;",
+			"cpu.load;dc",
+			"cpu.load;dc=",
+			"cpu.load;=eu",
+			"cpu.load;name=other",
+		}
+		for _, metric := range invalidMetrics {
+			_, _, err := ParseTaggedMetric(metric)
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
+
+func TestSeriesByTagMatcher(t *testing.T) {
+	tags := map[string]string{"dc": "eu", "host": "web1"}
+
+	Convey("Matcher checks all tag expressions", t, func() {
+		cases := []struct {
+			pattern string
+			match   bool
+		}{
+			{"seriesByTag('name=cpu.load')", true},
+			{"seriesByTag('name=cpu.load','dc=eu')", true},
+			{"seriesByTag('name=cpu.load','dc=us')", false},
+			{"seriesByTag('name=~cpu')", true},
+			{"seriesByTag('name=~load')", false},
+			{"seriesByTag('host=~web[0-9]','dc!=us')", true},
+			{"seriesByTag('host=~web[0-9]','dc!=~e')", false},
+			{"seriesByTag('name=cpu.load','rack=')", true},
+			{"seriesByTag('name=cpu.load','dc!=')", true},
+			{"seriesByTag('name=cpu.load','rack!=')", false},
+		}
+		for _, testCase := range cases {
+			tagSpecs, err := ParseSeriesByTag(testCase.pattern)
+			So(err, ShouldBeNil)
+			matcher, err := NewSeriesByTagMatcher(tagSpecs)
+			So(err, ShouldBeNil)
+			So(matcher.Match("cpu.load", tags), ShouldEqual, testCase.match)
+		}
+	})
+
+	Convey("Invalid regular expression should return error", t, func() {
+		_, err := NewSeriesByTagMatcher([]TagSpec{{Name: "host", Operator: MatchOperator, Value: "web[0-9"}})
+		So(err, ShouldNotBeNil)
+	})
+}
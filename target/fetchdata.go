@@ -2,15 +2,17 @@ package target
 
 import (
 	"math"
+	"time"
 
 	"github.com/go-graphite/carbonapi/expr/types"
 	pb "github.com/go-graphite/carbonzipper/carbonzipperpb3"
 	"github.com/moira-alert/moira"
 )
 
-// FetchData gets values of given pattern metrics from given interval and returns values and all found pattern metrics
-func FetchData(database moira.Database, pattern string, from int64, until int64, allowRealTimeAlerting bool) ([]*types.MetricData, []string, error) {
-	metrics, err := database.GetPatternMetrics(pattern)
+// FetchData gets values of given pattern metrics from given interval and returns values and all found pattern metrics.
+// Values of the finest archive are kept by checker for metricsTTL seconds, zero metricsTTL means they are not removed
+func FetchData(database moira.Database, pattern string, from int64, until int64, allowRealTimeAlerting bool, metricsTTL int64) ([]*types.MetricData, []string, error) {
+	metrics, err := getPatternMetrics(database, pattern)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -18,11 +20,18 @@ func FetchData(database moira.Database, pattern string, from int64, until int64,
 
 	if len(metrics) > 0 {
 		firstMetric := metrics[0]
-		retention, err := database.GetMetricRetention(firstMetric)
+		archives, err := database.GetMetricArchives(firstMetric)
 		if err != nil {
 			return nil, nil, err
 		}
-		dataList, err := database.GetMetricsValues(metrics, from, until)
+		archive := selectArchive(archives, from, time.Now().Unix(), metricsTTL)
+		retention := archives[archive].Precision
+		var dataList map[string][]*moira.MetricValue
+		if archive == 0 {
+			dataList, err = database.GetMetricsValues(metrics, from, until)
+		} else {
+			dataList, err = database.GetMetricsArchiveValues(metrics, retention, from, until)
+		}
 		if err != nil {
 			return nil, nil, err
 		}
@@ -38,6 +47,34 @@ func FetchData(database moira.Database, pattern string, from int64, until int64,
 	return metricDatas, metrics, nil
 }
 
+// selectArchive returns index of the finest archive which still keeps values from given time
+// or the coarsest archive if none of them does. The first archive keeps values not longer than metricsTTL,
+// because checker removes older ones
+func selectArchive(archives []moira.MetricArchive, from int64, now int64, metricsTTL int64) int {
+	for i, archive := range archives {
+		duration := archive.Duration
+		if i == 0 && metricsTTL > 0 && (duration == 0 || duration > metricsTTL) {
+			duration = metricsTTL
+		}
+		if duration == 0 || from >= now-duration {
+			return i
+		}
+	}
+	return len(archives) - 1
+}
+
+// getPatternMetrics gets metrics matching graphite path pattern or seriesByTag pattern
+func getPatternMetrics(database moira.Database, pattern string) ([]string, error) {
+	if !moira.IsSeriesByTagPattern(pattern) {
+		return database.GetPatternMetrics(pattern)
+	}
+	tagSpecs, err := moira.ParseSeriesByTag(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return database.GetTaggedMetrics(tagSpecs)
+}
+
 func createMetricData(metric string, from int64, until int64, retention int64, values []float64) *types.MetricData {
 	fetchResponse := pb.FetchResponse{
 		Name:      metric,
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math"
 	"testing"
+	"time"
 
 	"github.com/go-graphite/carbonapi/expr/types"
 	pb "github.com/go-graphite/carbonzipper/carbonzipperpb3"
@@ -111,24 +112,24 @@ func TestFetchData(t *testing.T) {
 	Convey("Errors Test", t, func() {
 		Convey("GetPatternMetricsError", func() {
 			dataBase.EXPECT().GetPatternMetrics(pattern).Return(nil, patternErr)
-			metricData, metrics, err := FetchData(dataBase, pattern, from, until, true)
+			metricData, metrics, err := FetchData(dataBase, pattern, from, until, true, 0)
 			So(metricData, ShouldBeNil)
 			So(metrics, ShouldBeNil)
 			So(err, ShouldResemble, patternErr)
 		})
-		Convey("GetMetricRetentionError", func() {
+		Convey("GetMetricArchivesError", func() {
 			dataBase.EXPECT().GetPatternMetrics(pattern).Return([]string{metric}, nil)
-			dataBase.EXPECT().GetMetricRetention(metric).Return(int64(0), retentionErr)
-			metricData, metrics, err := FetchData(dataBase, pattern, from, until, true)
+			dataBase.EXPECT().GetMetricArchives(metric).Return(nil, retentionErr)
+			metricData, metrics, err := FetchData(dataBase, pattern, from, until, true, 0)
 			So(metricData, ShouldBeNil)
 			So(metrics, ShouldBeNil)
 			So(err, ShouldResemble, retentionErr)
 		})
 		Convey("GetMetricsValuesError", func() {
 			dataBase.EXPECT().GetPatternMetrics(pattern).Return([]string{metric}, nil)
-			dataBase.EXPECT().GetMetricRetention(metric).Return(retention, nil)
+			dataBase.EXPECT().GetMetricArchives(metric).Return([]moira.MetricArchive{{Precision: retention}}, nil)
 			dataBase.EXPECT().GetMetricsValues([]string{metric}, from, until).Return(nil, metricErr)
-			metricData, metrics, err := FetchData(dataBase, pattern, from, until, true)
+			metricData, metrics, err := FetchData(dataBase, pattern, from, until, true, 0)
 			So(metricData, ShouldBeNil)
 			So(metrics, ShouldBeNil)
 			So(err, ShouldResemble, metricErr)
@@ -137,7 +138,7 @@ func TestFetchData(t *testing.T) {
 
 	Convey("Test no metrics", t, func() {
 		dataBase.EXPECT().GetPatternMetrics(pattern).Return([]string{}, nil)
-		metricData, metrics, err := FetchData(dataBase, pattern, from, until, false)
+		metricData, metrics, err := FetchData(dataBase, pattern, from, until, false, 0)
 		fetchResponse := pb.FetchResponse{
 			Name:      pattern,
 			StartTime: int32(from),
@@ -154,9 +155,9 @@ func TestFetchData(t *testing.T) {
 
 	Convey("Test allowRealTimeAlerting=true", t, func() {
 		dataBase.EXPECT().GetPatternMetrics(pattern).Return([]string{metric}, nil)
-		dataBase.EXPECT().GetMetricRetention(metric).Return(retention, nil)
+		dataBase.EXPECT().GetMetricArchives(metric).Return([]moira.MetricArchive{{Precision: retention}}, nil)
 		dataBase.EXPECT().GetMetricsValues([]string{metric}, from, until).Return(dataList, nil)
-		metricData, metrics, err := FetchData(dataBase, pattern, from, until, false)
+		metricData, metrics, err := FetchData(dataBase, pattern, from, until, false, 0)
 		fetchResponse := pb.FetchResponse{
 			Name:      metric,
 			StartTime: int32(from),
@@ -173,9 +174,9 @@ func TestFetchData(t *testing.T) {
 
 	Convey("Test allowRealTimeAlerting=true", t, func() {
 		dataBase.EXPECT().GetPatternMetrics(pattern).Return([]string{metric}, nil)
-		dataBase.EXPECT().GetMetricRetention(metric).Return(retention, nil)
+		dataBase.EXPECT().GetMetricArchives(metric).Return([]moira.MetricArchive{{Precision: retention}}, nil)
 		dataBase.EXPECT().GetMetricsValues([]string{metric}, from, until).Return(dataList, nil)
-		metricData, metrics, err := FetchData(dataBase, pattern, from, until, true)
+		metricData, metrics, err := FetchData(dataBase, pattern, from, until, true, 0)
 		fetchResponse := pb.FetchResponse{
 			Name:      metric,
 			StartTime: int32(from),
@@ -221,9 +222,9 @@ func TestFetchData(t *testing.T) {
 
 	Convey("Test multiple metrics", t, func() {
 		dataBase.EXPECT().GetPatternMetrics(pattern).Return([]string{metric, metric2}, nil)
-		dataBase.EXPECT().GetMetricRetention(metric).Return(retention, nil)
+		dataBase.EXPECT().GetMetricArchives(metric).Return([]moira.MetricArchive{{Precision: retention}}, nil)
 		dataBase.EXPECT().GetMetricsValues([]string{metric, metric2}, from, until).Return(dataList, nil)
-		metricData, metrics, err := FetchData(dataBase, pattern, from, until, true)
+		metricData, metrics, err := FetchData(dataBase, pattern, from, until, true, 0)
 		fetchResponse := pb.FetchResponse{
 			Name:      metric,
 			StartTime: int32(from),
@@ -240,9 +241,81 @@ func TestFetchData(t *testing.T) {
 		So(err, ShouldBeNil)
 	})
 
+	Convey("Test seriesByTag pattern", t, func() {
+		taggedPattern := "seriesByTag('name=super-puper-metric','dc=eu')"
+		taggedMetric := "super-puper-metric;dc=eu"
+		tagSpecs := []moira.TagSpec{
+			{Name: "name", Operator: moira.EqualOperator, Value: "super-puper-metric"},
+			{Name: "dc", Operator: moira.EqualOperator, Value: "eu"},
+		}
+		dataBase.EXPECT().GetTaggedMetrics(tagSpecs).Return([]string{taggedMetric}, nil)
+		dataBase.EXPECT().GetMetricArchives(taggedMetric).Return([]moira.MetricArchive{{Precision: retention}}, nil)
+		dataBase.EXPECT().GetMetricsValues([]string{taggedMetric}, from, until).Return(map[string][]*moira.MetricValue{taggedMetric: dataList[metric]}, nil)
+		metricData, metrics, err := FetchData(dataBase, taggedPattern, from, until, true, 0)
+		fetchResponse := pb.FetchResponse{
+			Name:      taggedMetric,
+			StartTime: int32(from),
+			StopTime:  int32(until),
+			StepTime:  int32(retention),
+			Values:    []float64{0, 1, 2, 3, 4},
+			IsAbsent:  make([]bool, 5),
+		}
+		expected := &types.MetricData{FetchResponse: fetchResponse}
+		So(metricData, ShouldResemble, []*types.MetricData{expected})
+		So(metrics, ShouldResemble, []string{taggedMetric})
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Test coarser archive for old values", t, func() {
+		now := time.Now().Unix()
+		archives := []moira.MetricArchive{{Precision: 10, Duration: 3600}, {Precision: 600, Duration: 86400 * 7}}
+		archiveFrom := now - 86400
+		archiveUntil := archiveFrom + 1800
+		archiveFrom = archiveFrom - archiveFrom%600
+		archiveData := map[string][]*moira.MetricValue{
+			metric: {
+				{RetentionTimestamp: archiveFrom, Timestamp: archiveFrom + 590, Value: 1},
+				{RetentionTimestamp: archiveFrom + 1200, Timestamp: archiveFrom + 1210, Value: 3},
+			},
+		}
+		dataBase.EXPECT().GetPatternMetrics(pattern).Return([]string{metric}, nil)
+		dataBase.EXPECT().GetMetricArchives(metric).Return(archives, nil)
+		dataBase.EXPECT().GetMetricsArchiveValues([]string{metric}, int64(600), archiveFrom, archiveUntil).Return(archiveData, nil)
+		metricData, metrics, err := FetchData(dataBase, pattern, archiveFrom, archiveUntil, false, 0)
+		So(err, ShouldBeNil)
+		So(metrics, ShouldResemble, []string{metric})
+		So(metricData[0].StepTime, ShouldEqual, 600)
+		So(metricData[0].Values[0], ShouldEqual, 1)
+		So(math.IsNaN(metricData[0].Values[1]), ShouldBeTrue)
+		So(metricData[0].Values[2], ShouldEqual, 3)
+	})
+
 	mockCtrl.Finish()
 }
 
+func TestSelectArchive(t *testing.T) {
+	archives := []moira.MetricArchive{{Precision: 10, Duration: 3600}, {Precision: 60, Duration: 86400}, {Precision: 600, Duration: 86400 * 30}}
+	var now int64 = 86400 * 100
+
+	Convey("Should select the finest archive keeping values from given time", t, func() {
+		So(selectArchive(archives, now-600, now, 0), ShouldEqual, 0)
+		So(selectArchive(archives, now-3600, now, 0), ShouldEqual, 0)
+		So(selectArchive(archives, now-7200, now, 0), ShouldEqual, 1)
+		So(selectArchive(archives, now-86400*7, now, 0), ShouldEqual, 2)
+		So(selectArchive(archives, now-86400*60, now, 0), ShouldEqual, 2)
+	})
+
+	Convey("Should not select the first archive for values removed after metrics TTL", t, func() {
+		So(selectArchive(archives, now-1800, now, 1800), ShouldEqual, 0)
+		So(selectArchive(archives, now-3600, now, 1800), ShouldEqual, 1)
+		So(selectArchive([]moira.MetricArchive{{Precision: 10}, {Precision: 60}}, now-3600, now, 1800), ShouldEqual, 1)
+	})
+
+	Convey("Should select single archive without duration", t, func() {
+		So(selectArchive([]moira.MetricArchive{{Precision: 60}}, 0, now, 0), ShouldEqual, 0)
+	})
+}
+
 func TestAllowRealTimeAlerting(t *testing.T) {
 	metricsValues := []*moira.MetricValue{
 		{
@@ -0,0 +1,62 @@
+package target
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/moira-alert/moira"
+)
+
+const seriesByTagCall = "seriesByTag("
+
+// seriesByTagPlaceholderFormat is a plain metric name used instead of seriesByTag call
+// while target is parsed and evaluated by carbonapi, which knows nothing about tagged series
+const seriesByTagPlaceholderFormat = "moira.series_by_tag.%d"
+
+// replaceSeriesByTag replaces every seriesByTag call in target with placeholder metric name.
+// Returns rewritten target and map of placeholders to canonical seriesByTag patterns
+func replaceSeriesByTag(target string) (string, map[string]string, error) {
+	seriesByTagPatterns := make(map[string]string)
+	var rewritten bytes.Buffer
+	rest := target
+	for {
+		start := strings.Index(rest, seriesByTagCall)
+		if start < 0 {
+			rewritten.WriteString(rest)
+			break
+		}
+		end := findCallEnd(rest, start+len(seriesByTagCall))
+		if end < 0 {
+			return "", nil, fmt.Errorf("unclosed seriesByTag call in target '%s'", target)
+		}
+		tagSpecs, err := moira.ParseSeriesByTag(rest[start:end])
+		if err != nil {
+			return "", nil, err
+		}
+		placeholder := fmt.Sprintf(seriesByTagPlaceholderFormat, len(seriesByTagPatterns))
+		seriesByTagPatterns[placeholder] = moira.FormatSeriesByTag(tagSpecs)
+		rewritten.WriteString(rest[:start])
+		rewritten.WriteString(placeholder)
+		rest = rest[end:]
+	}
+	return rewritten.String(), seriesByTagPatterns, nil
+}
+
+// findCallEnd returns index right after closing parenthesis of call which arguments start at given position
+func findCallEnd(target string, argumentsStart int) int {
+	var quote byte
+	for i := argumentsStart; i < len(target); i++ {
+		switch c := target[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ')':
+			return i + 1
+		}
+	}
+	return -1
+}
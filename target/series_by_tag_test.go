@@ -0,0 +1,38 @@
+package target
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestReplaceSeriesByTag(t *testing.T) {
+	Convey("Target without seriesByTag should not be changed", t, func() {
+		target, patterns, err := replaceSeriesByTag("sumSeries(super.puper.*)")
+		So(err, ShouldBeNil)
+		So(target, ShouldEqual, "sumSeries(super.puper.*)")
+		So(patterns, ShouldBeEmpty)
+	})
+
+	Convey("Every seriesByTag call should be replaced with placeholder", t, func() {
+		target, patterns, err := replaceSeriesByTag(`divideSeries(seriesByTag('name=disk.used', "dc=eu"), seriesByTag('name=disk.total','dc=(eu)'))`)
+		So(err, ShouldBeNil)
+		So(target, ShouldEqual, "divideSeries(moira.series_by_tag.0, moira.series_by_tag.1)")
+		So(patterns, ShouldResemble, map[string]string{
+			"moira.series_by_tag.0": "seriesByTag('name=disk.used','dc=eu')",
+			"moira.series_by_tag.1": "seriesByTag('name=disk.total','dc=(eu)')",
+		})
+	})
+
+	Convey("Invalid seriesByTag calls should return error", t, func() {
+		invalidTargets := []string{
+			"sumSeries(seriesByTag('name=disk.used'",
+			"seriesByTag('dc!=eu')",
+			"seriesByTag(disk.used)",
+		}
+		for _, invalidTarget := range invalidTargets {
+			_, _, err := replaceSeriesByTag(invalidTarget)
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
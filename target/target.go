@@ -18,8 +18,9 @@ type EvaluationResult struct {
 	Metrics    []string
 }
 
-// EvaluateTarget is analogue of evaluateTarget method in graphite-web, that gets target metrics value from DB and Evaluate it using carbon-api eval package
-func EvaluateTarget(database moira.Database, target string, from int64, until int64, allowRealTimeAlerting bool) (*EvaluationResult, error) {
+// EvaluateTarget is analogue of evaluateTarget method in graphite-web, that gets target metrics value from DB and Evaluate it using carbon-api eval package.
+// Values of the finest archive are kept by checker for metricsTTL seconds, zero metricsTTL means they are not removed
+func EvaluateTarget(database moira.Database, target string, from int64, until int64, allowRealTimeAlerting bool, metricsTTL int64) (*EvaluationResult, error) {
 	result := &EvaluationResult{
 		TimeSeries: make([]*TimeSeries, 0),
 		Patterns:   make([]string, 0),
@@ -31,7 +32,14 @@ func EvaluateTarget(database moira.Database, target string, from int64, until in
 	for targetIdx < len(targets) {
 		target := targets[targetIdx]
 		targetIdx++
-		expr2, _, err := parser.ParseExpr(target)
+		rewrittenTarget, seriesByTagPatterns, err := replaceSeriesByTag(target)
+		if err != nil {
+			return nil, ErrParseExpr{
+				internalError: err,
+				target:        target,
+			}
+		}
+		expr2, _, err := parser.ParseExpr(rewrittenTarget)
 		if err != nil {
 			return nil, ErrParseExpr{
 				internalError: err,
@@ -39,7 +47,7 @@ func EvaluateTarget(database moira.Database, target string, from int64, until in
 			}
 		}
 		patterns := expr2.Metrics()
-		metricsMap, metrics, err := getPatternsMetricData(database, patterns, from, until, allowRealTimeAlerting)
+		metricsMap, metrics, err := getPatternsMetricData(database, patterns, seriesByTagPatterns, from, until, allowRealTimeAlerting, metricsTTL)
 		if err != nil {
 			return nil, err
 		}
@@ -83,20 +91,20 @@ func EvaluateTarget(database moira.Database, target string, from int64, until in
 			}
 			result.Metrics = append(result.Metrics, metrics...)
 			for _, pattern := range patterns {
-				result.Patterns = append(result.Patterns, pattern.Metric)
+				result.Patterns = append(result.Patterns, resolvePattern(pattern.Metric, seriesByTagPatterns))
 			}
 		}
 	}
 	return result, nil
 }
 
-func getPatternsMetricData(database moira.Database, patterns []parser.MetricRequest, from int64, until int64, allowRealTimeAlerting bool) (map[parser.MetricRequest][]*types.MetricData, []string, error) {
+func getPatternsMetricData(database moira.Database, patterns []parser.MetricRequest, seriesByTagPatterns map[string]string, from int64, until int64, allowRealTimeAlerting bool, metricsTTL int64) (map[parser.MetricRequest][]*types.MetricData, []string, error) {
 	metrics := make([]string, 0)
 	metricsMap := make(map[parser.MetricRequest][]*types.MetricData)
 	for _, pattern := range patterns {
 		pattern.From += int32(from)
 		pattern.Until += int32(until)
-		metricDatas, patternMetrics, err := FetchData(database, pattern.Metric, int64(pattern.From), int64(pattern.Until), allowRealTimeAlerting)
+		metricDatas, patternMetrics, err := FetchData(database, resolvePattern(pattern.Metric, seriesByTagPatterns), int64(pattern.From), int64(pattern.Until), allowRealTimeAlerting, metricsTTL)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -105,3 +113,11 @@ func getPatternsMetricData(database moira.Database, patterns []parser.MetricRequ
 	}
 	return metricsMap, metrics, nil
 }
+
+// resolvePattern returns seriesByTag pattern if given metric request is its placeholder
+func resolvePattern(metric string, seriesByTagPatterns map[string]string) string {
+	if pattern, ok := seriesByTagPatterns[metric]; ok {
+		return pattern
+	}
+	return metric
+}
@@ -62,7 +62,7 @@ func TestEvaluateTarget(t *testing.T) {
 
 	Convey("Errors tests", t, func() {
 		Convey("Error while ParseExpr", func() {
-			result, err := EvaluateTarget(dataBase, "", from, until, true)
+			result, err := EvaluateTarget(dataBase, "", from, until, true, 0)
 			So(err, ShouldResemble, ErrParseExpr{target: "", internalError: parser.ErrMissingExpr})
 			So(err.Error(), ShouldResemble, "failed to parse target '': missing expression")
 			So(result, ShouldBeNil)
@@ -70,18 +70,18 @@ func TestEvaluateTarget(t *testing.T) {
 
 		Convey("Error in fetch data", func() {
 			dataBase.EXPECT().GetPatternMetrics(pattern).Return([]string{metric}, nil)
-			dataBase.EXPECT().GetMetricRetention(metric).Return(retention, nil)
+			dataBase.EXPECT().GetMetricArchives(metric).Return([]moira.MetricArchive{{Precision: retention}}, nil)
 			dataBase.EXPECT().GetMetricsValues([]string{metric}, from, until).Return(nil, metricErr)
-			result, err := EvaluateTarget(dataBase, "super.puper.pattern", from, until, true)
+			result, err := EvaluateTarget(dataBase, "super.puper.pattern", from, until, true, 0)
 			So(err, ShouldResemble, metricErr)
 			So(result, ShouldBeNil)
 		})
 
 		Convey("Error evaluate target", func() {
 			dataBase.EXPECT().GetPatternMetrics("super.puper.pattern").Return([]string{metric}, nil)
-			dataBase.EXPECT().GetMetricRetention(metric).Return(retention, nil)
+			dataBase.EXPECT().GetMetricArchives(metric).Return([]moira.MetricArchive{{Precision: retention}}, nil)
 			dataBase.EXPECT().GetMetricsValues([]string{metric}, from, until).Return(dataList, nil)
-			result, err := EvaluateTarget(dataBase, "aliasByNoe(super.puper.pattern, 2)", from, until, true)
+			result, err := EvaluateTarget(dataBase, "aliasByNoe(super.puper.pattern, 2)", from, until, true, 0)
 			So(err.Error(), ShouldResemble, "Unknown graphite function: \"aliasByNoe\"")
 			So(result, ShouldBeNil)
 		})
@@ -89,7 +89,7 @@ func TestEvaluateTarget(t *testing.T) {
 
 	Convey("Test no metrics", t, func() {
 		dataBase.EXPECT().GetPatternMetrics("super.puper.pattern").Return([]string{}, nil)
-		result, err := EvaluateTarget(dataBase, "aliasByNode(super.puper.pattern, 2)", from, until, true)
+		result, err := EvaluateTarget(dataBase, "aliasByNode(super.puper.pattern, 2)", from, until, true, 0)
 		So(err, ShouldBeNil)
 		fetchResponse := pb.FetchResponse{
 			Name:      "pattern",
@@ -111,9 +111,9 @@ func TestEvaluateTarget(t *testing.T) {
 
 	Convey("Test success evaluate", t, func() {
 		dataBase.EXPECT().GetPatternMetrics("super.puper.pattern").Return([]string{metric}, nil)
-		dataBase.EXPECT().GetMetricRetention(metric).Return(retention, nil)
+		dataBase.EXPECT().GetMetricArchives(metric).Return([]moira.MetricArchive{{Precision: retention}}, nil)
 		dataBase.EXPECT().GetMetricsValues([]string{metric}, from, until).Return(dataList, nil)
-		result, err := EvaluateTarget(dataBase, "aliasByNode(super.puper.pattern, 2)", from, until, true)
+		result, err := EvaluateTarget(dataBase, "aliasByNode(super.puper.pattern, 2)", from, until, true, 0)
 		fetchResponse := pb.FetchResponse{
 			Name:      "metric",
 			StartTime: int32(from),
@@ -134,9 +134,9 @@ func TestEvaluateTarget(t *testing.T) {
 
 	Convey("Test success evaluate pipe target", t, func() {
 		dataBase.EXPECT().GetPatternMetrics("super.puper.pattern").Return([]string{metric}, nil)
-		dataBase.EXPECT().GetMetricRetention(metric).Return(retention, nil)
+		dataBase.EXPECT().GetMetricArchives(metric).Return([]moira.MetricArchive{{Precision: retention}}, nil)
 		dataBase.EXPECT().GetMetricsValues([]string{metric}, from, until).Return(dataList, nil)
-		result, err := EvaluateTarget(dataBase, "super.puper.pattern | scale(100) | aliasByNode(2)", from, until, true)
+		result, err := EvaluateTarget(dataBase, "super.puper.pattern | scale(100) | aliasByNode(2)", from, until, true, 0)
 		fetchResponse := pb.FetchResponse{
 			Name:      "metric",
 			StartTime: int32(from),